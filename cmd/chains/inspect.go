@@ -0,0 +1,136 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/chains/pkg/chains"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func inspectCommand(o *clientOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inspect",
+		Short: "Show the signatures and provenance Chains stored",
+	}
+	cmd.AddCommand(inspectTaskRunCommand(o))
+	return cmd
+}
+
+func inspectTaskRunCommand(o *clientOptions) *cobra.Command {
+	var namespace, output string
+	ti := &chains.TaskRunInspector{}
+	cmd := &cobra.Command{
+		Use:   "taskrun NAME",
+		Short: "Decode the payloads, signatures and certificates stored for a TaskRun",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "text" && output != "json" {
+				return fmt.Errorf("unsupported output %q, must be text or json", output)
+			}
+			ctx := cmd.Context()
+			kc, pc, dc, err := o.clients()
+			if err != nil {
+				return err
+			}
+			ctx, err = o.chainsContext(ctx, kc, dc, namespace)
+			if err != nil {
+				return err
+			}
+			tr, err := pc.TektonV1beta1().TaskRuns(namespace).Get(ctx, args[0], metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			ti.KubeClient = kc
+			ti.Pipelineclientset = pc
+			result, err := ti.InspectTaskRun(ctx, tr)
+			if err != nil {
+				return err
+			}
+			if output == "json" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(result)
+			}
+			printInspection(cmd.OutOrStdout(), result)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "default", "namespace of the TaskRun")
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "output format, text or json")
+	cmd.Flags().StringSliceVar(&ti.Backends, "backend", nil, "only read from these storage backends, defaults to all configured ones")
+	cmd.Flags().BoolVar(&ti.FetchTransparency, "tlog", false, "fetch the transparency log entry recorded on the TaskRun")
+	return cmd
+}
+
+func printInspection(w io.Writer, result *chains.TaskRunInspection) {
+	fmt.Fprintf(w, "TaskRun:      %s/%s\n", result.Namespace, result.Name)
+	fmt.Fprintf(w, "Signed:       %t\n", result.Signed)
+	if result.Transparency != "" {
+		fmt.Fprintf(w, "Transparency: %s\n", result.Transparency)
+	}
+	if len(result.TransparencyEntry) > 0 {
+		fmt.Fprintf(w, "Transparency entry:\n%s\n", indentJSON(result.TransparencyEntry, "  "))
+	}
+	if len(result.Artifacts) == 0 {
+		fmt.Fprintln(w, "\nNo signed artifacts found.")
+	}
+
+	for _, a := range result.Artifacts {
+		fmt.Fprintf(w, "\nArtifact:     %s %s\n", a.Type, a.Ref)
+		fmt.Fprintf(w, "Backend:      %s\n", a.Backend)
+		fmt.Fprintf(w, "Format:       %s\n", a.PayloadFormat)
+		if a.PayloadType != "" {
+			fmt.Fprintf(w, "Payload type: %s\n", a.PayloadType)
+		}
+		fmt.Fprintln(w, "Signatures:")
+		for _, s := range a.Signatures {
+			if s.KeyID != "" {
+				fmt.Fprintf(w, "  - keyid: %s\n    sig:   %s\n", s.KeyID, s.Signature)
+			} else {
+				fmt.Fprintf(w, "  - %s\n", s.Signature)
+			}
+		}
+		if len(a.Certificates) > 0 {
+			fmt.Fprintln(w, "Certificates:")
+			for _, c := range a.Certificates {
+				fmt.Fprintf(w, "  - subject:    %s\n", c.Subject)
+				fmt.Fprintf(w, "    issuer:     %s\n", c.Issuer)
+				if c.OIDCIssuer != "" {
+					fmt.Fprintf(w, "    oidc:       %s\n", c.OIDCIssuer)
+				}
+				fmt.Fprintf(w, "    serial:     %s\n", c.Serial)
+				fmt.Fprintf(w, "    valid:      %s - %s\n", c.NotBefore.Format(time.RFC3339), c.NotAfter.Format(time.RFC3339))
+			}
+		}
+		if len(a.Payload) > 0 {
+			fmt.Fprintf(w, "Payload:\n%s\n", indentJSON(a.Payload, "  "))
+		}
+	}
+}
+
+func indentJSON(raw json.RawMessage, prefix string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, raw, prefix, "  "); err != nil {
+		return prefix + strings.TrimSpace(string(raw))
+	}
+	return prefix + buf.String()
+}
@@ -0,0 +1,356 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/cosign/pkg/oci"
+	sigs "github.com/sigstore/cosign/pkg/signature"
+	"github.com/sigstore/rekor/pkg/client"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	"github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/config"
+	versioned "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"knative.dev/pkg/logging"
+)
+
+// clientOptions holds the flags needed to talk to the cluster Chains runs in.
+type clientOptions struct {
+	kubeconfig      string
+	context         string
+	chainsNamespace string
+	verbose         bool
+}
+
+func (o *clientOptions) addFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&o.kubeconfig, "kubeconfig", "", "path to the kubeconfig file, defaults to the standard loading rules")
+	cmd.PersistentFlags().StringVar(&o.context, "context", "", "kubeconfig context to use")
+	cmd.PersistentFlags().StringVar(&o.chainsNamespace, "chains-namespace", "tekton-chains", "namespace Chains is installed in")
+	cmd.PersistentFlags().BoolVarP(&o.verbose, "verbose", "v", false, "log progress to stderr")
+}
+
+func (o *clientOptions) clients() (kubernetes.Interface, versioned.Interface, dynamic.Interface, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = o.kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: o.context}
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "loading kubeconfig")
+	}
+	kc, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	pc, err := versioned.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	dc, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return kc, pc, dc, nil
+}
+
+// chainsContext returns a context carrying the chains-config of the cluster, overridden by the
+// chains-config of namespace if it is allowed to, and a logger. The chains-config ChainsConfig
+// replaces the chains-config ConfigMap if it exists, like it does in the controller.
+func (o *clientOptions) chainsContext(ctx context.Context, kc kubernetes.Interface, dc dynamic.Interface, namespace string) (context.Context, error) {
+	cfg, err := o.resourceConfig(ctx, dc)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading chains config")
+	}
+	if cfg == nil {
+		cm, err := kc.CoreV1().ConfigMaps(o.chainsNamespace).Get(ctx, config.ChainsConfig, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			cfg, err = config.NewConfigFromMap(map[string]string{})
+		case err == nil:
+			cfg, err = config.NewConfigFromConfigMap(cm)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "loading chains config")
+		}
+	}
+	if cfg.NamespaceOverrides.AllowedKeys.Len() > 0 && namespace != o.chainsNamespace {
+		cm, err := kc.CoreV1().ConfigMaps(namespace).Get(ctx, config.ChainsConfig, metav1.GetOptions{})
+		switch {
+		case err == nil:
+			if cfg, err = cfg.WithOverrides(cm.Data); err != nil {
+				return nil, errors.Wrapf(err, "overriding chains config with %s/%s", namespace, config.ChainsConfig)
+			}
+		case !apierrors.IsNotFound(err):
+			return nil, errors.Wrap(err, "loading namespace chains config")
+		}
+	}
+	return logging.WithLogger(config.ToContext(ctx, cfg), o.logger()), nil
+}
+
+// resourceConfig returns the configuration of the chains-config ChainsConfig, or nil if it doesn't
+// exist.
+func (o *clientOptions) resourceConfig(ctx context.Context, dc dynamic.Interface) (*config.Config, error) {
+	resource := v1alpha1.SchemeGroupVersion.WithResource("chainsconfigs")
+	u, err := dc.Resource(resource).Namespace(o.chainsNamespace).Get(ctx, v1alpha1.ChainsConfigName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// Also when the ChainsConfig CRD isn't installed.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cc := &v1alpha1.ChainsConfig{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, cc); err != nil {
+		return nil, err
+	}
+	return config.NewConfigFromResource(ctx, cc)
+}
+
+func (o *clientOptions) logger() *zap.SugaredLogger {
+	if !o.verbose {
+		return zap.NewNop().Sugar()
+	}
+	cfg := zap.NewDevelopmentConfig()
+	cfg.DisableStacktrace = true
+	l, err := cfg.Build()
+	if err != nil {
+		return zap.NewNop().Sugar()
+	}
+	return l.Sugar()
+}
+
+// verifyOptions holds the flags selecting what to trust when verifying.
+type verifyOptions struct {
+	key        string
+	roots      string
+	identities []string
+	rsaScheme  string
+}
+
+func (v *verifyOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&v.key, "key", "", "public key to verify with: a file, KMS URI or k8s://namespace/secret")
+	cmd.Flags().StringVar(&v.roots, "roots", "", "PEM file with the roots trusted to issue signing certificates")
+	cmd.Flags().StringSliceVar(&v.identities, "certificate-identity", nil, "email, URI or DNS SAN that signing certificates must have, required with --roots")
+	cmd.Flags().StringVar(&v.rsaScheme, "rsa-scheme", signing.RSASchemePKCS1v15, "signature scheme of RSA keys, pkcs1v15 or pss")
+}
+
+func (v *verifyOptions) verifier(ctx context.Context) (signature.Verifier, error) {
+	if v.key == "" {
+		return nil, nil
+	}
+	verifier, err := sigs.PublicKeyFromKeyRef(ctx, v.key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading key %s", v.key)
+	}
+	pub, err := verifier.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	// Keys are loaded for PKCS#1 v1.5, switch to PSS if asked to.
+	if _, ok := pub.(*rsa.PublicKey); ok && v.rsaScheme != signing.RSASchemePKCS1v15 {
+		return signing.LoadVerifier(pub, v.rsaScheme)
+	}
+	return verifier, nil
+}
+
+func (v *verifyOptions) certPool() (*x509.CertPool, error) {
+	if v.roots == "" {
+		return nil, nil
+	}
+	pool, err := loadCertPool(v.roots)
+	if err != nil {
+		return nil, err
+	}
+	if len(v.identities) == 0 {
+		return nil, errors.New("--certificate-identity is required with --roots")
+	}
+	return pool, nil
+}
+
+// loadCertPool returns a pool of the PEM encoded certificates in path, or nil if path is empty.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	if path == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(raw) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// checkIdentity checks that the certificate of sig, if it has one, was issued to one of the
+// trusted identities.
+func (v *verifyOptions) checkIdentity(sig oci.Signature) error {
+	cert, err := sig.Cert()
+	if err != nil || cert == nil {
+		return err
+	}
+	for _, id := range signing.CertIdentities(cert) {
+		for _, want := range v.identities {
+			if id == want {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("certificate identities %v are not trusted", signing.CertIdentities(cert))
+}
+
+func verifyCommand(o *clientOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify signatures produced by Chains",
+	}
+	cmd.AddCommand(verifyTaskRunCommand(o), verifyImageCommand())
+	return cmd
+}
+
+func verifyTaskRunCommand(o *clientOptions) *cobra.Command {
+	v := &verifyOptions{}
+	var namespace, timestampRoots string
+	cmd := &cobra.Command{
+		Use:   "taskrun NAME",
+		Short: "Verify the signatures stored for a TaskRun in every configured storage backend",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			kc, pc, dc, err := o.clients()
+			if err != nil {
+				return err
+			}
+			ctx, err = o.chainsContext(ctx, kc, dc, namespace)
+			if err != nil {
+				return err
+			}
+			verifier, err := v.verifier(ctx)
+			if err != nil {
+				return err
+			}
+			roots, err := v.certPool()
+			if err != nil {
+				return err
+			}
+			// The keys of the controller's signers aren't available here.
+			if verifier == nil && roots == nil {
+				return errors.New("one of --key or --roots is required")
+			}
+			tsaRoots, err := loadCertPool(timestampRoots)
+			if err != nil {
+				return err
+			}
+			tr, err := pc.TektonV1beta1().TaskRuns(namespace).Get(ctx, args[0], metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			tv := &chains.TaskRunVerifier{
+				KubeClient:        kc,
+				Pipelineclientset: pc,
+				Verifier:          verifier,
+				Roots:             roots,
+				Identities:        v.identities,
+				TimestampRoots:    tsaRoots,
+			}
+			if err := tv.VerifyTaskRun(ctx, tr); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Verified TaskRun %s/%s\n", tr.Namespace, tr.Name)
+			return nil
+		},
+	}
+	v.addFlags(cmd)
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "default", "namespace of the TaskRun")
+	cmd.Flags().StringVar(&timestampRoots, "timestamp-roots", "", "PEM file with the roots trusted to issue timestamp authority certificates, to verify the stored timestamps")
+	return cmd
+}
+
+func verifyImageCommand() *cobra.Command {
+	v := &verifyOptions{}
+	var attestations bool
+	var rekorURL string
+	cmd := &cobra.Command{
+		Use:   "image REFERENCE",
+		Short: "Verify the signatures or attestations Chains pushed for an image",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			ref, err := name.ParseReference(args[0])
+			if err != nil {
+				return err
+			}
+			co := &cosign.CheckOpts{}
+			if co.SigVerifier, err = v.verifier(ctx); err != nil {
+				return err
+			}
+			if co.RootCerts, err = v.certPool(); err != nil {
+				return err
+			}
+			if co.SigVerifier == nil && co.RootCerts == nil {
+				return errors.New("one of --key or --roots is required")
+			}
+			// cosign only checks when certificates were valid against their transparency log entry.
+			if co.RootCerts != nil && rekorURL == "" {
+				return errors.New("--rekor-url is required with --roots")
+			}
+			if rekorURL != "" {
+				if co.RekorClient, err = client.GetRekorClient(rekorURL); err != nil {
+					return err
+				}
+			}
+
+			verify := cosign.VerifyImageSignatures
+			if attestations {
+				verify = cosign.VerifyImageAttestations
+			}
+			verified, _, err := verify(ctx, ref, co)
+			if err != nil {
+				return err
+			}
+			// cosign only matches email identities, so check them all here.
+			if co.RootCerts != nil {
+				for _, sig := range verified {
+					if err := v.checkIdentity(sig); err != nil {
+						return err
+					}
+				}
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Verified %d signature(s) for %s\n", len(verified), ref)
+			return nil
+		},
+	}
+	v.addFlags(cmd)
+	cmd.Flags().BoolVar(&attestations, "attestations", false, "verify attestations instead of signatures")
+	cmd.Flags().StringVar(&rekorURL, "rekor-url", "", "also verify transparency log inclusion against this Rekor server")
+	return cmd
+}
@@ -15,11 +15,15 @@ package main
 
 import (
 	"flag"
+	"log"
+	"strings"
 
 	"github.com/tektoncd/chains/pkg/reconciler/taskrun"
+	"k8s.io/apimachinery/pkg/labels"
 	"knative.dev/pkg/injection"
 	"knative.dev/pkg/injection/sharedmain"
 	"knative.dev/pkg/signals"
+	"knative.dev/pkg/system"
 
 	// Run with all of the upstream providers.
 	// We link this here to give downstreams greater choice/control over
@@ -28,11 +32,44 @@ import (
 	_ "github.com/sigstore/cosign/pkg/providers/all"
 )
 
-var namespace = flag.String("namespace", "", "Namespace to restrict informer to. Optional, defaults to all namespaces.")
+var (
+	namespace         = flag.String("namespace", "", "Namespace to restrict informer to, or a comma-separated list of namespaces. Optional, defaults to all namespaces.")
+	namespaceSelector = flag.String("namespace-selector", "", "Label selector of the namespaces to restrict informers to, in addition to -namespace. Optional.")
+	publicKeyAddress  = flag.String("public-key-address", ":8080", "Address to serve the generated public key on, when signers.x509.keys.generate is enabled. Not served if empty.")
+	probeAddress      = flag.String("probe-address", ":8081", "Address to serve the readiness probe on. Not served if empty.")
+)
 
 func main() {
 	flag.Parse()
-	ctx := injection.WithNamespaceScope(signals.NewContext(), *namespace)
+	opts := taskrun.Options{
+		PublicKeyAddress: *publicKeyAddress,
+		ProbeAddress:     *probeAddress,
+	}
+
+	var namespaces []string
+	for _, ns := range strings.Split(*namespace, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	scope := ""
+	if len(namespaces) == 1 {
+		scope = namespaces[0]
+	}
+	if len(namespaces) > 1 || *namespaceSelector != "" {
+		selector, err := labels.Parse(*namespaceSelector)
+		if err != nil {
+			log.Fatalf("Invalid -namespace-selector: %v", err)
+		}
+		if !selector.Empty() {
+			opts.NamespaceSelector = selector
+		}
+		opts.Namespaces = namespaces
+		// The controller watches each namespace with its own informers. The informers shared
+		// through the context only watch the namespace of the controller.
+		scope = system.Namespace()
+	}
+	ctx := injection.WithNamespaceScope(signals.NewContext(), scope)
 
-	sharedmain.MainWithContext(ctx, "watcher", taskrun.NewController)
+	sharedmain.MainWithContext(ctx, "watcher", taskrun.NewControllerWithOptions(opts))
 }
@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The signer command is a reference signer plugin for the remote signer. It signs
+// with the x509.pem or cosign.key in the signing secrets, so the key only needs to
+// be mounted into the plugin, in a sidecar or a separate deployment.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/tektoncd/chains/pkg/chains/signing/remote"
+	chainsx509 "github.com/tektoncd/chains/pkg/chains/signing/x509"
+	"github.com/tektoncd/chains/pkg/config"
+	"go.uber.org/zap"
+)
+
+var (
+	listen       = flag.String("listen", "unix:///var/run/chains-signer/signer.sock", "Address to listen on: unix://<path>, tcp://<loopback host>:<port> or tls://<host>:<port>.")
+	secretPath   = flag.String("secret-path", "/etc/signing-secrets", "Directory with the signing key, in the format of the Chains signing secrets.")
+	keyID        = flag.String("key-id", "", "ID of the named key to sign with. The unnamed key is used if not set.")
+	rsaScheme    = flag.String("rsa-scheme", "pkcs1v15", "Signature scheme of RSA keys, pkcs1v15 or pss.")
+	tlsCertFile  = flag.String("tls-cert-file", "", "PEM certificate to serve tls:// addresses with.")
+	tlsKeyFile   = flag.String("tls-key-file", "", "PEM private key of --tls-cert-file.")
+	clientCAFile = flag.String("tls-client-ca-file", "", "PEM CA bundle to verify client certificates with. Required with --tls-cert-file.")
+)
+
+func main() {
+	flag.Parse()
+	logger := newLogger()
+
+	cfg := config.Config{}
+	cfg.Signers.X509.ActiveKey = *keyID
+	cfg.Signers.X509.RSAScheme = *rsaScheme
+	signer, err := chainsx509.NewSigner(context.Background(), *secretPath, cfg, logger)
+	if err != nil {
+		logger.Fatalf("Error loading the signing key from %s: %s", *secretPath, err)
+	}
+	ks, err := remote.NewKeyService(signer, *rsaScheme)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	tlsConfig, err := newTLSConfig()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	if path := strings.TrimPrefix(*listen, "unix://"); path != *listen {
+		// Remove the socket of a previous run.
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logger.Fatal(err)
+		}
+	}
+	lis, err := remote.Listen(*listen, tlsConfig)
+	if err != nil {
+		logger.Fatalf("Error listening on %s: %s", *listen, err)
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		lis.Close()
+	}()
+
+	logger.Infof("Serving the signing key on %s", *listen)
+	if err := remote.Serve(lis, ks); err != nil && !errors.Is(err, net.ErrClosed) {
+		logger.Fatal(err)
+	}
+}
+
+func newLogger() *zap.SugaredLogger {
+	l, err := zap.NewProduction()
+	if err != nil {
+		panic(err)
+	}
+	return l.Sugar()
+}
+
+// newTLSConfig returns the TLS configuration to serve tls:// addresses with, if one is configured.
+func newTLSConfig() (*tls.Config, error) {
+	if *tlsCertFile == "" && *tlsKeyFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(*tlsCertFile, *tlsKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	// Anyone who can connect can sign, so clients must always present a certificate.
+	if *clientCAFile == "" {
+		return nil, errors.New("--tls-client-ca-file is required with --tls-cert-file")
+	}
+	ca, err := ioutil.ReadFile(*clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificates found in %s", *clientCAFile)
+	}
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}, nil
+}
@@ -7,9 +7,13 @@ replace k8s.io/kube-openapi => k8s.io/kube-openapi v0.0.0-20211109043538-2043435
 require (
 	cloud.google.com/go/compute v1.2.0
 	cloud.google.com/go/storage v1.20.0
+	github.com/ThalesIgnite/crypto11 v1.2.5
 	github.com/armon/go-metrics v0.3.10
 	github.com/armon/go-radix v1.0.0
+	github.com/fsnotify/fsnotify v1.5.1
 	github.com/ghodss/yaml v1.0.0
+	github.com/go-openapi/runtime v0.23.0
+	github.com/go-openapi/strfmt v0.21.2
 	github.com/golang/snappy v0.0.4
 	github.com/golangci/golangci-lint v1.43.0
 	github.com/google/addlicense v1.0.0
@@ -36,17 +40,22 @@ require (
 	github.com/mitchellh/mapstructure v1.4.3
 	github.com/pierrec/lz4 v2.6.1+incompatible
 	github.com/pkg/errors v0.9.1
+	github.com/sassoftware/relic v0.0.0-20210427151427-dfb082b79b74
 	github.com/secure-systems-lab/go-securesystemslib v0.3.0
 	github.com/sigstore/cosign v1.5.2-0.20220210140103-2381756282ae
+	github.com/sigstore/fulcio v0.1.2-0.20220114150912-86a2036f9bc7
 	github.com/sigstore/rekor v0.4.1-0.20220114213500-23f583409af3
 	github.com/sigstore/sigstore v1.1.1-0.20220130134424-bae9b66b8442
+	github.com/spf13/cobra v1.3.0
 	github.com/tektoncd/pipeline v0.31.1-0.20220105002759-3e137645be61
 	github.com/tektoncd/plumbing v0.0.0-20211012143332-c7cc43d9bc0c
 	go.uber.org/atomic v1.9.0
 	go.uber.org/zap v1.21.0
 	gocloud.dev v0.24.1-0.20211119014450-028788aaaa4c
 	golang.org/x/crypto v0.0.0-20220112180741-5e0467b6c7ce
+	golang.org/x/oauth2 v0.0.0-20211104180415-d3ed0bb246c8
 	google.golang.org/protobuf v1.27.1
+	gopkg.in/square/go-jose.v2 v2.6.0
 	k8s.io/api v0.22.5
 	k8s.io/apimachinery v0.22.5
 	k8s.io/client-go v0.22.5
@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+)
+
+var _ apis.Defaultable = (*ChainsConfig)(nil)
+
+// SetDefaults implements apis.Defaultable. The defaults are the same as the ones of the
+// chains-config ConfigMap.
+func (c *ChainsConfig) SetDefaults(ctx context.Context) {
+	c.Spec.SetDefaults(ctx)
+}
+
+// SetDefaults sets the defaults of the configuration.
+func (s *ChainsConfigSpec) SetDefaults(ctx context.Context) {
+	s.Artifacts.TaskRuns.setDefaults("tekton", "tekton")
+	s.Artifacts.OCI.setDefaults("simplesigning", "oci")
+	if s.Transparency.Mode == "" {
+		s.Transparency.Mode = TransparencyDisabled
+	}
+	if s.Transparency.URL == "" {
+		s.Transparency.URL = "https://rekor.sigstore.dev"
+	}
+	x509 := &s.Signers.X509
+	if x509.Fulcio.Address == "" {
+		x509.Fulcio.Address = "https://v1.fulcio.sigstore.dev"
+	}
+	if x509.Fulcio.Identity == "" {
+		x509.Fulcio.Identity = "controller"
+	}
+	if x509.CA.Validity.Duration == 0 {
+		x509.CA.Validity = metav1.Duration{Duration: 10 * time.Minute}
+	}
+	if s.Builder.ID == "" {
+		s.Builder.ID = "https://tekton.dev/chains/v2"
+	}
+	if s.SignOn == "" {
+		s.SignOn = SignOnAlways
+	}
+}
+
+func (a *ArtifactSpec) setDefaults(format, storage string) {
+	if a.Format == "" {
+		a.Format = format
+	}
+	if len(a.Storage) == 0 && !a.Disabled {
+		a.Storage = []string{storage}
+	}
+	if len(a.Signers) == 0 {
+		a.Signers = []string{"x509"}
+	}
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+// ChainsConfigName is the name of the ChainsConfig the controller uses, in its namespace.
+// While it exists, it replaces the chains-config ConfigMap.
+const ChainsConfigName = "chains-config"
+
+// ChainsConfig configures how Chains signs and stores the artifacts of TaskRuns.
+//
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ChainsConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ChainsConfigSpec `json:"spec,omitempty"`
+	// +optional
+	Status ChainsConfigStatus `json:"status,omitempty"`
+}
+
+// ChainsConfigSpec is the configuration of Chains.
+type ChainsConfigSpec struct {
+	Artifacts    ArtifactsSpec    `json:"artifacts,omitempty"`
+	Storage      StorageSpec      `json:"storage,omitempty"`
+	Signers      SignersSpec      `json:"signers,omitempty"`
+	Transparency TransparencySpec `json:"transparency,omitempty"`
+	Timestamp    TimestampSpec    `json:"timestamp,omitempty"`
+	Builder      BuilderSpec      `json:"builder,omitempty"`
+	// NamespaceOverrides limits what the chains-config ConfigMaps of namespaces can override.
+	NamespaceOverrides NamespaceOverridesSpec `json:"namespaceOverrides,omitempty"`
+	// Selection decides which TaskRuns are signed.
+	Selection SelectionSpec `json:"selection,omitempty"`
+	// SignOn decides whether TaskRuns that didn't succeed are signed, always (the default) or
+	// success.
+	SignOn string `json:"signOn,omitempty"`
+}
+
+// ArtifactsSpec configures each type of artifact.
+type ArtifactsSpec struct {
+	TaskRuns ArtifactSpec `json:"taskRuns,omitempty"`
+	OCI      ArtifactSpec `json:"oci,omitempty"`
+}
+
+// ArtifactSpec configures how an artifact is formatted, signed and stored.
+type ArtifactSpec struct {
+	// Disabled stops signing the artifact.
+	Disabled bool   `json:"disabled,omitempty"`
+	Format   string `json:"format,omitempty"`
+	// Storage lists the storage backends to store the signatures in.
+	Storage []string `json:"storage,omitempty"`
+	// Signers lists the signers to sign with. The first one is the primary signer,
+	// any others co-sign the artifact.
+	Signers []string `json:"signers,omitempty"`
+	// Transparency overrides the transparency log settings for this artifact, if set.
+	Transparency *TransparencySpec `json:"transparency,omitempty"`
+}
+
+// StorageSpec configures the storage backends.
+type StorageSpec struct {
+	GCS   GCSStorageSpec   `json:"gcs,omitempty"`
+	OCI   OCIStorageSpec   `json:"oci,omitempty"`
+	DocDB DocDBStorageSpec `json:"docdb,omitempty"`
+}
+
+type GCSStorageSpec struct {
+	Bucket string `json:"bucket,omitempty"`
+}
+
+type OCIStorageSpec struct {
+	Repository string `json:"repository,omitempty"`
+	Insecure   bool   `json:"insecure,omitempty"`
+}
+
+type DocDBStorageSpec struct {
+	URL string `json:"url,omitempty"`
+}
+
+// SignersSpec configures the signers.
+type SignersSpec struct {
+	// NamespaceSecret is the name of a Secret in the namespace of a TaskRun to load its
+	// signing keys from.
+	NamespaceSecret string           `json:"namespaceSecret,omitempty"`
+	X509            X509SignerSpec   `json:"x509,omitempty"`
+	KMS             KMSSignerSpec    `json:"kms,omitempty"`
+	PKCS11          PKCS11SignerSpec `json:"pkcs11,omitempty"`
+	Remote          RemoteSignerSpec `json:"remote,omitempty"`
+}
+
+type X509SignerSpec struct {
+	// RSAScheme is the signature scheme used with RSA keys, pkcs1v15 or pss.
+	RSAScheme string     `json:"rsaScheme,omitempty"`
+	Keys      KeysSpec   `json:"keys,omitempty"`
+	Fulcio    FulcioSpec `json:"fulcio,omitempty"`
+	CA        CASpec     `json:"ca,omitempty"`
+}
+
+// KeysSpec configures the rotation and generation of the x509 signing keys.
+type KeysSpec struct {
+	// Active is the ID of the named key to sign with. The unnamed key is used if empty.
+	Active  string       `json:"active,omitempty"`
+	Retired []RetiredKey `json:"retired,omitempty"`
+	// GracePeriod is how long retired keys still verify TaskRuns that completed before
+	// they were retired.
+	GracePeriod metav1.Duration `json:"gracePeriod,omitempty"`
+	// Generate generates the active key if it doesn't exist, and publishes its public key.
+	Generate bool `json:"generate,omitempty"`
+}
+
+// RetiredKey is a key that no longer signs.
+type RetiredKey struct {
+	ID        string      `json:"id"`
+	RetiredAt metav1.Time `json:"retiredAt"`
+}
+
+type FulcioSpec struct {
+	Enabled bool   `json:"enabled,omitempty"`
+	Address string `json:"address,omitempty"`
+	// Identity is whose identity certificates are requested for, controller or taskrun.
+	Identity string     `json:"identity,omitempty"`
+	Client   ClientSpec `json:"client,omitempty"`
+}
+
+// CASpec configures the local CA that certifies a new key for every TaskRun.
+type CASpec struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// KMSRef is the KMS reference of the CA key, instead of ca.key in the signing secrets.
+	KMSRef   string          `json:"kmsRef,omitempty"`
+	Validity metav1.Duration `json:"validity,omitempty"`
+}
+
+type KMSSignerSpec struct {
+	KMSRef string `json:"kmsRef,omitempty"`
+}
+
+type PKCS11SignerSpec struct {
+	Module     string `json:"module,omitempty"`
+	TokenLabel string `json:"tokenLabel,omitempty"`
+	Slot       *int   `json:"slot,omitempty"`
+	KeyLabel   string `json:"keyLabel,omitempty"`
+	// KeyID is the hex encoded ID of the key pair.
+	KeyID     string `json:"keyID,omitempty"`
+	RSAScheme string `json:"rsaScheme,omitempty"`
+}
+
+type RemoteSignerSpec struct {
+	// Address is where the remote signer listens: unix://<path>, tcp://<host>:<port>
+	// or tls://<host>:<port>.
+	Address string     `json:"address,omitempty"`
+	Client  ClientSpec `json:"client,omitempty"`
+}
+
+// Transparency log modes.
+const (
+	TransparencyDisabled = "disabled"
+	TransparencyEnabled  = "enabled"
+	// TransparencyManual only uploads the TaskRuns with the transparency annotation.
+	TransparencyManual = "manual"
+)
+
+type TransparencySpec struct {
+	// Mode is disabled, enabled or manual.
+	Mode   string     `json:"mode,omitempty"`
+	URL    string     `json:"url,omitempty"`
+	Client ClientSpec `json:"client,omitempty"`
+}
+
+// TimestampSpec configures the RFC 3161 timestamp authority signatures are timestamped by.
+// Signatures aren't timestamped if URL is empty.
+type TimestampSpec struct {
+	URL    string     `json:"url,omitempty"`
+	Client ClientSpec `json:"client,omitempty"`
+}
+
+// ClientSpec contains the paths to the TLS and auth material used to talk to a service,
+// mounted into the controller.
+type ClientSpec struct {
+	CAFile    string `json:"caFile,omitempty"`
+	CertFile  string `json:"certFile,omitempty"`
+	KeyFile   string `json:"keyFile,omitempty"`
+	TokenFile string `json:"tokenFile,omitempty"`
+}
+
+type BuilderSpec struct {
+	ID string `json:"id,omitempty"`
+}
+
+// NamespaceOverridesSpec contains the chains-config keys that the namespace of a TaskRun
+// may override.
+type NamespaceOverridesSpec struct {
+	AllowedKeys []string `json:"allowedKeys,omitempty"`
+}
+
+// SelectionSpec contains the selectors of the TaskRuns to sign. A nil selector selects everything.
+type SelectionSpec struct {
+	// NamespaceSelector selects the namespaces of the TaskRuns by their labels.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// TaskRunSelector selects the TaskRuns by their labels.
+	TaskRunSelector *metav1.LabelSelector `json:"taskRunSelector,omitempty"`
+}
+
+// Policies for signing TaskRuns that didn't succeed.
+const (
+	SignOnAlways  = "always"
+	SignOnSuccess = "success"
+)
+
+// ChainsConfigStatus reports whether the controller applied the configuration.
+type ChainsConfigStatus struct {
+	duckv1.Status `json:",inline"`
+}
+
+// ChainsConfigList contains a list of ChainsConfigs.
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ChainsConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ChainsConfig `json:"items"`
+}
+
+// Reasons of the Ready condition.
+const (
+	// ReasonApplied is the reason of a configuration the controller uses.
+	ReasonApplied = "Applied"
+	// ReasonInvalid is the reason of a configuration the controller rejected.
+	ReasonInvalid = "Invalid"
+	// ReasonIgnored is the reason of a ChainsConfig that isn't the one the controller uses.
+	ReasonIgnored = "Ignored"
+)
+
+var chainsConfigCondSet = apis.NewLivingConditionSet()
+
+// GetGroupVersionKind implements kmeta.OwnerRefable.
+func (*ChainsConfig) GetGroupVersionKind() schema.GroupVersionKind {
+	return SchemeGroupVersion.WithKind("ChainsConfig")
+}
+
+// InitializeConditions sets the Ready condition to Unknown.
+func (s *ChainsConfigStatus) InitializeConditions() {
+	chainsConfigCondSet.Manage(s).InitializeConditions()
+}
+
+// MarkApplied sets the Ready condition to True.
+func (s *ChainsConfigStatus) MarkApplied() {
+	chainsConfigCondSet.Manage(s).MarkTrueWithReason(apis.ConditionReady, ReasonApplied, "The controller uses this configuration")
+}
+
+// MarkNotApplied sets the Ready condition to False with reason and message.
+func (s *ChainsConfigStatus) MarkNotApplied(reason, messageFormat string, messageA ...interface{}) {
+	chainsConfigCondSet.Manage(s).MarkFalse(apis.ConditionReady, reason, messageFormat, messageA...)
+}
+
+// IsReady returns whether the controller applied the configuration.
+func (s *ChainsConfigStatus) IsReady() bool {
+	return chainsConfigCondSet.Manage(s).IsHappy()
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/apis"
+)
+
+var _ apis.Validatable = (*ChainsConfig)(nil)
+
+var (
+	taskRunFormats  = sets.NewString("tekton", "in-toto", "tekton-provenance")
+	ociFormats      = sets.NewString("simplesigning")
+	storageBackends = sets.NewString("tekton", "oci", "gcs", "docdb")
+	signerTypes     = sets.NewString("x509", "kms", "pkcs11", "remote")
+	rsaSchemes      = sets.NewString("pkcs1v15", "pss")
+	fulcioIDs       = sets.NewString("controller", "taskrun")
+	transparency    = sets.NewString(TransparencyDisabled, TransparencyEnabled, TransparencyManual)
+	signOn          = sets.NewString(SignOnAlways, SignOnSuccess)
+)
+
+// Validate implements apis.Validatable. It checks the values the API accepts; combinations of
+// values are checked when the configuration is applied, like for the chains-config ConfigMap.
+func (c *ChainsConfig) Validate(ctx context.Context) *apis.FieldError {
+	return c.Spec.Validate(ctx).ViaField("spec")
+}
+
+// Validate checks the values of the configuration.
+func (s *ChainsConfigSpec) Validate(ctx context.Context) (errs *apis.FieldError) {
+	errs = errs.Also(s.Artifacts.TaskRuns.validate(taskRunFormats).ViaField("artifacts", "taskRuns"))
+	errs = errs.Also(s.Artifacts.OCI.validate(ociFormats).ViaField("artifacts", "oci"))
+	errs = errs.Also(s.Transparency.validate().ViaField("transparency"))
+
+	x509 := s.Signers.X509
+	errs = errs.Also(oneOf(x509.RSAScheme, rsaSchemes, "signers", "x509", "rsaScheme"))
+	errs = errs.Also(oneOf(x509.Fulcio.Identity, fulcioIDs, "signers", "x509", "fulcio", "identity"))
+	if x509.Keys.GracePeriod.Duration < 0 {
+		errs = errs.Also(apis.ErrInvalidValue(x509.Keys.GracePeriod.Duration, "signers.x509.keys.gracePeriod", "must not be negative"))
+	}
+	for i, k := range x509.Keys.Retired {
+		if k.ID == "" {
+			errs = errs.Also(apis.ErrMissingField("id").ViaFieldIndex("retired", i).ViaField("signers", "x509", "keys"))
+		}
+	}
+	if x509.CA.Validity.Duration < 0 {
+		errs = errs.Also(apis.ErrInvalidValue(x509.CA.Validity.Duration, "signers.x509.ca.validity", "must not be negative"))
+	}
+	errs = errs.Also(oneOf(s.Signers.PKCS11.RSAScheme, rsaSchemes, "signers", "pkcs11", "rsaScheme"))
+	if s.Signers.Remote.Client.TokenFile != "" {
+		errs = errs.Also(apis.ErrDisallowedFields("signers.remote.client.tokenFile"))
+	}
+
+	for field, selector := range map[string]*metav1.LabelSelector{
+		"namespaceSelector": s.Selection.NamespaceSelector,
+		"taskRunSelector":   s.Selection.TaskRunSelector,
+	} {
+		if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+			errs = errs.Also(apis.ErrInvalidValue(err.Error(), field).ViaField("selection"))
+		}
+	}
+
+	errs = errs.Also(oneOf(s.SignOn, signOn, "signOn"))
+
+	for i, k := range s.NamespaceOverrides.AllowedKeys {
+		if k == "" {
+			errs = errs.Also(apis.ErrInvalidArrayValue(k, "namespaceOverrides.allowedKeys", i))
+		}
+	}
+	return errs
+}
+
+func (a *ArtifactSpec) validate(formats sets.String) (errs *apis.FieldError) {
+	errs = errs.Also(oneOf(a.Format, formats, "format"))
+	for i, s := range a.Storage {
+		if !storageBackends.Has(s) {
+			errs = errs.Also(invalidArrayValue(s, storageBackends, "storage", i))
+		}
+	}
+	seen := sets.NewString()
+	for i, s := range a.Signers {
+		if !validSigner(s) {
+			errs = errs.Also(apis.ErrInvalidArrayValue(fmt.Sprintf("%s, wanted one of %v or x509:<key ID>", s, signerTypes.List()), "signers", i))
+		}
+		if seen.Has(s) {
+			errs = errs.Also(apis.ErrMultipleOneOf(fmt.Sprintf("signers[%d]", i)))
+		}
+		seen.Insert(s)
+	}
+	if t := a.Transparency; t != nil {
+		errs = errs.Also(t.validate().ViaField("transparency"))
+		// Only the mode and the URL can be overridden per artifact.
+		if t.Client != (ClientSpec{}) {
+			errs = errs.Also(apis.ErrDisallowedFields("transparency.client"))
+		}
+	}
+	return errs
+}
+
+func (t *TransparencySpec) validate() *apis.FieldError {
+	return oneOf(t.Mode, transparency, "mode")
+}
+
+// oneOf returns an error for the field at path if value is set and not one of values.
+func oneOf(value string, values sets.String, path ...string) *apis.FieldError {
+	if value == "" || values.Has(value) {
+		return nil
+	}
+	return apis.ErrInvalidValue(fmt.Sprintf("%s, wanted one of %v", value, values.List()), path[len(path)-1]).ViaField(path[:len(path)-1]...)
+}
+
+// keyIDPattern matches the IDs of named keys, like the chains-config ConfigMap does.
+var keyIDPattern = regexp.MustCompile(`^[a-zA-Z0-9][-_a-zA-Z0-9]*$`)
+
+// validSigner returns whether s is a signer type, or x509:<key ID> for a named x509 key.
+func validSigner(s string) bool {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) == 1 {
+		return signerTypes.Has(s)
+	}
+	return parts[0] == "x509" && keyIDPattern.MatchString(parts[1])
+}
+
+func invalidArrayValue(value string, values sets.String, field string, index int) *apis.FieldError {
+	return apis.ErrInvalidArrayValue(fmt.Sprintf("%s, wanted one of %v", value, values.List()), field, index)
+}
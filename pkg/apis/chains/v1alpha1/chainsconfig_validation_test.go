@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestChainsConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		spec ChainsConfigSpec
+		want string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "invalid format",
+			spec: ChainsConfigSpec{Artifacts: ArtifactsSpec{TaskRuns: ArtifactSpec{Format: "slsa"}}},
+			want: "invalid value: slsa, wanted one of [in-toto tekton tekton-provenance]: spec.artifacts.taskRuns.format",
+		},
+		{
+			name: "invalid storage",
+			spec: ChainsConfigSpec{Artifacts: ArtifactsSpec{OCI: ArtifactSpec{Storage: []string{"oci", "s3"}}}},
+			want: "spec.artifacts.oci.storage[1]",
+		},
+		{
+			name: "duplicate signer",
+			spec: ChainsConfigSpec{Artifacts: ArtifactsSpec{OCI: ArtifactSpec{Signers: []string{"kms", "kms"}}}},
+			want: "spec.artifacts.oci.signers[1]",
+		},
+		{
+			name: "named x509 keys",
+			spec: ChainsConfigSpec{Artifacts: ArtifactsSpec{TaskRuns: ArtifactSpec{Signers: []string{"x509:new", "x509:old"}}}},
+		},
+		{
+			name: "named kms key",
+			spec: ChainsConfigSpec{Artifacts: ArtifactsSpec{TaskRuns: ArtifactSpec{Signers: []string{"x509", "kms:old"}}}},
+			want: "spec.artifacts.taskRuns.signers[1]",
+		},
+		{
+			name: "invalid transparency mode",
+			spec: ChainsConfigSpec{Transparency: TransparencySpec{Mode: "true"}},
+			want: "spec.transparency.mode",
+		},
+		{
+			name: "artifact transparency client",
+			spec: ChainsConfigSpec{Artifacts: ArtifactsSpec{TaskRuns: ArtifactSpec{
+				Transparency: &TransparencySpec{Client: ClientSpec{CAFile: "/ca.crt"}},
+			}}},
+			want: "spec.artifacts.taskRuns.transparency.client",
+		},
+		{
+			name: "invalid sign-on policy",
+			spec: ChainsConfigSpec{SignOn: "failure"},
+			want: "spec.signOn",
+		},
+		{
+			name: "retired key without an ID",
+			spec: ChainsConfigSpec{Signers: SignersSpec{X509: X509SignerSpec{Keys: KeysSpec{Retired: []RetiredKey{{}}}}}},
+			want: "spec.signers.x509.keys.retired[0].id",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := &ChainsConfig{Spec: tt.spec}
+			c.SetDefaults(ctx)
+			err := c.Validate(ctx)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("Validate() = %v", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("Validate() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestChainsConfigSetDefaults(t *testing.T) {
+	c := &ChainsConfig{Spec: ChainsConfigSpec{
+		Artifacts: ArtifactsSpec{OCI: ArtifactSpec{Disabled: true}},
+	}}
+	c.SetDefaults(context.Background())
+	if got := c.Spec.Artifacts.TaskRuns.Storage; len(got) != 1 || got[0] != "tekton" {
+		t.Errorf("taskRuns.storage = %v, want [tekton]", got)
+	}
+	if got := c.Spec.Artifacts.OCI.Storage; len(got) != 0 {
+		t.Errorf("oci.storage = %v, want none for a disabled artifact", got)
+	}
+	if got := c.Spec.Transparency.Mode; got != TransparencyDisabled {
+		t.Errorf("transparency.mode = %s, want %s", got, TransparencyDisabled)
+	}
+}
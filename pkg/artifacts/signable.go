@@ -28,7 +28,8 @@ import (
 type Signable interface {
 	ExtractObjects(tr *v1beta1.TaskRun) []interface{}
 	StorageBackend(cfg config.Config) sets.String
-	Signer(cfg config.Config) string
+	Signers(cfg config.Config) []string
+	Transparency(cfg config.Config) config.TransparencyConfig
 	PayloadFormat(cfg config.Config) formats.PayloadType
 	Key(interface{}) string
 	Type() string
@@ -59,8 +60,15 @@ func (ta *TaskRunArtifact) PayloadFormat(cfg config.Config) formats.PayloadType
 	return formats.PayloadType(cfg.Artifacts.TaskRuns.Format)
 }
 
-func (ta *TaskRunArtifact) Signer(cfg config.Config) string {
-	return cfg.Artifacts.TaskRuns.Signer
+func (ta *TaskRunArtifact) Signers(cfg config.Config) []string {
+	return cfg.Artifacts.TaskRuns.Signers
+}
+
+func (ta *TaskRunArtifact) Transparency(cfg config.Config) config.TransparencyConfig {
+	if t := cfg.Artifacts.TaskRuns.Transparency; t != nil {
+		return *t
+	}
+	return cfg.Transparency
 }
 
 func (ta *TaskRunArtifact) Enabled(cfg config.Config) bool {
@@ -193,8 +201,15 @@ func (oa *OCIArtifact) PayloadFormat(cfg config.Config) formats.PayloadType {
 	return formats.PayloadType(cfg.Artifacts.OCI.Format)
 }
 
-func (oa *OCIArtifact) Signer(cfg config.Config) string {
-	return cfg.Artifacts.OCI.Signer
+func (oa *OCIArtifact) Signers(cfg config.Config) []string {
+	return cfg.Artifacts.OCI.Signers
+}
+
+func (oa *OCIArtifact) Transparency(cfg config.Config) config.TransparencyConfig {
+	if t := cfg.Artifacts.OCI.Transparency; t != nil {
+		return *t
+	}
+	return cfg.Transparency
 }
 
 func (oa *OCIArtifact) Key(obj interface{}) string {
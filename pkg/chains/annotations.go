@@ -34,13 +34,21 @@ const (
 	MaxRetries                   = 3
 )
 
+// SignedAtAnnotation records when a TaskRun was signed. It is only informational: the owner of
+// the TaskRun can change it, so certificates are checked at the time of a transparency log entry
+// or timestamp instead.
+const SignedAtAnnotation = "chains.tekton.dev/signed-at"
+
+// SignAnnotation opts a TaskRun out of signing when set to "false".
+const SignAnnotation = "chains.tekton.dev/sign"
+
 // Reconciled determines whether a TaskRun has already passed through the reconcile loops, up to 3x
 func Reconciled(tr *v1beta1.TaskRun) bool {
 	val, ok := tr.ObjectMeta.Annotations[ChainsAnnotation]
 	if !ok {
 		return false
 	}
-	return val == "true" || val == "failed"
+	return val == "true" || val == "failed" || val == "skipped"
 }
 
 // MarkSigned marks a TaskRun as signed.
@@ -51,6 +59,11 @@ func MarkSigned(tr *v1beta1.TaskRun, ps versioned.Interface, annotations map[str
 	return AddAnnotation(tr, ps, ChainsAnnotation, "true", annotations)
 }
 
+// MarkSkipped marks a TaskRun as skipped, when the configuration says not to sign it.
+func MarkSkipped(tr *v1beta1.TaskRun, ps versioned.Interface, annotations map[string]string) error {
+	return AddAnnotation(tr, ps, ChainsAnnotation, "skipped", annotations)
+}
+
 func MarkFailed(tr *v1beta1.TaskRun, ps versioned.Interface, annotations map[string]string) error {
 	return AddAnnotation(tr, ps, ChainsAnnotation, "failed", annotations)
 }
@@ -0,0 +1,277 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"go.uber.org/zap"
+)
+
+const (
+	// fulcioRenewBefore is how long before they expire Fulcio certificates are renewed.
+	fulcioRenewBefore = 3 * time.Minute
+	// fulcioRenewRetry is how long to wait before trying to renew a certificate again.
+	fulcioRenewRetry = 30 * time.Second
+	// fulcioRenewTimeout is how long renewing a certificate may take.
+	fulcioRenewTimeout = time.Minute
+	// fulcioMinValidity is how long Fulcio certificates must still be valid for to sign with them.
+	fulcioMinValidity = 30 * time.Second
+	// signerCacheTTL is how long unused signers are kept for.
+	signerCacheTTL = time.Hour
+)
+
+// signerCache caches signers, so they aren't created again for every TaskRun. The
+// zero value is ready to use.
+type signerCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedSigners
+	// now and afterFunc are overridden in tests.
+	now       func() time.Time
+	afterFunc func(time.Duration, func()) (stop func() bool)
+}
+
+type cachedSigners struct {
+	signers  map[string]signing.Signer
+	lastUsed time.Time
+	// renewAt and expiresAt are set if the signers have a certificate from Fulcio or the local CA.
+	renewAt   time.Time
+	expiresAt time.Time
+	// stop cancels the renewal of the certificate.
+	stop func() bool
+	// background is whether the certificate is renewed in the background. Otherwise the signers
+	// are created again when they are needed after renewAt.
+	background bool
+
+	// ctx, cfg, logger and newSigners create the signers again.
+	ctx        context.Context
+	cfg        config.Config
+	logger     *zap.SugaredLogger
+	newSigners func(context.Context) (map[string]signing.Signer, error)
+}
+
+// signers returns the signers for tr, from the cache if they were created already.
+func (ts *TaskRunSigner) signers(ctx context.Context, tr *v1beta1.TaskRun, cfg config.Config, logger *zap.SugaredLogger) (map[string]signing.Signer, error) {
+	secret, err := signingSecretFor(ctx, ts.KubeClient, ts.SecretPath, tr, cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	if secret.name == "" {
+		secret.version = ts.secrets.version(ts.SecretPath, logger)
+	}
+	identity := identityOf(tr, cfg)
+	key, err := secret.cacheKey(cfg, identity)
+	if err != nil {
+		return nil, err
+	}
+	// Only the certificates of the controller's identity are renewed in the background: the ones
+	// of a TaskRun, or of its ServiceAccount, are rarely used again.
+	return ts.cache.get(ctx, key, cfg, logger, identity == "", func(ctx context.Context) (map[string]signing.Signer, error) {
+		ctx, err := withTaskRunIdentity(ctx, ts.KubeClient, tr, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return secret.signers(ctx, cfg, logger)
+	})
+}
+
+// get returns the signers cached under key, or creates them with newSigners. Signers with a
+// Fulcio or local CA certificate are created again before it expires, in the background if
+// background is set.
+func (c *signerCache) get(ctx context.Context, key string, cfg config.Config, logger *zap.SugaredLogger, background bool, newSigners func(context.Context) (map[string]signing.Signer, error)) (map[string]signing.Signer, error) {
+	now := c.clock()
+	c.mu.Lock()
+	c.evict(now)
+	if cached, ok := c.entries[key]; ok {
+		cached.lastUsed = now
+		c.mu.Unlock()
+		return cached.signers, nil
+	}
+	c.mu.Unlock()
+
+	signers, err := newSigners(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := missingSigners(signers, cfg); err != nil {
+		// Sign with what could be configured, the objects that need other signers are skipped.
+		logger.Debugf("Not caching signers: %s", err)
+		return signers, nil
+	}
+
+	entry := &cachedSigners{
+		signers:    signers,
+		lastUsed:   now,
+		background: background,
+		ctx:        detachedContext{ctx},
+		cfg:        cfg,
+		logger:     logger,
+		newSigners: newSigners,
+	}
+	entry.setExpiry()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]*cachedSigners{}
+	}
+	if old, ok := c.entries[key]; ok {
+		old.cancel()
+	}
+	c.entries[key] = entry
+	c.scheduleRenewal(key, entry, entry.renewAt.Sub(now))
+	return signers, nil
+}
+
+// scheduleRenewal renews the certificate of the signers cached under key after d. c.mu must be held.
+func (c *signerCache) scheduleRenewal(key string, e *cachedSigners, d time.Duration) {
+	if e.renewAt.IsZero() || !e.background {
+		return
+	}
+	afterFunc := c.afterFunc
+	if afterFunc == nil {
+		afterFunc = func(d time.Duration, f func()) func() bool {
+			return time.AfterFunc(d, f).Stop
+		}
+	}
+	e.stop = afterFunc(d, func() { c.renew(key, e) })
+}
+
+// renew creates the signers cached under key again, if they are still used. While
+// their certificate is still valid, failures are retried and the old signers keep signing.
+func (c *signerCache) renew(key string, e *cachedSigners) {
+	c.mu.Lock()
+	if c.entries[key] != e {
+		c.mu.Unlock()
+		return
+	}
+	if c.expired(e, c.clock()) {
+		delete(c.entries, key)
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(e.ctx, fulcioRenewTimeout)
+	defer cancel()
+	signers, err := e.newSigners(ctx)
+	if err == nil {
+		err = missingSigners(signers, e.cfg)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries[key] != e {
+		return
+	}
+	if err != nil {
+		e.logger.Warnf("Error renewing the certificate, it expires at %s: %s", e.expiresAt, err)
+		c.scheduleRenewal(key, e, fulcioRenewRetry)
+		return
+	}
+	renewed := *e
+	renewed.signers = signers
+	renewed.setExpiry()
+	c.entries[key] = &renewed
+	c.scheduleRenewal(key, &renewed, renewed.renewAt.Sub(c.clock()))
+}
+
+// setExpiry sets when the certificate of the x509 signer expires, if it is from Fulcio or the local CA.
+func (e *cachedSigners) setExpiry() {
+	e.renewAt, e.expiresAt = time.Time{}, time.Time{}
+	if !e.cfg.Signers.X509.FulcioEnabled && !e.cfg.Signers.X509.CAEnabled {
+		return
+	}
+	for name, s := range e.signers {
+		if t, _ := config.ParseSigner(name); t != signing.TypeX509 {
+			continue
+		}
+		certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(s.Cert()))
+		if err != nil || len(certs) == 0 {
+			continue
+		}
+		if e.expiresAt.IsZero() || certs[0].NotAfter.Before(e.expiresAt) {
+			e.expiresAt = certs[0].NotAfter
+		}
+	}
+	if !e.expiresAt.IsZero() {
+		e.renewAt = e.expiresAt.Add(-fulcioRenewBefore)
+	}
+}
+
+func (e *cachedSigners) cancel() {
+	if e.stop != nil {
+		e.stop()
+	}
+}
+
+// evict removes the signers that weren't used for a while, or which certificate is about to expire.
+func (c *signerCache) evict(now time.Time) {
+	for k, e := range c.entries {
+		if c.expired(e, now) {
+			e.cancel()
+			delete(c.entries, k)
+		}
+	}
+}
+
+// expired returns whether the signers weren't used for a while, or their certificate is about to
+// expire, or is due for renewal when it isn't renewed in the background.
+func (c *signerCache) expired(e *cachedSigners, now time.Time) bool {
+	if now.Sub(e.lastUsed) > signerCacheTTL {
+		return true
+	}
+	if e.expiresAt.IsZero() {
+		return false
+	}
+	if !e.background {
+		return now.After(e.renewAt)
+	}
+	return now.After(e.expiresAt.Add(-fulcioMinValidity))
+}
+
+func (c *signerCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// detachedContext has the values of a context, but isn't canceled with it, so that signers
+// can be renewed in the background after the reconciliation they were created in.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+// missingSigners returns an error if any of the signers configured for an artifact is missing.
+func missingSigners(signers map[string]signing.Signer, cfg config.Config) error {
+	for _, a := range []config.Artifact{cfg.Artifacts.TaskRuns, cfg.Artifacts.OCI} {
+		for _, s := range a.Signers {
+			if _, ok := signers[s]; !ok {
+				return fmt.Errorf("signer %s could not be configured", s)
+			}
+		}
+	}
+	return nil
+}
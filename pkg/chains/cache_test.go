@@ -0,0 +1,310 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/chains/signing/x509"
+	"github.com/tektoncd/chains/pkg/chains/signing/x509/fulciotest"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekubeclient "knative.dev/pkg/client/injection/kube/client/fake"
+	"knative.dev/pkg/logging"
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+func TestTaskRunSigner_CachesSigners(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	logger := logging.FromContext(ctx)
+	kc := fakekubeclient.Get(ctx)
+
+	created := 0
+	oldSigners := getSigners
+	getSigners = func(ctx context.Context, sp string, cfg config.Config, l *zap.SugaredLogger) map[string]signing.Signer {
+		created++
+		return oldSigners(ctx, sp, cfg, l)
+	}
+	defer func() { getSigners = oldSigners }()
+
+	dir := t.TempDir()
+	writeKey(t, filepath.Join(dir, "x509.pem"))
+	ts := &TaskRunSigner{KubeClient: kc, SecretPath: dir}
+	cfg := config.Config{}
+	cfg.Artifacts.TaskRuns.Signers = []string{signing.TypeX509}
+	tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "team-a"}}
+
+	x509Signer := func(cfg config.Config) signing.Signer {
+		t.Helper()
+		signers, err := ts.signers(ctx, tr, cfg, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signers[signing.TypeX509]
+	}
+
+	first := x509Signer(cfg)
+	if got := x509Signer(cfg); got != first || created != 1 {
+		t.Errorf("expected the signers to be cached, created them %d times", created)
+	}
+
+	// The signing secrets change.
+	replaceKey(t, ts, filepath.Join(dir, "x509.pem"))
+	second := x509Signer(cfg)
+	if second == first || created != 2 {
+		t.Errorf("expected new signers for the new key, created them %d times", created)
+	}
+
+	// The config changes.
+	cfg.Signers.X509.RSAScheme = "pss"
+	if got := x509Signer(cfg); got == second || created != 3 {
+		t.Errorf("expected new signers for the new config, created them %d times", created)
+	}
+
+	// The namespace Secret is created, then changes.
+	cfg.Signers.NamespaceSecret = "signing-secrets"
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "signing-secrets", Namespace: "team-a"},
+		Data:       map[string][]byte{"x509.pem": newKey(t)},
+	}
+	if _, err := kc.CoreV1().Secrets("team-a").Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	third := x509Signer(cfg)
+	if got := x509Signer(cfg); got != third || created != 4 {
+		t.Errorf("expected the signers from the namespace secret to be cached, created them %d times", created)
+	}
+	secret.Data["x509.pem"] = newKey(t)
+	if _, err := kc.CoreV1().Secrets("team-a").Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := x509Signer(cfg); got == third || created != 5 {
+		t.Errorf("expected new signers for the new namespace secret, created them %d times", created)
+	}
+}
+
+func TestTaskRunSigner_RenewsFulcioCertificates(t *testing.T) {
+	fulcio := fulciotest.NewServer(t)
+	ctx, _ := rtesting.SetupFakeContext(t)
+	logger := logging.FromContext(ctx)
+	kc := fakekubeclient.Get(ctx)
+	// The identity of the controller.
+	ctx = x509.WithIdentityToken(ctx, fulciotest.Token("system:serviceaccount:tekton-chains:tekton-chains-controller"))
+
+	now := time.Now()
+	ts := &TaskRunSigner{KubeClient: kc}
+	ts.cache.now = func() time.Time { return now }
+	var renewals []time.Duration
+	var renew func()
+	ts.cache.afterFunc = func(d time.Duration, f func()) func() bool {
+		renewals = append(renewals, d)
+		renew = f
+		return func() bool { return true }
+	}
+	cfg := config.Config{}
+	cfg.Artifacts.TaskRuns.Signers = []string{signing.TypeX509}
+	cfg.Signers.X509.FulcioEnabled = true
+	cfg.Signers.X509.FulcioAddr = fulcio.URL
+	tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "team-a"}}
+
+	x509Signer := func() signing.Signer {
+		t.Helper()
+		signers, err := ts.signers(ctx, tr, cfg, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signers[signing.TypeX509]
+	}
+	expiry := func(s signing.Signer) time.Time {
+		t.Helper()
+		certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(s.Cert()))
+		if err != nil || len(certs) == 0 {
+			t.Fatalf("expected a Fulcio certificate, got %q: %v", s.Cert(), err)
+		}
+		return certs[0].NotAfter
+	}
+
+	first := x509Signer()
+	if want := expiry(first).Add(-fulcioRenewBefore).Sub(now); len(renewals) != 1 || renewals[0] != want {
+		t.Errorf("expected the renewal to be scheduled in %s, got %v", want, renewals)
+	}
+
+	now = now.Add(time.Minute)
+	if got := x509Signer(); got != first || len(fulcio.Tokens()) != 1 {
+		t.Errorf("expected the Fulcio certificate to be reused, requested %d", len(fulcio.Tokens()))
+	}
+
+	// Shortly before the certificate expires, a new one is requested in the background.
+	now = expiry(first).Add(-fulcioRenewBefore)
+	renew()
+	if len(fulcio.Tokens()) != 2 {
+		t.Errorf("expected the Fulcio certificate to be renewed, requested %d", len(fulcio.Tokens()))
+	}
+	second := x509Signer()
+	if second == first || len(fulcio.Tokens()) != 2 {
+		t.Errorf("expected the renewed certificate to be used, requested %d", len(fulcio.Tokens()))
+	}
+
+	// If Fulcio is unavailable, the certificate is used while it is still valid, and renewing it is retried.
+	fulcio.Close()
+	now = expiry(second).Add(-fulcioRenewBefore)
+	renew()
+	if got := renewals[len(renewals)-1]; got != fulcioRenewRetry {
+		t.Errorf("expected the renewal to be retried in %s, got %s", fulcioRenewRetry, got)
+	}
+	if got := x509Signer(); got != second {
+		t.Error("expected the valid certificate to be used while Fulcio is unavailable")
+	}
+	now = expiry(second)
+	if got := x509Signer(); got != nil {
+		t.Error("expected no x509 signer once the certificate expired")
+	}
+}
+
+func TestTaskRunSigner_StopsRenewingUnusedSigners(t *testing.T) {
+	fulcio := fulciotest.NewServer(t)
+	ctx, _ := rtesting.SetupFakeContext(t)
+	logger := logging.FromContext(ctx)
+	kc := fakekubeclient.Get(ctx)
+	// The identity of the controller.
+	ctx = x509.WithIdentityToken(ctx, fulciotest.Token("system:serviceaccount:tekton-chains:tekton-chains-controller"))
+
+	now := time.Now()
+	ts := &TaskRunSigner{KubeClient: kc}
+	ts.cache.now = func() time.Time { return now }
+	var renew func()
+	ts.cache.afterFunc = func(d time.Duration, f func()) func() bool {
+		renew = f
+		return func() bool { return true }
+	}
+	cfg := config.Config{}
+	cfg.Artifacts.TaskRuns.Signers = []string{signing.TypeX509}
+	cfg.Signers.X509.FulcioEnabled = true
+	cfg.Signers.X509.FulcioAddr = fulcio.URL
+	tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "team-a"}}
+
+	if _, err := ts.signers(ctx, tr, cfg, logger); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(signerCacheTTL + time.Second)
+	renew()
+	if len(fulcio.Tokens()) != 1 {
+		t.Errorf("expected no certificate for unused signers, requested %d", len(fulcio.Tokens()))
+	}
+	if len(ts.cache.entries) != 0 {
+		t.Errorf("expected the unused signers to be evicted, got %d", len(ts.cache.entries))
+	}
+}
+
+func TestTaskRunSigner_RenewsTaskRunCertificatesWhenUsed(t *testing.T) {
+	fulcio := fulciotest.NewServer(t)
+	ctx, _ := rtesting.SetupFakeContext(t)
+	logger := logging.FromContext(ctx)
+	kc := fakekubeclient.Get(ctx)
+	fakeTokenRequests(kc)
+
+	now := time.Now()
+	ts := &TaskRunSigner{KubeClient: kc}
+	ts.cache.now = func() time.Time { return now }
+	ts.cache.afterFunc = func(d time.Duration, f func()) func() bool {
+		t.Errorf("expected no background renewal of the certificate of a TaskRun, scheduled in %s", d)
+		return func() bool { return true }
+	}
+	cfg := config.Config{}
+	cfg.Artifacts.TaskRuns.Signers = []string{signing.TypeX509}
+	cfg.Signers.X509.FulcioEnabled = true
+	cfg.Signers.X509.FulcioAddr = fulcio.URL
+	cfg.Signers.X509.FulcioIdentity = config.FulcioIdentityTaskRun
+	tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "team-a"}}
+
+	x509Signer := func() signing.Signer {
+		t.Helper()
+		signers, err := ts.signers(ctx, tr, cfg, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signers[signing.TypeX509]
+	}
+	first := x509Signer()
+	if got := x509Signer(); got != first || len(fulcio.Tokens()) != 1 {
+		t.Errorf("expected the Fulcio certificate to be reused, requested %d", len(fulcio.Tokens()))
+	}
+
+	// Once the certificate is due for renewal, a new one is only requested when it's needed.
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(first.Cert()))
+	if err != nil || len(certs) == 0 {
+		t.Fatalf("expected a Fulcio certificate, got %q: %v", first.Cert(), err)
+	}
+	now = certs[0].NotAfter.Add(-fulcioRenewBefore).Add(time.Second)
+	if len(fulcio.Tokens()) != 1 {
+		t.Errorf("expected no certificate until the signers are used, requested %d", len(fulcio.Tokens()))
+	}
+	if got := x509Signer(); got == first || len(fulcio.Tokens()) != 2 {
+		t.Errorf("expected a new Fulcio certificate, requested %d", len(fulcio.Tokens()))
+	}
+}
+
+func newKey(t *testing.T) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pem, err := cryptoutils.MarshalPrivateKeyToPEM(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem
+}
+
+func writeKey(t *testing.T, path string) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, newKey(t), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// replaceKey replaces the key at path like Kubernetes updates mounted Secrets, and waits for ts to notice.
+func replaceKey(t *testing.T, ts *TaskRunSigner, path string) {
+	t.Helper()
+	logger := zap.NewNop().Sugar()
+	version := ts.secrets.version(filepath.Dir(path), logger)
+	if version == "" {
+		t.Fatal("expected the signing secrets to be watched")
+	}
+	tmp := filepath.Join(t.TempDir(), filepath.Base(path))
+	writeKey(t, tmp)
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(10 * time.Second); ts.secrets.version(filepath.Dir(path), logger) == version; {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the signing secrets to change")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -21,12 +21,17 @@ import (
 	"strings"
 
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"knative.dev/pkg/apis"
 )
 
 // BuildConfig is the custom Chains format to fill out the
 // "buildConfig" section of the slsa-provenance predicate
 type BuildConfig struct {
 	Steps []Step `json:"steps"`
+	// TerminationReason is the reason of the Succeeded condition of a TaskRun that failed, timed
+	// out or was cancelled, since the slsa-provenance metadata has no room for it. It is empty for
+	// TaskRuns that succeeded.
+	TerminationReason string `json:"terminationReason,omitempty"`
 }
 
 // Step corresponds to one step in the TaskRun
@@ -60,7 +65,11 @@ func buildConfig(tr *v1beta1.TaskRun) BuildConfig {
 		// append to all of the steps
 		steps = append(steps, s)
 	}
-	return BuildConfig{Steps: steps}
+	bc := BuildConfig{Steps: steps}
+	if c := tr.Status.GetCondition(apis.ConditionSucceeded); c != nil && !c.IsTrue() {
+		bc.TerminationReason = c.Reason
+	}
+	return bc
 }
 
 func container(stepState v1beta1.StepState, tr *v1beta1.TaskRun) v1beta1.Step {
@@ -23,6 +23,8 @@ import (
 	"github.com/ghodss/yaml"
 	"github.com/google/go-cmp/cmp"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/apis"
 )
 
 func TestBuildConfig(t *testing.T) {
@@ -89,3 +91,16 @@ status:
 		t.Fatalf("expected \n%v\n got \n%v\n", expected, got)
 	}
 }
+
+func TestBuildConfigTerminationReason(t *testing.T) {
+	tr := &v1beta1.TaskRun{}
+	tr.Status.SetCondition(&apis.Condition{Type: apis.ConditionSucceeded, Status: corev1.ConditionFalse, Reason: "TaskRunTimeout"})
+	if got := buildConfig(tr).TerminationReason; got != "TaskRunTimeout" {
+		t.Errorf("TerminationReason = %q, want %q", got, "TaskRunTimeout")
+	}
+
+	tr.Status.SetCondition(&apis.Condition{Type: apis.ConditionSucceeded, Status: corev1.ConditionTrue, Reason: "Succeeded"})
+	if got := buildConfig(tr).TerminationReason; got != "" {
+		t.Errorf("TerminationReason = %q for a successful TaskRun, want none", got)
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	"go.uber.org/zap"
+	"knative.dev/pkg/apis"
 
 	"github.com/google/go-containerregistry/pkg/name"
 )
@@ -115,6 +116,9 @@ func metadata(tr *v1beta1.TaskRun) provenance.ProvenanceMetadata {
 			m.Reproducible = true
 		}
 	}
+	if c := tr.Status.GetCondition(apis.ConditionSucceeded); c != nil && !c.IsTrue() {
+		m.TerminationReason = c.Reason
+	}
 	return m
 }
 
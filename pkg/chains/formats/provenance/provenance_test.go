@@ -30,7 +30,9 @@ import (
 	"github.com/tektoncd/chains/pkg/chains/provenance"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	"github.com/tektoncd/pipeline/pkg/apis/resource/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
 	logtesting "knative.dev/pkg/logging/testing"
 )
 
@@ -67,6 +69,19 @@ func TestMetadata(t *testing.T) {
 	}
 }
 
+func TestMetadataTerminationReason(t *testing.T) {
+	tr := &v1beta1.TaskRun{}
+	tr.Status.SetCondition(&apis.Condition{Type: apis.ConditionSucceeded, Status: corev1.ConditionFalse, Reason: "TaskRunCancelled"})
+	if got := metadata(tr).TerminationReason; got != "TaskRunCancelled" {
+		t.Errorf("TerminationReason = %q, want %q", got, "TaskRunCancelled")
+	}
+
+	tr.Status.SetCondition(&apis.Condition{Type: apis.ConditionSucceeded, Status: corev1.ConditionTrue, Reason: "Succeeded"})
+	if got := metadata(tr).TerminationReason; got != "" {
+		t.Errorf("TerminationReason = %q for a successful TaskRun, want none", got)
+	}
+}
+
 func TestMaterialsWithTaskRunResults(t *testing.T) {
 	// make sure this works with Git resources
 	taskrun := `apiVersion: tekton.dev/v1beta1
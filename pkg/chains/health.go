@@ -0,0 +1,317 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	versioned "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// StatusConfigMapName is the name of the ConfigMap the controller reports its health in.
+	StatusConfigMapName = "chains-status"
+
+	// healthCheckTaskRun is the name of the TaskRun that signers and storage backends are
+	// checked for. It doesn't exist, it stands in for the TaskRuns they are created for.
+	healthCheckTaskRun = "chains-health-check"
+)
+
+var (
+	// recheckInterval is how often the configuration is checked again while it is healthy,
+	// and unhealthyRecheckInterval while it isn't. Overridden in tests.
+	recheckInterval          = 5 * time.Minute
+	unhealthyRecheckInterval = 30 * time.Second
+)
+
+// Statuses of a HealthCheck.
+const (
+	HealthOK = "ok"
+	// HealthNotConfigured means that a signer has no key configured yet, like in a new install.
+	HealthNotConfigured = "not-configured"
+	HealthFailed        = "failed"
+)
+
+// HealthCheck is the result of checking a signer, a storage backend or a transparency log.
+type HealthCheck struct {
+	// Name is signer.<type>, storage.<type> or transparency.<artifact type>.
+	Name  string
+	Error error
+}
+
+// Status returns HealthOK, HealthNotConfigured or HealthFailed.
+func (c HealthCheck) Status() string {
+	switch {
+	case c.Error == nil:
+		return HealthOK
+	case signing.IsNoKey(c.Error):
+		return HealthNotConfigured
+	}
+	return HealthFailed
+}
+
+// Health is the result of checking everything the configuration signs and stores with.
+type Health struct {
+	Checks    []HealthCheck
+	CheckedAt time.Time
+}
+
+// Ready returns whether all the checks passed, except for signers without a key yet. The
+// controller is ready then, since TaskRuns are signed as soon as a key is configured.
+func (h *Health) Ready() bool {
+	for _, c := range h.Checks {
+		if c.Status() == HealthFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// Degraded returns whether any of the checks didn't pass.
+func (h *Health) Degraded() bool {
+	return h.Err() != nil
+}
+
+// Err returns the errors of the checks that failed.
+func (h *Health) Err() error {
+	var merr *multierror.Error
+	for _, c := range h.Checks {
+		if c.Error != nil {
+			merr = multierror.Append(merr, errors.Wrap(c.Error, c.Name))
+		}
+	}
+	return merr.ErrorOrNil()
+}
+
+// HealthChecker checks that the signers, storage backends and transparency logs of the
+// configuration can be used, and reports the result in the status ConfigMap and over HTTP.
+type HealthChecker struct {
+	KubeClient        kubernetes.Interface
+	Pipelineclientset versioned.Interface
+	SecretPath        string
+	// Namespace is the namespace of the controller, where the status ConfigMap is.
+	Namespace string
+
+	mu     sync.RWMutex
+	health *Health
+	// cancel stops checking the previous configuration.
+	cancel context.CancelFunc
+	// watching tracks the goroutines of Watch.
+	watching sync.WaitGroup
+}
+
+// Watch checks cfg now, and again periodically until Watch is called with the next
+// configuration or ctx is done.
+func (hc *HealthChecker) Watch(ctx context.Context, cfg config.Config, logger *zap.SugaredLogger) {
+	ctx, cancel := context.WithCancel(ctx)
+	hc.mu.Lock()
+	if hc.cancel != nil {
+		hc.cancel()
+	}
+	hc.cancel = cancel
+	hc.mu.Unlock()
+
+	healthy, unhealthy := recheckInterval, unhealthyRecheckInterval
+	hc.watching.Add(1)
+	go func() {
+		defer hc.watching.Done()
+		for {
+			interval := healthy
+			if h := hc.Check(ctx, cfg, logger); h.Degraded() {
+				interval = unhealthy
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+}
+
+// Check checks cfg, and reports the result.
+func (hc *HealthChecker) Check(ctx context.Context, cfg config.Config, logger *zap.SugaredLogger) *Health {
+	h := hc.check(ctx, cfg, logger)
+	if err := h.Err(); err != nil && !h.Ready() {
+		logger.Errorf("The configuration can't be used to sign: %v", err)
+	} else if err != nil {
+		logger.Warnf("Not all signers have a key configured, their TaskRuns aren't signed until they do: %v", err)
+	} else {
+		logger.Info("The configured signers, storage backends and transparency logs are ready")
+	}
+	// Don't report the result if the configuration changed in the meantime.
+	if ctx.Err() != nil {
+		return h
+	}
+	hc.mu.Lock()
+	hc.health = h
+	hc.mu.Unlock()
+	if err := hc.report(ctx, h); err != nil {
+		logger.Errorf("Error reporting the health in ConfigMap %s/%s: %v", hc.Namespace, StatusConfigMapName, err)
+	}
+	return h
+}
+
+// Health returns the result of the last check, or nil if nothing was checked yet.
+func (hc *HealthChecker) Health() *Health {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+	return hc.health
+}
+
+// ServeHTTP serves the readiness of the controller: 200 if the last check passed or is only
+// degraded, 503 otherwise. The errors themselves are only logged.
+func (hc *HealthChecker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h := hc.Health()
+	if h == nil {
+		http.Error(w, "the configuration wasn't checked yet", http.StatusServiceUnavailable)
+		return
+	}
+	if !h.Ready() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if !h.Degraded() {
+		fmt.Fprintln(w, HealthOK)
+		return
+	}
+	for _, c := range h.Checks {
+		if status := c.Status(); status != HealthOK {
+			fmt.Fprintf(w, "%s: %s\n", c.Name, status)
+		}
+	}
+}
+
+func (hc *HealthChecker) check(ctx context.Context, cfg config.Config, logger *zap.SugaredLogger) *Health {
+	h := &Health{}
+	tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: healthCheckTaskRun, Namespace: hc.Namespace}}
+
+	var signable []artifacts.Signable
+	for _, s := range []artifacts.Signable{&artifacts.TaskRunArtifact{Logger: logger}, &artifacts.OCIArtifact{Logger: logger}} {
+		if s.Enabled(cfg) {
+			signable = append(signable, s)
+		}
+	}
+
+	// Signers
+	signerTypes := []string{}
+	seen := sets.NewString()
+	for _, s := range signable {
+		for _, t := range s.Signers(cfg) {
+			if !seen.Has(t) {
+				seen.Insert(t)
+				signerTypes = append(signerTypes, t)
+			}
+		}
+	}
+	signerCtx, err := withTaskRunIdentity(ctx, hc.KubeClient, tr, cfg)
+	for _, t := range signerTypes {
+		c := HealthCheck{Name: "signer." + strings.Replace(t, ":", ".", 1), Error: err}
+		if err == nil {
+			_, c.Error = newSigner(signerCtx, t, hc.SecretPath, cfg, logger)
+		}
+		h.Checks = append(h.Checks, c)
+	}
+
+	// Storage backends, one at a time so each gets its own result.
+	backends := sets.NewString()
+	for _, s := range signable {
+		backends.Insert(s.StorageBackend(cfg).List()...)
+	}
+	for _, b := range backends.List() {
+		bcfg := cfg
+		bcfg.Artifacts.TaskRuns.StorageBackend = sets.NewString(b)
+		bcfg.Artifacts.OCI.StorageBackend = sets.NewString("")
+		_, err := getBackends(hc.Pipelineclientset, hc.KubeClient, logger, tr, bcfg)
+		h.Checks = append(h.Checks, HealthCheck{Name: "storage." + b, Error: err})
+	}
+
+	// Transparency logs
+	for _, s := range signable {
+		t := s.Transparency(cfg)
+		if !t.Enabled {
+			continue
+		}
+		h.Checks = append(h.Checks, HealthCheck{Name: "transparency." + s.Type(), Error: checkTlog(ctx, t, logger)})
+	}
+
+	h.CheckedAt = time.Now().UTC()
+	return h
+}
+
+// checkTlog checks that the transparency log can be reached.
+func checkTlog(ctx context.Context, cfg config.TransparencyConfig, logger *zap.SugaredLogger) error {
+	rekorClient, err := getRekor(cfg, logger)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return errors.Wrapf(rekorClient.CheckTlog(ctx), "reaching %s", cfg.URL)
+}
+
+// report creates or updates the status ConfigMap with the result of h. Every authenticated
+// user can read it, so it only has the status of the checks, their errors are only logged.
+func (hc *HealthChecker) report(ctx context.Context, h *Health) error {
+	data := map[string]string{
+		"ready":      strconv.FormatBool(h.Ready()),
+		"degraded":   strconv.FormatBool(h.Degraded()),
+		"checked-at": h.CheckedAt.Format(time.RFC3339),
+	}
+	for _, c := range h.Checks {
+		data[c.Name] = c.Status()
+	}
+
+	cms := hc.KubeClient.CoreV1().ConfigMaps(hc.Namespace)
+	cm, err := cms.Get(ctx, StatusConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = cms.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      StatusConfigMapName,
+				Namespace: hc.Namespace,
+				Labels:    map[string]string{"app.kubernetes.io/part-of": "tekton-chains"},
+			},
+			Data: data,
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(cm.Data, data) {
+		return nil
+	}
+	cm = cm.DeepCopy()
+	cm.Data = data
+	_, err = cms.Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
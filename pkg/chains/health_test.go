@@ -0,0 +1,212 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/fake"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func healthConfig() config.Config {
+	return config.Config{
+		Artifacts: config.ArtifactConfigs{
+			TaskRuns: config.Artifact{
+				Format:         "tekton",
+				StorageBackend: sets.NewString("mock"),
+				Signers:        []string{"x509"},
+			},
+			OCI: config.Artifact{
+				StorageBackend: sets.NewString(""),
+			},
+		},
+		Transparency: config.TransparencyConfig{Enabled: true, URL: "https://rekor.example.com"},
+	}
+}
+
+func TestHealthChecker_Check(t *testing.T) {
+	tests := []struct {
+		name       string
+		secretPath string
+		rekor      *mockRekor
+		wantReady  bool
+		wantData   map[string]string
+		wantBody   string
+	}{
+		{
+			name:       "ready",
+			secretPath: "./signing/x509/testdata/",
+			rekor:      &mockRekor{},
+			wantReady:  true,
+			wantData: map[string]string{
+				"ready":               "true",
+				"degraded":            "false",
+				"signer.x509":         "ok",
+				"storage.mock":        "ok",
+				"transparency.tekton": "ok",
+			},
+			wantBody: "ok\n",
+		},
+		{
+			// A new install is ready, and signs as soon as a key is configured.
+			name:       "no signing key",
+			secretPath: t.TempDir(),
+			rekor:      &mockRekor{},
+			wantReady:  true,
+			wantData: map[string]string{
+				"ready":               "true",
+				"degraded":            "true",
+				"signer.x509":         "not-configured",
+				"storage.mock":        "ok",
+				"transparency.tekton": "ok",
+			},
+			wantBody: "signer.x509: not-configured\n",
+		},
+		{
+			name:       "invalid signing key",
+			secretPath: invalidKeyDir(t),
+			rekor:      &mockRekor{},
+			wantData: map[string]string{
+				"ready":               "false",
+				"degraded":            "true",
+				"signer.x509":         "failed",
+				"storage.mock":        "ok",
+				"transparency.tekton": "ok",
+			},
+			wantBody: "signer.x509: failed\n",
+		},
+		{
+			name:       "transparency log unavailable",
+			secretPath: "./signing/x509/testdata/",
+			rekor:      &mockRekor{unavailable: true},
+			wantData: map[string]string{
+				"ready":               "false",
+				"degraded":            "true",
+				"signer.x509":         "ok",
+				"storage.mock":        "ok",
+				"transparency.tekton": "failed",
+			},
+			wantBody: "transparency.tekton: failed\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cleanup := setupMocks([]*mockBackend{{backendType: "mock"}}, tt.rekor)
+			defer cleanup()
+
+			ctx := context.Background()
+			kc := fake.NewSimpleClientset()
+			hc := &HealthChecker{KubeClient: kc, SecretPath: tt.secretPath, Namespace: "tekton-chains"}
+			srv := httptest.NewServer(hc)
+			defer srv.Close()
+			if code, _ := getStatus(t, srv.URL); code != http.StatusServiceUnavailable {
+				t.Errorf("expected %d before checking, got %d", http.StatusServiceUnavailable, code)
+			}
+
+			h := hc.Check(ctx, healthConfig(), logtesting.TestLogger(t))
+			if h.Ready() != tt.wantReady {
+				t.Errorf("Ready() = %t, want %t: %v", h.Ready(), tt.wantReady, h.Err())
+			}
+			wantCode := http.StatusServiceUnavailable
+			if tt.wantReady {
+				wantCode = http.StatusOK
+			}
+			if code, body := getStatus(t, srv.URL); code != wantCode || body != tt.wantBody {
+				t.Errorf("expected %d %q, got %d %q", wantCode, tt.wantBody, code, body)
+			}
+
+			cm, err := kc.CoreV1().ConfigMaps("tekton-chains").Get(ctx, StatusConfigMapName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(cm.Data) != 6 || cm.Data["checked-at"] == "" {
+				t.Errorf("unexpected status %v", cm.Data)
+			}
+			for k, v := range tt.wantData {
+				if cm.Data[k] != v {
+					t.Errorf("status %s = %q, want %q", k, cm.Data[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthChecker_Watch(t *testing.T) {
+	oldInterval := unhealthyRecheckInterval
+	unhealthyRecheckInterval = 10 * time.Millisecond
+	defer func() { unhealthyRecheckInterval = oldInterval }()
+	rekor := &mockRekor{unavailable: true}
+	cleanup := setupMocks([]*mockBackend{{backendType: "mock"}}, rekor)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hc := &HealthChecker{KubeClient: fake.NewSimpleClientset(), SecretPath: "./signing/x509/testdata/", Namespace: "tekton-chains"}
+	defer func() {
+		cancel()
+		hc.watching.Wait()
+	}()
+	hc.Watch(ctx, healthConfig(), logtesting.TestLogger(t))
+	waitForHealth(t, hc, func(h *Health) bool { return h != nil && !h.Ready() })
+
+	// The configuration is checked again without the transparency log.
+	cfg := healthConfig()
+	cfg.Transparency.Enabled = false
+	hc.Watch(ctx, cfg, logtesting.TestLogger(t))
+	waitForHealth(t, hc, func(h *Health) bool { return h.Ready() })
+}
+
+func waitForHealth(t *testing.T, hc *HealthChecker, cond func(*Health) bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond(hc.Health()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the health, last was %+v", hc.Health())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func getStatus(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+// invalidKeyDir returns a directory with a signing key that can't be parsed.
+func invalidKeyDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "x509.pem"), []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tektoncd/chains/pkg/chains/signing/x509"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// FulcioAudience is the audience of the ServiceAccount tokens that Fulcio certificates are requested with.
+	FulcioAudience = "sigstore"
+
+	// identityTokenTTL is how long ServiceAccount tokens are valid for, the minimum the API server allows.
+	identityTokenTTL = 10 * time.Minute
+
+	defaultServiceAccount = "default"
+)
+
+// withTaskRunIdentity returns a copy of ctx with a token for the ServiceAccount tr ran as,
+// if Fulcio certificates are requested with the identity of TaskRuns, or with tr itself if
+// the local CA issues the certificates.
+func withTaskRunIdentity(ctx context.Context, kc kubernetes.Interface, tr *v1beta1.TaskRun, cfg config.Config) (context.Context, error) {
+	if cfg.Signers.X509.CAEnabled {
+		return x509.WithCertIdentity(ctx, x509.CertIdentity{
+			Namespace:      tr.Namespace,
+			TaskRun:        tr.Name,
+			ServiceAccount: serviceAccountName(tr),
+		}), nil
+	}
+	if identityOf(tr, cfg) == "" {
+		return ctx, nil
+	}
+	if kc == nil {
+		return nil, errors.New("no Kubernetes client to request ServiceAccount tokens with")
+	}
+
+	sa := serviceAccountName(tr)
+	expiration := int64(identityTokenTTL.Seconds())
+	token, err := kc.CoreV1().ServiceAccounts(tr.Namespace).CreateToken(ctx, sa, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			Audiences:         []string{FulcioAudience},
+			ExpirationSeconds: &expiration,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "requesting token for ServiceAccount %s/%s", tr.Namespace, sa)
+	}
+	return x509.WithIdentityToken(ctx, token.Status.Token), nil
+}
+
+// identityOf returns the namespace/name of the ServiceAccount whose identity the signers for tr
+// are certified for, or an empty string if it is the controller's. With the local CA, every
+// TaskRun has its own identity.
+func identityOf(tr *v1beta1.TaskRun, cfg config.Config) string {
+	if cfg.Signers.X509.CAEnabled {
+		return tr.Namespace + "/" + tr.Name + "/" + serviceAccountName(tr)
+	}
+	if !cfg.Signers.X509.FulcioEnabled || cfg.Signers.X509.FulcioIdentity != config.FulcioIdentityTaskRun {
+		return ""
+	}
+	return tr.Namespace + "/" + serviceAccountName(tr)
+}
+
+func serviceAccountName(tr *v1beta1.TaskRun) string {
+	if tr.Spec.ServiceAccountName == "" {
+		return defaultServiceAccount
+	}
+	return tr.Spec.ServiceAccountName
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/tektoncd/chains/pkg/chains/signing/x509/fulciotest"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	fakepipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client/fake"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+	fakekubeclient "knative.dev/pkg/client/injection/kube/client/fake"
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+func TestTaskRunSigner_TaskRunIdentity(t *testing.T) {
+	tests := []struct {
+		name           string
+		identity       string
+		serviceAccount string
+		wantSubject    string
+	}{
+		{
+			name:           "taskrun service account",
+			identity:       config.FulcioIdentityTaskRun,
+			serviceAccount: "builder",
+			wantSubject:    "system:serviceaccount:team-a:builder",
+		},
+		{
+			name:        "default service account",
+			identity:    config.FulcioIdentityTaskRun,
+			wantSubject: "system:serviceaccount:team-a:default",
+		},
+		{
+			name:           "controller identity",
+			identity:       config.FulcioIdentityController,
+			serviceAccount: "builder",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fulcio := fulciotest.NewServer(t)
+			backend := &mockBackend{backendType: "mock"}
+			cleanup := setupMocks([]*mockBackend{backend}, &mockRekor{})
+			defer cleanup()
+
+			ctx, _ := rtesting.SetupFakeContext(t)
+			ps := fakepipelineclient.Get(ctx)
+			kc := fakekubeclient.Get(ctx)
+			requests := fakeTokenRequests(kc)
+
+			cfg := &config.Config{
+				Artifacts: config.ArtifactConfigs{
+					TaskRuns: config.Artifact{
+						Format:         "tekton",
+						StorageBackend: sets.NewString("mock"),
+						Signers:        []string{"x509"},
+					},
+				},
+			}
+			cfg.Signers.X509.FulcioEnabled = true
+			cfg.Signers.X509.FulcioAddr = fulcio.URL
+			cfg.Signers.X509.FulcioIdentity = tt.identity
+			ctx = config.ToContext(ctx, cfg)
+
+			tr := &v1beta1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "team-a"},
+				Spec:       v1beta1.TaskRunSpec{ServiceAccountName: tt.serviceAccount},
+			}
+			if _, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Create(ctx, tr, metav1.CreateOptions{}); err != nil {
+				t.Fatalf("error creating fake taskrun: %v", err)
+			}
+			ts := &TaskRunSigner{
+				KubeClient:        kc,
+				Pipelineclientset: ps,
+			}
+			// There is no auth provider for the controller's identity in tests, so
+			// signing with it fails, and only the token requests are checked.
+			if err := ts.SignTaskRun(ctx, tr); err != nil && tt.wantSubject != "" {
+				t.Fatalf("TaskRunSigner.SignTaskRun() error = %v", err)
+			}
+
+			if tt.wantSubject == "" {
+				if len(*requests) != 0 {
+					t.Errorf("expected no ServiceAccount tokens to be requested, got %d", len(*requests))
+				}
+				return
+			}
+			if len(*requests) != 1 {
+				t.Fatalf("expected 1 ServiceAccount token request, got %d", len(*requests))
+			}
+			if diff := cmp.Diff([]string{FulcioAudience}, (*requests)[0].Spec.Audiences); diff != "" {
+				t.Errorf("token audiences (-want +got): %s", diff)
+			}
+			certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(backend.storedCert))
+			if err != nil || len(certs) == 0 {
+				t.Fatalf("expected a certificate to be stored, got %q: %v", backend.storedCert, err)
+			}
+			if uris := certs[0].URIs; len(uris) != 1 || uris[0].String() != tt.wantSubject {
+				t.Errorf("expected a certificate for %s, got %v", tt.wantSubject, uris)
+			}
+		})
+	}
+}
+
+func TestTaskRunSigner_LocalCA(t *testing.T) {
+	backend := &mockBackend{backendType: "mock"}
+	cleanup := setupMocks([]*mockBackend{backend}, &mockRekor{})
+	defer cleanup()
+
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	kc := fakekubeclient.Get(ctx)
+	requests := fakeTokenRequests(kc)
+
+	cfg := &config.Config{
+		Artifacts: config.ArtifactConfigs{
+			TaskRuns: config.Artifact{
+				Format:         "tekton",
+				StorageBackend: sets.NewString("mock"),
+				Signers:        []string{"x509"},
+			},
+		},
+	}
+	cfg.Signers.X509.CAEnabled = true
+	cfg.Signers.X509.CAValidity = 10 * time.Minute
+	ctx = config.ToContext(ctx, cfg)
+
+	tr := &v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "team-a"},
+		Spec:       v1beta1.TaskRunSpec{ServiceAccountName: "builder"},
+	}
+	if _, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Create(ctx, tr, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error creating fake taskrun: %v", err)
+	}
+	ts := &TaskRunSigner{
+		KubeClient:        kc,
+		Pipelineclientset: ps,
+		SecretPath:        writeLocalCA(t),
+	}
+	if err := ts.SignTaskRun(ctx, tr); err != nil {
+		t.Fatalf("TaskRunSigner.SignTaskRun() error = %v", err)
+	}
+
+	if len(*requests) != 0 {
+		t.Errorf("expected no ServiceAccount tokens to be requested, got %d", len(*requests))
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(backend.storedCert))
+	if err != nil || len(certs) == 0 {
+		t.Fatalf("expected a certificate to be stored, got %q: %v", backend.storedCert, err)
+	}
+	var uris []string
+	for _, u := range certs[0].URIs {
+		uris = append(uris, u.String())
+	}
+	want := []string{
+		"https://tekton.dev/namespaces/team-a/taskruns/foo",
+		"https://kubernetes.io/namespaces/team-a/serviceaccounts/builder",
+	}
+	if diff := cmp.Diff(want, uris); diff != "" {
+		t.Errorf("certificate SANs (-want +got): %s", diff)
+	}
+}
+
+// writeLocalCA writes a self-signed CA to a new directory, in the format of the signing secrets.
+func writeLocalCA(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "chains"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(d, "ca.crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(d, "ca.key"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// fakeTokenRequests makes the fake client return tokens for the mock Fulcio server,
+// and returns the TokenRequests it receives.
+func fakeTokenRequests(kc *fake.Clientset) *[]*authenticationv1.TokenRequest {
+	requests := &[]*authenticationv1.TokenRequest{}
+	kc.PrependReactor("create", "serviceaccounts", func(action ktesting.Action) (bool, runtime.Object, error) {
+		create := action.(ktesting.CreateActionImpl)
+		if create.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		req := create.GetObject().(*authenticationv1.TokenRequest).DeepCopy()
+		*requests = append(*requests, req)
+		req.Status.Token = fulciotest.Token(fmt.Sprintf("system:serviceaccount:%s:%s", create.GetNamespace(), create.Name))
+		return true, req, nil
+	})
+	return requests
+}
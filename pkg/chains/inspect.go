@@ -0,0 +1,308 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	sigs "github.com/sigstore/cosign/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/chains/transport"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	versioned "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/logging"
+)
+
+// TaskRunInspection is everything Chains recorded for a TaskRun.
+type TaskRunInspection struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Signed    bool   `json:"signed"`
+	// Transparency is the URL of the transparency log entry, if one was uploaded.
+	Transparency string `json:"transparency,omitempty"`
+	// TransparencyEntry is the entry fetched from the transparency log, if requested.
+	TransparencyEntry json.RawMessage      `json:"transparencyEntry,omitempty"`
+	Artifacts         []ArtifactInspection `json:"artifacts"`
+}
+
+// ArtifactInspection is what one storage backend holds for a signed artifact.
+type ArtifactInspection struct {
+	Type          string `json:"type"`
+	Backend       string `json:"backend"`
+	Ref           string `json:"ref"`
+	PayloadFormat string `json:"payloadFormat"`
+	// Payload is the signed payload, or the payload of the DSSE envelope for wrapped formats.
+	Payload      json.RawMessage         `json:"payload,omitempty"`
+	PayloadType  string                  `json:"payloadType,omitempty"`
+	Signatures   []SignatureInspection   `json:"signatures"`
+	Certificates []CertificateInspection `json:"certificates,omitempty"`
+}
+
+// SignatureInspection is a single base64 encoded signature, along with the key ID
+// recorded in the DSSE envelope it came from.
+type SignatureInspection struct {
+	KeyID     string `json:"keyid,omitempty"`
+	Signature string `json:"sig"`
+}
+
+// CertificateInspection summarizes a certificate stored alongside a signature.
+type CertificateInspection struct {
+	Subject    string    `json:"subject"`
+	Issuer     string    `json:"issuer"`
+	OIDCIssuer string    `json:"oidcIssuer,omitempty"`
+	Serial     string    `json:"serial"`
+	NotBefore  time.Time `json:"notBefore"`
+	NotAfter   time.Time `json:"notAfter"`
+}
+
+// TaskRunInspector reads back what Chains stored for TaskRuns.
+type TaskRunInspector struct {
+	KubeClient        kubernetes.Interface
+	Pipelineclientset versioned.Interface
+	// Backends restricts the storage backends to read from. All configured backends are used if empty.
+	Backends []string
+	// FetchTransparency fetches the transparency log entry recorded on the TaskRun.
+	FetchTransparency bool
+}
+
+func (ti *TaskRunInspector) InspectTaskRun(ctx context.Context, tr *v1beta1.TaskRun) (*TaskRunInspection, error) {
+	cfg := *config.FromContext(ctx)
+	logger := logging.FromContext(ctx)
+
+	allBackends, err := getBackends(ti.Pipelineclientset, ti.KubeClient, logger, tr, cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range ti.Backends {
+		if _, ok := allBackends[b]; !ok {
+			return nil, fmt.Errorf("storage backend %s is not configured", b)
+		}
+	}
+
+	result := &TaskRunInspection{
+		Namespace:    tr.Namespace,
+		Name:         tr.Name,
+		Signed:       Reconciled(tr),
+		Transparency: tr.Annotations[ChainsTransparencyAnnotation],
+		Artifacts:    []ArtifactInspection{},
+	}
+
+	enabledSignableTypes := []artifacts.Signable{
+		&artifacts.TaskRunArtifact{Logger: logger},
+		&artifacts.OCIArtifact{Logger: logger},
+	}
+	allFormats := allFormatters(cfg, logger)
+	for _, signableType := range enabledSignableTypes {
+		if !signableType.Enabled(cfg) {
+			continue
+		}
+		payloadFormat := signableType.PayloadFormat(cfg)
+		wrap := false
+		if payloader, ok := allFormats[payloadFormat]; ok {
+			wrap = payloader.Wrap()
+		}
+		for _, obj := range signableType.ExtractObjects(tr) {
+			for _, backend := range signableType.StorageBackend(cfg).List() {
+				if len(ti.Backends) > 0 && !contains(ti.Backends, backend) {
+					continue
+				}
+				b, ok := allBackends[backend]
+				if !ok {
+					return nil, fmt.Errorf("storage backend %s is not configured", backend)
+				}
+				// Co-signers of unwrapped payloads store their signatures under their own key, but
+				// backends like OCI return every signature of the artifact for each of them.
+				seen := map[string]bool{}
+				for _, key := range signatureKeys(signableType.Key(obj), signableType.Signers(cfg), wrap) {
+					opts := config.StorageOpts{
+						Key:           key,
+						PayloadFormat: payloadFormat,
+					}
+					inspections, err := inspectArtifact(b, opts)
+					if err != nil {
+						return nil, errors.Wrapf(err, "inspecting %s for %s in %s", signableType.Type(), opts.Key, backend)
+					}
+					for _, inspection := range inspections {
+						if seen[inspection.Ref] {
+							continue
+						}
+						seen[inspection.Ref] = true
+						inspection.Type = signableType.Type()
+						result.Artifacts = append(result.Artifacts, inspection)
+					}
+				}
+			}
+		}
+	}
+
+	if ti.FetchTransparency && result.Transparency != "" {
+		entry, err := fetchTransparencyEntry(ctx, cfg.Transparency, result.Transparency)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fetching transparency log entry %s", result.Transparency)
+		}
+		result.TransparencyEntry = entry
+	}
+	return result, nil
+}
+
+func inspectArtifact(b storage.Backend, opts config.StorageOpts) ([]ArtifactInspection, error) {
+	signatures, err := b.RetrieveSignatures(opts)
+	if err != nil {
+		return nil, err
+	}
+	payloads, err := b.RetrievePayloads(opts)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := b.RetrieveCerts(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]string, 0, len(signatures))
+	for ref := range signatures {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+
+	inspections := make([]ArtifactInspection, 0, len(refs))
+	for _, ref := range refs {
+		ai := ArtifactInspection{
+			Backend:       b.Type(),
+			Ref:           ref,
+			PayloadFormat: string(opts.PayloadFormat),
+			Payload:       asJSON(payloads[ref]),
+			Signatures:    []SignatureInspection{},
+		}
+		for _, sig := range signatures[ref] {
+			env, ok := asEnvelope(sig)
+			if !ok {
+				ai.Signatures = append(ai.Signatures, SignatureInspection{
+					Signature: base64.StdEncoding.EncodeToString([]byte(sig)),
+				})
+				continue
+			}
+			ai.PayloadType = env.PayloadType
+			if ai.Payload == nil {
+				if payload, err := base64.StdEncoding.DecodeString(env.Payload); err == nil {
+					ai.Payload = asJSON(string(payload))
+				}
+			}
+			for _, s := range env.Signatures {
+				ai.Signatures = append(ai.Signatures, SignatureInspection{KeyID: s.KeyID, Signature: s.Sig})
+			}
+		}
+		if cert, ok := certs[ref]; ok {
+			if ai.Certificates, err = inspectCertificates(cert); err != nil {
+				return nil, errors.Wrapf(err, "parsing certificate for %s", ref)
+			}
+		}
+		inspections = append(inspections, ai)
+	}
+	return inspections, nil
+}
+
+// asEnvelope returns the DSSE envelope sig holds, if it is one.
+func asEnvelope(sig string) (*dsse.Envelope, bool) {
+	env := &dsse.Envelope{}
+	if err := json.Unmarshal([]byte(sig), env); err != nil || env.PayloadType == "" {
+		return nil, false
+	}
+	return env, true
+}
+
+// asJSON returns payload as is if it is JSON, and as a JSON string otherwise.
+func asJSON(payload string) json.RawMessage {
+	if payload == "" {
+		return nil
+	}
+	if json.Valid([]byte(payload)) {
+		return json.RawMessage(payload)
+	}
+	b, _ := json.Marshal(payload)
+	return b
+}
+
+func inspectCertificates(pem string) ([]CertificateInspection, error) {
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(pem))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]CertificateInspection, 0, len(certs))
+	for _, c := range certs {
+		subject := sigs.CertSubject(c)
+		if subject == "" {
+			subject = c.Subject.String()
+		}
+		result = append(result, CertificateInspection{
+			Subject:    subject,
+			Issuer:     c.Issuer.String(),
+			OIDCIssuer: sigs.CertIssuerExtension(c),
+			Serial:     c.SerialNumber.String(),
+			NotBefore:  c.NotBefore,
+			NotAfter:   c.NotAfter,
+		})
+	}
+	return result, nil
+}
+
+func fetchTransparencyEntry(ctx context.Context, cfg config.TransparencyConfig, url string) (json.RawMessage, error) {
+	client, err := transport.NewClient(cfg.Client, "Authorization")
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if !json.Valid(body) {
+		return nil, errors.New("response is not JSON")
+	}
+	return body, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
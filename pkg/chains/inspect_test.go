@@ -0,0 +1,242 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	fakepipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+func TestTaskRunInspector_InspectTaskRun(t *testing.T) {
+	tests := []struct {
+		name            string
+		format          string
+		backends        []string
+		wantPayloadType string
+		wantKeyID       bool
+		wantErr         bool
+	}{
+		{
+			name:   "tekton",
+			format: "tekton",
+		},
+		{
+			name:            "in-toto",
+			format:          "in-toto",
+			wantPayloadType: "application/vnd.in-toto+json",
+			wantKeyID:       true,
+		},
+		{
+			name:     "selected backend",
+			format:   "tekton",
+			backends: []string{"mock"},
+		},
+		{
+			name:     "unknown backend",
+			format:   "tekton",
+			backends: []string{"gcs"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &mockBackend{backendType: "mock"}
+			cleanup := setupMocks([]*mockBackend{backend}, &mockRekor{})
+			defer cleanup()
+
+			ctx, _ := rtesting.SetupFakeContext(t)
+			ps := fakepipelineclient.Get(ctx)
+			ctx = config.ToContext(ctx, &config.Config{
+				Artifacts: config.ArtifactConfigs{
+					TaskRuns: config.Artifact{
+						Format:         tt.format,
+						StorageBackend: sets.NewString("mock"),
+						Signers:        []string{"x509"},
+					},
+				},
+			})
+
+			tr := &v1beta1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "foo",
+				},
+			}
+			if _, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Create(ctx, tr, metav1.CreateOptions{}); err != nil {
+				t.Fatalf("error creating fake taskrun: %v", err)
+			}
+			ts := &TaskRunSigner{
+				Pipelineclientset: ps,
+				SecretPath:        "./signing/x509/testdata/",
+			}
+			if err := ts.SignTaskRun(ctx, tr); err != nil {
+				t.Fatalf("TaskRunSigner.SignTaskRun() error = %v", err)
+			}
+			signed, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Get(ctx, tr.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error fetching fake taskrun: %v", err)
+			}
+
+			ti := &TaskRunInspector{
+				Pipelineclientset: ps,
+				Backends:          tt.backends,
+			}
+			got, err := ti.InspectTaskRun(ctx, signed)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TaskRunInspector.InspectTaskRun() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if !got.Signed {
+				t.Error("expected TaskRun to be reported as signed")
+			}
+			if len(got.Artifacts) != 1 {
+				t.Fatalf("expected 1 artifact, got %d", len(got.Artifacts))
+			}
+			a := got.Artifacts[0]
+			if a.Type != "tekton" || a.Backend != "mock" || a.PayloadFormat != tt.format {
+				t.Errorf("unexpected artifact %+v", a)
+			}
+			if a.PayloadType != tt.wantPayloadType {
+				t.Errorf("expected payload type %q, got %q", tt.wantPayloadType, a.PayloadType)
+			}
+			if !json.Valid(a.Payload) {
+				t.Errorf("expected a JSON payload, got %s", a.Payload)
+			}
+			if len(a.Signatures) != 1 || a.Signatures[0].Signature == "" {
+				t.Fatalf("expected 1 signature, got %+v", a.Signatures)
+			}
+			if (a.Signatures[0].KeyID != "") != tt.wantKeyID {
+				t.Errorf("unexpected key ID %q", a.Signatures[0].KeyID)
+			}
+		})
+	}
+}
+
+func TestTaskRunInspector_FetchTransparency(t *testing.T) {
+	entry := `{"abc": {"logIndex": 42}}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("logIndex") != "42" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, entry)
+	}))
+	defer srv.Close()
+
+	cleanup := setupMocks([]*mockBackend{{backendType: "mock"}}, &mockRekor{})
+	defer cleanup()
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ctx = config.ToContext(ctx, &config.Config{})
+
+	tr := &v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "foo",
+			Annotations: map[string]string{
+				ChainsTransparencyAnnotation: srv.URL + "/api/v1/log/entries?logIndex=42",
+			},
+		},
+	}
+	ti := &TaskRunInspector{
+		Pipelineclientset: fakepipelineclient.Get(ctx),
+		FetchTransparency: true,
+	}
+	got, err := ti.InspectTaskRun(ctx, tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got.TransparencyEntry) != entry {
+		t.Errorf("expected entry %s, got %s", entry, got.TransparencyEntry)
+	}
+
+	tr.Annotations[ChainsTransparencyAnnotation] = srv.URL + "/api/v1/log/entries?logIndex=1"
+	if _, err := ti.InspectTaskRun(ctx, tr); err == nil {
+		t.Error("expected an error for a missing entry")
+	}
+}
+
+func TestTaskRunInspector_MultipleSigners(t *testing.T) {
+	tests := []struct {
+		format   string
+		wantRefs []string
+		wantSigs int
+	}{
+		{
+			format:   "tekton",
+			wantRefs: []string{"taskrun-uid", "taskrun-uid-kms"},
+			wantSigs: 1,
+		},
+		{
+			format:   "in-toto",
+			wantRefs: []string{"taskrun-uid"},
+			wantSigs: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			defer setupCosigner(t)()
+
+			ctx, _ := rtesting.SetupFakeContext(t)
+			ps := fakepipelineclient.Get(ctx)
+			ctx = config.ToContext(ctx, &config.Config{
+				Artifacts: config.ArtifactConfigs{
+					TaskRuns: config.Artifact{
+						Format:         tt.format,
+						StorageBackend: sets.NewString("tekton"),
+						Signers:        []string{"x509", "kms"},
+					},
+				},
+			})
+			tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: "uid"}}
+			if _, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Create(ctx, tr, metav1.CreateOptions{}); err != nil {
+				t.Fatalf("error creating fake taskrun: %v", err)
+			}
+			ts := &TaskRunSigner{Pipelineclientset: ps, SecretPath: "./signing/x509/testdata/"}
+			if err := ts.SignTaskRun(ctx, tr); err != nil {
+				t.Fatalf("TaskRunSigner.SignTaskRun() error = %v", err)
+			}
+			signed, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Get(ctx, tr.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error fetching fake taskrun: %v", err)
+			}
+
+			got, err := (&TaskRunInspector{Pipelineclientset: ps}).InspectTaskRun(ctx, signed)
+			if err != nil {
+				t.Fatalf("TaskRunInspector.InspectTaskRun() error = %v", err)
+			}
+			refs := []string{}
+			for _, a := range got.Artifacts {
+				refs = append(refs, a.Ref)
+				if len(a.Signatures) != tt.wantSigs {
+					t.Errorf("got %d signatures for %s, want %d", len(a.Signatures), a.Ref, tt.wantSigs)
+				}
+			}
+			if diff := cmp.Diff(tt.wantRefs, refs); diff != "" {
+				t.Errorf("inspected refs (-want +got): %s", diff)
+			}
+		})
+	}
+}
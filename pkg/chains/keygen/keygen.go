@@ -0,0 +1,261 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package keygen generates the signing key of a fresh install, and publishes the public
+// key in a ConfigMap and over HTTP so verifiers can discover it.
+package keygen
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/tektoncd/chains/pkg/chains/signing/x509"
+	"github.com/tektoncd/chains/pkg/config"
+	"go.uber.org/zap"
+	jose "gopkg.in/square/go-jose.v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// SigningSecretName is the name of the Secret with the global signing keys.
+	SigningSecretName = "signing-secrets"
+	// PublicKeyConfigMapName is the name of the ConfigMap the public key is published in.
+	PublicKeyConfigMapName = "chains-public-key"
+
+	// PublicKeyFile holds the PEM encoded public key, in the ConfigMap and over HTTP.
+	PublicKeyFile = "cosign.pub"
+	// JWKSFile holds the public key as a JSON Web Key Set in the ConfigMap.
+	JWKSFile = "jwks.json"
+	// JWKSPath is where the JSON Web Key Set is served over HTTP.
+	JWKSPath = "/.well-known/jwks.json"
+)
+
+// Publisher generates the active x509 key if it doesn't exist yet, and publishes its
+// public key. Its zero value publishes nothing until Reconcile succeeds.
+type Publisher struct {
+	KubeClient kubernetes.Interface
+	// Namespace is the namespace of the signing secrets and the public key ConfigMap.
+	Namespace string
+
+	// reconcileMu serializes Reconcile, which runs for every change of the configuration.
+	reconcileMu sync.Mutex
+	mu          sync.RWMutex
+	published   map[string][]byte
+}
+
+// Reconcile generates the active key in the signing secrets if it doesn't exist, and
+// publishes its public key, if key generation is enabled in cfg.
+func (p *Publisher) Reconcile(ctx context.Context, cfg config.Config, logger *zap.SugaredLogger) error {
+	if !cfg.Signers.X509.GenerateKey {
+		return nil
+	}
+	if cfg.Signers.X509.FulcioEnabled || cfg.Signers.X509.CAEnabled {
+		logger.Info("Not generating a signing key, new keys are certified by Fulcio or the local CA")
+		return nil
+	}
+	p.reconcileMu.Lock()
+	defer p.reconcileMu.Unlock()
+
+	secrets := p.KubeClient.CoreV1().Secrets(p.Namespace)
+	secret, err := secrets.Get(ctx, SigningSecretName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "getting signing secret %s/%s", p.Namespace, SigningSecretName)
+	}
+	keyID := cfg.Signers.X509.ActiveKey
+	if !hasKey(secret.Data, keyID) {
+		key, err := generateKey()
+		if err != nil {
+			return err
+		}
+		secret = secret.DeepCopy()
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[keyFile(keyID, "x509.pem")] = key
+		// Update fails if another replica changed the Secret since, the key it generated is kept then.
+		if secret, err = secrets.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+			return errors.Wrap(err, "storing the generated signing key")
+		}
+		logger.Infof("Generated signing key %s in %s/%s", keyFile(keyID, "x509.pem"), p.Namespace, SigningSecretName)
+	}
+
+	pub, err := publicKey(ctx, secret.Data, keyID, logger)
+	if err != nil {
+		return err
+	}
+	published, err := encode(pub, keyID)
+	if err != nil {
+		return err
+	}
+	if err := p.publish(ctx, published); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.published = published
+	p.mu.Unlock()
+	return nil
+}
+
+// ServeHTTP serves the published public key at /cosign.pub, and as a JSON Web Key Set at
+// /.well-known/jwks.json.
+func (p *Publisher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var file, contentType string
+	switch r.URL.Path {
+	case "/" + PublicKeyFile:
+		file, contentType = PublicKeyFile, "application/x-pem-file"
+	case JWKSPath:
+		file, contentType = JWKSFile, "application/jwk-set+json"
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	p.mu.RLock()
+	data, ok := p.published[file]
+	p.mu.RUnlock()
+	if !ok {
+		http.Error(w, "no public key published", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(data)
+}
+
+// publish creates or updates the public key ConfigMap.
+func (p *Publisher) publish(ctx context.Context, published map[string][]byte) error {
+	data := map[string]string{}
+	for k, v := range published {
+		data[k] = string(v)
+	}
+	cms := p.KubeClient.CoreV1().ConfigMaps(p.Namespace)
+	cm, err := cms.Get(ctx, PublicKeyConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = cms.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      PublicKeyConfigMapName,
+				Namespace: p.Namespace,
+				Labels:    map[string]string{"app.kubernetes.io/part-of": "tekton-chains"},
+			},
+			Data: data,
+		}, metav1.CreateOptions{})
+		return errors.Wrap(err, "creating the public key ConfigMap")
+	}
+	if err != nil {
+		return errors.Wrap(err, "getting the public key ConfigMap")
+	}
+	if reflect.DeepEqual(cm.Data, data) {
+		return nil
+	}
+	cm = cm.DeepCopy()
+	cm.Data = data
+	_, err = cms.Update(ctx, cm, metav1.UpdateOptions{})
+	return errors.Wrap(err, "updating the public key ConfigMap")
+}
+
+// hasKey returns whether data holds the key with keyID, as an x509 or a cosign key.
+func hasKey(data map[string][]byte, keyID string) bool {
+	for _, name := range []string{"x509.pem", "cosign.key"} {
+		if len(data[keyFile(keyID, name)]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// keyFile returns the name of a file of the key with keyID in the signing secrets.
+func keyFile(keyID, name string) string {
+	if keyID != "" {
+		return keyID + "." + name
+	}
+	return name
+}
+
+// generateKey returns a new ECDSA P-256 private key, PKCS#8 PEM encoded.
+func generateKey() ([]byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return cryptoutils.MarshalPrivateKeyToPEM(key)
+}
+
+// publicKey returns the public key of the key with keyID in the signing secret data.
+func publicKey(ctx context.Context, data map[string][]byte, keyID string, logger *zap.SugaredLogger) (crypto.PublicKey, error) {
+	// Load the key like the x509 signer does, so every format it supports can be published.
+	dir, err := ioutil.TempDir("", "chains-signing-secrets")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	for k, v := range data {
+		if k != filepath.Base(k) || k == ".." {
+			continue
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, k), v, 0600); err != nil {
+			return nil, err
+		}
+	}
+	cfg := config.Config{}
+	cfg.Signers.X509.ActiveKey = keyID
+	signer, err := x509.NewSigner(ctx, dir, cfg, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading the signing key")
+	}
+	return signer.PublicKey()
+}
+
+// encode returns the files the public key is published as.
+func encode(pub crypto.PublicKey, keyID string) (map[string][]byte, error) {
+	pemBytes, err := cryptoutils.MarshalPublicKeyToPEM(pub)
+	if err != nil {
+		return nil, err
+	}
+	jwk := jose.JSONWebKey{Key: pub, KeyID: keyID, Use: "sig"}
+	// RSA keys sign with either PKCS#1 v1.5 or PSS, so their algorithm is left out.
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		jwk.Algorithm = fmt.Sprintf("ES%d", k.Curve.Params().BitSize)
+	case ed25519.PublicKey:
+		jwk.Algorithm = "EdDSA"
+	}
+	if jwk.KeyID == "" {
+		// Unnamed keys are identified by their RFC 7638 thumbprint.
+		thumbprint, err := jwk.Thumbprint(crypto.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		jwk.KeyID = base64.RawURLEncoding.EncodeToString(thumbprint)
+	}
+	jwks, err := json.MarshalIndent(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{jwk}}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{PublicKeyFile: pemBytes, JWKSFile: jwks}, nil
+}
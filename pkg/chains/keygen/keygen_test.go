@@ -0,0 +1,197 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keygen
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/tektoncd/chains/pkg/config"
+	jose "gopkg.in/square/go-jose.v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekubeclient "k8s.io/client-go/kubernetes/fake"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+const namespace = "tekton-chains"
+
+func newPublisher(data map[string][]byte) *Publisher {
+	return &Publisher{
+		KubeClient: fakekubeclient.NewSimpleClientset(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: SigningSecretName, Namespace: namespace},
+			Data:       data,
+		}),
+		Namespace: namespace,
+	}
+}
+
+func generateConfig(keyID string) config.Config {
+	cfg := config.Config{}
+	cfg.Signers.X509.GenerateKey = true
+	cfg.Signers.X509.ActiveKey = keyID
+	return cfg
+}
+
+func TestReconcile_Generate(t *testing.T) {
+	ctx := context.Background()
+	logger := logtesting.TestLogger(t)
+	p := newPublisher(nil)
+	if err := p.Reconcile(ctx, generateConfig("2022"), logger); err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := p.KubeClient.CoreV1().Secrets(namespace).Get(ctx, SigningSecretName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := secret.Data["2022.x509.pem"]
+	priv, err := cryptoutils.UnmarshalPEMToPrivateKey(key, cryptoutils.SkipPassword)
+	if err != nil {
+		t.Fatalf("generated key isn't a PEM private key: %v", err)
+	}
+
+	cm, err := p.KubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, PublicKeyConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := cryptoutils.MarshalPublicKeyToPEM(priv.(crypto.Signer).Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cm.Data[PublicKeyFile] != string(want) {
+		t.Errorf("published public key %q doesn't match the generated key %q", cm.Data[PublicKeyFile], want)
+	}
+	var jwks jose.JSONWebKeySet
+	if err := json.Unmarshal([]byte(cm.Data[JWKSFile]), &jwks); err != nil {
+		t.Fatal(err)
+	}
+	if keys := jwks.Key("2022"); len(keys) != 1 || keys[0].Algorithm != "ES256" {
+		t.Errorf("unexpected JWKS %s", cm.Data[JWKSFile])
+	}
+
+	// The key isn't generated again.
+	if err := p.Reconcile(ctx, generateConfig("2022"), logger); err != nil {
+		t.Fatal(err)
+	}
+	secret, err = p.KubeClient.CoreV1().Secrets(namespace).Get(ctx, SigningSecretName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(secret.Data["2022.x509.pem"]) != string(key) {
+		t.Error("the key was generated again")
+	}
+}
+
+func TestReconcile_ExistingKey(t *testing.T) {
+	ctx := context.Background()
+	key, err := ioutil.ReadFile("../signing/x509/testdata/x509.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := newPublisher(map[string][]byte{"x509.pem": key})
+	if err := p.Reconcile(ctx, generateConfig(""), logtesting.TestLogger(t)); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := p.KubeClient.CoreV1().Secrets(namespace).Get(ctx, SigningSecretName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secret.Data) != 1 || string(secret.Data["x509.pem"]) != string(key) {
+		t.Errorf("the signing secret changed: %v", secret.Data)
+	}
+
+	// An unnamed key is identified by its thumbprint.
+	cm, err := p.KubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, PublicKeyConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var jwks jose.JSONWebKeySet
+	if err := json.Unmarshal([]byte(cm.Data[JWKSFile]), &jwks); err != nil {
+		t.Fatal(err)
+	}
+	if len(jwks.Keys) != 1 || jwks.Keys[0].KeyID == "" {
+		t.Errorf("unexpected JWKS %s", cm.Data[JWKSFile])
+	}
+}
+
+func TestReconcile_Disabled(t *testing.T) {
+	ctx := context.Background()
+	p := newPublisher(nil)
+	if err := p.Reconcile(ctx, config.Config{}, logtesting.TestLogger(t)); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := p.KubeClient.CoreV1().Secrets(namespace).Get(ctx, SigningSecretName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secret.Data) != 0 {
+		t.Errorf("expected no key to be generated, got %v", secret.Data)
+	}
+	if _, err := p.KubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, PublicKeyConfigMapName, metav1.GetOptions{}); err == nil {
+		t.Error("expected no public key to be published")
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	p := newPublisher(nil)
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	// Nothing is served until the key is published.
+	if code, _ := get(t, srv.URL+"/"+PublicKeyFile); code != http.StatusNotFound {
+		t.Errorf("expected %d before publishing, got %d", http.StatusNotFound, code)
+	}
+	if err := p.Reconcile(context.Background(), generateConfig(""), logtesting.TestLogger(t)); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		path string
+		file string
+	}{
+		{path: "/" + PublicKeyFile, file: PublicKeyFile},
+		{path: JWKSPath, file: JWKSFile},
+	} {
+		code, body := get(t, srv.URL+tc.path)
+		if code != http.StatusOK {
+			t.Errorf("GET %s: unexpected status %d", tc.path, code)
+		}
+		if body != string(p.published[tc.file]) {
+			t.Errorf("GET %s: got %q, want %q", tc.path, body, p.published[tc.file])
+		}
+	}
+	if code, _ := get(t, srv.URL+"/other"); code != http.StatusNotFound {
+		t.Errorf("expected %d for an unknown path, got %d", http.StatusNotFound, code)
+	}
+}
+
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
@@ -61,6 +61,9 @@ type ProvenanceMetadata struct {
 	BuildFinishedOn *time.Time `json:"buildFinishedOn,omitempty"`
 	// removed: Completeness
 	Reproducible bool `json:"reproducible,omitempty"`
+	// TerminationReason is the reason of the Succeeded condition of a TaskRun that failed, timed
+	// out or was cancelled. It is empty for TaskRuns that succeeded.
+	TerminationReason string `json:"terminationReason,omitempty"`
 }
 
 // ProvenanceMaterial defines the materials used to build an artifact.
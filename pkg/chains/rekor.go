@@ -15,15 +15,23 @@ package chains
 
 import (
 	"context"
+	"encoding/base64"
+	"net/url"
 	"time"
 
+	"github.com/go-openapi/runtime"
+	httptransport "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
 	"github.com/pkg/errors"
 	"github.com/sigstore/cosign/pkg/cosign"
 	rc "github.com/sigstore/rekor/pkg/client"
 	"github.com/sigstore/rekor/pkg/generated/client"
+	"github.com/sigstore/rekor/pkg/generated/client/tlog"
 	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/util"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/chains/transport"
 	"github.com/tektoncd/chains/pkg/config"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	"go.uber.org/zap"
@@ -45,6 +53,9 @@ type rekor struct {
 
 type rekorClient interface {
 	UploadTlog(ctx context.Context, signer signing.Signer, signature, rawPayload []byte, cert, payloadFormat string) (*models.LogEntryAnon, error)
+	VerifyTlog(ctx context.Context, pkoc, signature, rawPayload []byte, payloadFormat string) (*models.LogEntryAnon, error)
+	// CheckTlog checks that the transparency log can be reached.
+	CheckTlog(ctx context.Context) error
 }
 
 func (r *rekor) UploadTlog(ctx context.Context, signer signing.Signer, signature, rawPayload []byte, cert, payloadFormat string) (*models.LogEntryAnon, error) {
@@ -52,10 +63,64 @@ func (r *rekor) UploadTlog(ctx context.Context, signer signing.Signer, signature
 	if err != nil {
 		return nil, errors.Wrap(err, "public key or cert")
 	}
-	if payloadFormat == "in-toto" || payloadFormat == "tekton-provenance" {
-		return cosign.TLogUploadInTotoAttestation(ctx, r.c, signature, pkoc)
+	// Retries and re-reconciles can try to upload the same signature more than once,
+	// so reuse the existing entry if there is one.
+	// Any error other than not finding one is returned, rather than risking a duplicate upload.
+	entry, err := r.findTlogEntry(ctx, signature, rawPayload, pkoc, payloadFormat)
+	if err == nil {
+		r.logger.Infof("Found existing transparency log entry with index %d", *entry.LogIndex)
+		return entry, nil
 	}
-	return cosign.TLogUpload(ctx, r.c, signature, rawPayload, pkoc)
+	if !isTlogEntryNotFound(err) {
+		return nil, errors.Wrap(err, "searching for an existing transparency log entry")
+	}
+	r.logger.Debug("No existing transparency log entry found")
+	if isAttestation(payloadFormat) {
+		return tlogUploadInTotoAttestation(ctx, r.c, signature, pkoc)
+	}
+	return tlogUpload(ctx, r.c, signature, rawPayload, pkoc)
+}
+
+// CheckTlog checks that the transparency log can be reached, by getting its current state.
+func (r *rekor) CheckTlog(ctx context.Context) error {
+	_, err := r.c.Tlog.GetLogInfo(tlog.NewGetLogInfoParamsWithContext(ctx))
+	return err
+}
+
+// VerifyTlog checks that the signature was included in the transparency log, returning its entry.
+func (r *rekor) VerifyTlog(ctx context.Context, pkoc, signature, rawPayload []byte, payloadFormat string) (*models.LogEntryAnon, error) {
+	// cosign verifies the inclusion proof of any entry it finds.
+	return r.findTlogEntry(ctx, signature, rawPayload, pkoc, payloadFormat)
+}
+
+// findTlogEntry searches the transparency log for an entry matching the signature and public key or cert.
+func (r *rekor) findTlogEntry(ctx context.Context, signature, rawPayload, pkoc []byte, payloadFormat string) (*models.LogEntryAnon, error) {
+	// Attestations are stored as in-toto entries, which cosign searches for
+	// with an empty signature and the envelope as the payload.
+	b64Sig := ""
+	payload := signature
+	if !isAttestation(payloadFormat) {
+		b64Sig = base64.StdEncoding.EncodeToString(signature)
+		payload = rawPayload
+	}
+	uuid, _, err := cosignFindTlogEntry(ctx, r.c, b64Sig, payload, pkoc)
+	if err != nil {
+		return nil, err
+	}
+	return getTlogEntry(ctx, r.c, uuid)
+}
+
+// tlogEntryNotFound is the error cosign returns when the search for an entry has no results.
+const tlogEntryNotFound = "signature not found in transparency log"
+
+// isTlogEntryNotFound returns whether err means the transparency log has no matching entry, as
+// opposed to the search failing. cosign doesn't export a sentinel error for it.
+func isTlogEntryNotFound(err error) bool {
+	return errors.Cause(err).Error() == tlogEntryNotFound
+}
+
+func isAttestation(payloadFormat string) bool {
+	return payloadFormat == "in-toto" || payloadFormat == "tekton-provenance"
 }
 
 // return the cert if we have it, otherwise return public key
@@ -75,8 +140,22 @@ func publicKeyOrCert(signer signing.Signer, cert string) ([]byte, error) {
 }
 
 // for testing
-var getRekor = func(url string, l *zap.SugaredLogger) (rekorClient, error) {
-	rekorClient, err := rc.GetRekorClient(url)
+var (
+	cosignFindTlogEntry         = cosign.FindTlogEntry
+	getTlogEntry                = cosign.GetTlogEntry
+	tlogUpload                  = cosign.TLogUpload
+	tlogUploadInTotoAttestation = cosign.TLogUploadInTotoAttestation
+)
+
+// for testing
+var getRekor = func(cfg config.TransparencyConfig, l *zap.SugaredLogger) (rekorClient, error) {
+	var rekorClient *client.Rekor
+	var err error
+	if cfg.Client == (config.ClientConfig{}) {
+		rekorClient, err = rc.GetRekorClient(cfg.URL)
+	} else {
+		rekorClient, err = newRekorClient(cfg)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -86,13 +165,45 @@ var getRekor = func(url string, l *zap.SugaredLogger) (rekorClient, error) {
 	}, nil
 }
 
-func shouldUploadTlog(cfg config.Config, tr *v1beta1.TaskRun) bool {
+// newRekorClient mirrors rc.GetRekorClient, but talks to Rekor with the configured TLS and auth settings.
+func newRekorClient(cfg config.TransparencyConfig) (*client.Rekor, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := transport.NewClient(cfg.Client, "Authorization")
+	if err != nil {
+		return nil, errors.Wrap(err, "configuring rekor client")
+	}
+	rt := httptransport.NewWithClient(u.Host, client.DefaultBasePath, []string{u.Scheme}, httpClient)
+	rt.Consumers["application/yaml"] = rc.YamlConsumer()
+	rt.Consumers["application/x-pem-file"] = runtime.TextConsumer()
+	rt.Consumers["application/pem-certificate-chain"] = runtime.TextConsumer()
+	rt.Producers["application/yaml"] = rc.YamlProducer()
+	rt.Producers["application/timestamp-query"] = runtime.ByteStreamProducer()
+	rt.Consumers["application/timestamp-reply"] = runtime.ByteStreamConsumer()
+
+	registry := strfmt.Default
+	registry.Add("signedCheckpoint", &util.SignedNote{}, util.SignedCheckpointValidator)
+	return client.New(rt, registry), nil
+}
+
+// uploadTlog uploads the signature to the configured transparency log.
+func uploadTlog(ctx context.Context, cfg config.TransparencyConfig, logger *zap.SugaredLogger, signer signing.Signer, signature, rawPayload []byte, payloadFormat string) (*models.LogEntryAnon, error) {
+	rekorClient, err := getRekor(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	return rekorClient.UploadTlog(ctx, signer, signature, rawPayload, signer.Cert(), payloadFormat)
+}
+
+func shouldUploadTlog(cfg config.TransparencyConfig, tr *v1beta1.TaskRun) bool {
 	// if transparency isn't enabled, return false
-	if !cfg.Transparency.Enabled {
+	if !cfg.Enabled {
 		return false
 	}
 	// if transparency is enabled and verification is disabled, return true
-	if !cfg.Transparency.VerifyAnnotation {
+	if !cfg.VerifyAnnotation {
 		return true
 	}
 
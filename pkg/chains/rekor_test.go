@@ -14,10 +14,16 @@ limitations under the License.
 package chains
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
 	"testing"
 
+	"github.com/sigstore/rekor/pkg/generated/client"
+	"github.com/sigstore/rekor/pkg/generated/models"
 	"github.com/tektoncd/chains/pkg/config"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"go.uber.org/zap"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -81,11 +87,135 @@ func TestShouldUploadTlog(t *testing.T) {
 					Annotations: test.annotations,
 				},
 			}
-			cfg := config.Config{Transparency: test.cfg}
-			got := shouldUploadTlog(cfg, tr)
+			got := shouldUploadTlog(test.cfg, tr)
 			if got != test.expected {
 				t.Fatalf("got (%v) doesn't match expected (%v)", got, test.expected)
 			}
 		})
 	}
 }
+
+func TestUploadTlog(t *testing.T) {
+	tests := []struct {
+		description   string
+		payloadFormat string
+		existing      bool
+		findErr       error
+		wantSig       string
+		wantPayload   string
+		wantIndex     int64
+		wantUploads   int
+	}{
+		{
+			description:   "simplesigning, new entry",
+			payloadFormat: "simplesigning",
+			wantSig:       base64.StdEncoding.EncodeToString([]byte("sig")),
+			wantPayload:   "payload",
+			wantIndex:     2,
+			wantUploads:   1,
+		},
+		{
+			description:   "simplesigning, existing entry",
+			payloadFormat: "simplesigning",
+			existing:      true,
+			wantSig:       base64.StdEncoding.EncodeToString([]byte("sig")),
+			wantPayload:   "payload",
+			wantIndex:     1,
+		},
+		{
+			description:   "in-toto, new entry",
+			payloadFormat: "in-toto",
+			wantPayload:   "sig",
+			wantIndex:     2,
+			wantUploads:   1,
+		},
+		{
+			description:   "in-toto, existing entry",
+			payloadFormat: "in-toto",
+			existing:      true,
+			wantPayload:   "sig",
+			wantIndex:     1,
+		},
+		{
+			description:   "search error",
+			payloadFormat: "simplesigning",
+			findErr:       errors.New("searching log query: connection refused"),
+			wantSig:       base64.StdEncoding.EncodeToString([]byte("sig")),
+			wantPayload:   "payload",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			uploads := 0
+			existingIndex, newIndex := int64(1), int64(2)
+			cleanup := setupTlogMocks(
+				func(_ context.Context, _ *client.Rekor, b64Sig string, payload, pubKey []byte) (string, int64, error) {
+					if b64Sig != test.wantSig {
+						t.Errorf("searched with signature %q, expected %q", b64Sig, test.wantSig)
+					}
+					if string(payload) != test.wantPayload {
+						t.Errorf("searched with payload %q, expected %q", payload, test.wantPayload)
+					}
+					if string(pubKey) != "cert" {
+						t.Errorf("searched with public key %q, expected cert", pubKey)
+					}
+					if test.findErr != nil {
+						return "", 0, test.findErr
+					}
+					if !test.existing {
+						return "", 0, errors.New("signature not found in transparency log")
+					}
+					return "uuid", existingIndex, nil
+				},
+				func(_ context.Context, _ *client.Rekor, uuid string) (*models.LogEntryAnon, error) {
+					return &models.LogEntryAnon{LogIndex: &existingIndex}, nil
+				},
+				func() (*models.LogEntryAnon, error) {
+					uploads++
+					return &models.LogEntryAnon{LogIndex: &newIndex}, nil
+				},
+			)
+			defer cleanup()
+
+			r := &rekor{logger: zap.NewNop().Sugar()}
+			entry, err := r.UploadTlog(context.Background(), nil, []byte("sig"), []byte("payload"), "cert", test.payloadFormat)
+			if test.findErr != nil {
+				if err == nil {
+					t.Error("UploadTlog() expected the search error")
+				}
+				if uploads != 0 {
+					t.Errorf("got %d uploads after a search error, expected none", uploads)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UploadTlog() error = %v", err)
+			}
+			if *entry.LogIndex != test.wantIndex {
+				t.Errorf("got log index %d, expected %d", *entry.LogIndex, test.wantIndex)
+			}
+			if uploads != test.wantUploads {
+				t.Errorf("got %d uploads, expected %d", uploads, test.wantUploads)
+			}
+		})
+	}
+}
+
+func setupTlogMocks(
+	find func(context.Context, *client.Rekor, string, []byte, []byte) (string, int64, error),
+	get func(context.Context, *client.Rekor, string) (*models.LogEntryAnon, error),
+	upload func() (*models.LogEntryAnon, error)) func() {
+	oldFind, oldGet, oldUpload, oldUploadInToto := cosignFindTlogEntry, getTlogEntry, tlogUpload, tlogUploadInTotoAttestation
+	cosignFindTlogEntry = find
+	getTlogEntry = get
+	tlogUpload = func(context.Context, *client.Rekor, []byte, []byte, []byte) (*models.LogEntryAnon, error) {
+		return upload()
+	}
+	tlogUploadInTotoAttestation = func(context.Context, *client.Rekor, []byte, []byte) (*models.LogEntryAnon, error) {
+		return upload()
+	}
+	return func() {
+		cosignFindTlogEntry, getTlogEntry, tlogUpload, tlogUploadInTotoAttestation = oldFind, oldGet, oldUpload, oldUploadInToto
+	}
+}
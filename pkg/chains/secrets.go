@@ -0,0 +1,219 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// signingSecret is the key material signers are created from, either the global
+// signing secrets mounted into the controller or a Secret in a TaskRun's namespace.
+type signingSecret struct {
+	// path is the directory the global signing secrets are mounted in.
+	path string
+	// name is the namespace/name of the namespace Secret, which data is from.
+	name string
+	data map[string][]byte
+	// version changes whenever the files in path do, it is empty if they aren't watched.
+	version string
+}
+
+// signersFor returns the signers for tr. If signers.namespace-secret is set and that Secret
+// exists in the namespace of tr, the signers are loaded from it alone. Otherwise they are
+// loaded from the global signing secrets at secretPath.
+func signersFor(ctx context.Context, kc kubernetes.Interface, secretPath string, tr *v1beta1.TaskRun, cfg config.Config, logger *zap.SugaredLogger) (map[string]signing.Signer, error) {
+	secret, err := signingSecretFor(ctx, kc, secretPath, tr, cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	return secret.signers(ctx, cfg, logger)
+}
+
+// signingSecretFor returns the signing secret for tr. The namespace Secret only holds key
+// material: the references of KMS keys are only set in the global configuration, so that a
+// namespace can't sign with any key the controller can reach.
+func signingSecretFor(ctx context.Context, kc kubernetes.Interface, secretPath string, tr *v1beta1.TaskRun, cfg config.Config, logger *zap.SugaredLogger) (*signingSecret, error) {
+	name := cfg.Signers.NamespaceSecret
+	if name == "" || kc == nil {
+		return &signingSecret{path: secretPath}, nil
+	}
+	secret, err := kc.CoreV1().Secrets(tr.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		logger.Debugf("No signing secret %s in namespace %s, using the global signing secrets", name, tr.Namespace)
+		return &signingSecret{path: secretPath}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting signing secret %s/%s", tr.Namespace, name)
+	}
+	logger.Debugf("Using signing secret %s/%s", tr.Namespace, name)
+	return &signingSecret{name: tr.Namespace + "/" + name, data: secret.Data}, nil
+}
+
+// signers creates the signers from the secret.
+func (s *signingSecret) signers(ctx context.Context, cfg config.Config, logger *zap.SugaredLogger) (map[string]signing.Signer, error) {
+	if s.name == "" {
+		return getSigners(ctx, s.path, cfg, logger), nil
+	}
+
+	// The signers read their key material when they are created, so the
+	// files only need to exist until then.
+	dir, err := ioutil.TempDir("", "chains-signing-secrets")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	for k, v := range s.data {
+		if k != filepath.Base(k) || k == ".." {
+			logger.Warnf("Ignoring invalid key %q in signing secret %s", k, s.name)
+			continue
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, k), v, 0600); err != nil {
+			return nil, errors.Wrapf(err, "writing key %s of signing secret %s", k, s.name)
+		}
+	}
+	return getSigners(ctx, dir, cfg, logger), nil
+}
+
+// cacheKey returns a key that changes whenever the signers created from the
+// secret would, because of a change of the key material, cfg or identity.
+func (s *signingSecret) cacheKey(cfg config.Config, identity string) (string, error) {
+	h := sha256.New()
+	signers, err := json.Marshal(cfg.Signers)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00", signers, identity, s.path, s.name)
+
+	data := s.data
+	if s.name == "" {
+		if s.version != "" {
+			fmt.Fprintf(h, "%s\x00", s.version)
+			return hex.EncodeToString(h.Sum(nil)), nil
+		}
+		data = readSecretDir(s.path)
+	}
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s\x00%d\x00", k, len(data[k]))
+		h.Write(data[k])
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// readSecretDir returns the contents of the files in a directory a Secret is mounted in.
+func readSecretDir(path string) map[string][]byte {
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil
+	}
+	data := map[string][]byte{}
+	for _, f := range files {
+		// The files are symlinks into a hidden directory, that Kubernetes swaps
+		// atomically when the Secret changes.
+		if f.IsDir() || strings.HasPrefix(f.Name(), "..") {
+			continue
+		}
+		contents, err := ioutil.ReadFile(filepath.Join(path, f.Name()))
+		if err != nil {
+			continue
+		}
+		data[f.Name()] = contents
+	}
+	return data
+}
+
+// secretWatcher watches the directory the global signing secrets are mounted in, so
+// it doesn't have to be read to find out whether they changed. The zero value is ready to use.
+type secretWatcher struct {
+	once    sync.Once
+	mu      sync.Mutex
+	watched bool
+	changes uint64
+}
+
+// version returns a version of the files in path, that changes whenever they do. It
+// returns "" if path can't be watched, and then has to be read instead.
+func (w *secretWatcher) version(path string, logger *zap.SugaredLogger) string {
+	w.once.Do(func() { w.watch(path, logger) })
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.watched {
+		return ""
+	}
+	return fmt.Sprint(w.changes)
+}
+
+func (w *secretWatcher) watch(path string, logger *zap.SugaredLogger) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logger.Warnf("Not watching the signing secrets in %s: %s", path, err)
+		return
+	}
+	// Kubernetes swaps a symlink in the directory when the Secret changes, so
+	// the files themselves don't need to be watched.
+	if err := watcher.Add(path); err != nil {
+		logger.Warnf("Not watching the signing secrets in %s: %s", path, err)
+		watcher.Close()
+		return
+	}
+	w.watched = true
+	go func() {
+		for {
+			select {
+			case _, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				w.mu.Lock()
+				w.changes++
+				w.mu.Unlock()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				// Events may have been lost, read the directory from now on.
+				logger.Warnf("Error watching the signing secrets in %s: %s", path, err)
+				w.mu.Lock()
+				w.watched = false
+				w.mu.Unlock()
+				watcher.Close()
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	fakepipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client/fake"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	fakekubeclient "knative.dev/pkg/client/injection/kube/client/fake"
+	"knative.dev/pkg/logging"
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+func TestSignersFor(t *testing.T) {
+	teamKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	teamPEM, err := cryptoutils.MarshalPrivateKeyToPEM(teamKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		namespaceSecret string
+		namespace       string
+		wantTeamKey     bool
+		wantKMSRef      string
+	}{
+		{
+			name:      "disabled",
+			namespace: "team-a",
+		},
+		{
+			name:            "namespace secret",
+			namespaceSecret: "team-signing-secrets",
+			namespace:       "team-a",
+			wantTeamKey:     true,
+			wantKMSRef:      "gcpkms://global",
+		},
+		{
+			name:            "fallback",
+			namespaceSecret: "team-signing-secrets",
+			namespace:       "team-b",
+			wantKMSRef:      "gcpkms://global",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := rtesting.SetupFakeContext(t)
+			kc := fakekubeclient.Get(ctx)
+			if _, err := kc.CoreV1().Secrets("team-a").Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-signing-secrets"},
+				Data: map[string][]byte{
+					"x509.pem": teamPEM,
+					// The KMS key can't be chosen by the namespace.
+					"kms.ref": []byte("gcpkms://team-a"),
+				},
+			}, metav1.CreateOptions{}); err != nil {
+				t.Fatal(err)
+			}
+
+			var gotKMSRef string
+			oldSigners := getSigners
+			getSigners = func(ctx context.Context, sp string, cfg config.Config, l *zap.SugaredLogger) map[string]signing.Signer {
+				gotKMSRef = cfg.Signers.KMS.KMSRef
+				return oldSigners(ctx, sp, cfg, l)
+			}
+			defer func() { getSigners = oldSigners }()
+
+			cfg := config.Config{}
+			cfg.Signers.NamespaceSecret = tt.namespaceSecret
+			cfg.Signers.KMS.KMSRef = "gcpkms://global"
+			tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: tt.namespace}}
+			signers, err := signersFor(ctx, kc, "./signing/x509/testdata/", tr, cfg, logging.FromContext(ctx))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s, ok := signers[signing.TypeX509]
+			if !ok {
+				t.Fatal("expected an x509 signer")
+			}
+			pub, err := s.PublicKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := teamKey.PublicKey.Equal(pub); got != tt.wantTeamKey {
+				t.Errorf("signer uses the namespace key: %v, want %v", got, tt.wantTeamKey)
+			}
+			if tt.wantKMSRef != "" && gotKMSRef != tt.wantKMSRef {
+				t.Errorf("got kms ref %q, want %q", gotKMSRef, tt.wantKMSRef)
+			}
+		})
+	}
+}
+
+func TestTaskRunSigner_NamespaceSecret(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM, err := cryptoutils.MarshalPrivateKeyToPEM(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := &mockBackend{backendType: "mock"}
+	cleanup := setupMocks([]*mockBackend{backend}, &mockRekor{})
+	defer cleanup()
+
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	kc := fakekubeclient.Get(ctx)
+	cfg := &config.Config{
+		Artifacts: config.ArtifactConfigs{
+			TaskRuns: config.Artifact{
+				Format:         "tekton",
+				StorageBackend: sets.NewString("mock"),
+				Signers:        []string{"x509"},
+			},
+		},
+	}
+	cfg.Signers.NamespaceSecret = "signing-secrets"
+	ctx = config.ToContext(ctx, cfg)
+
+	if _, err := kc.CoreV1().Secrets("team-a").Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "signing-secrets"},
+		Data:       map[string][]byte{"x509.pem": keyPEM},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "team-a"}}
+	if _, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Create(ctx, tr, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error creating fake taskrun: %v", err)
+	}
+	ts := &TaskRunSigner{
+		KubeClient:        kc,
+		Pipelineclientset: ps,
+		SecretPath:        "./signing/x509/testdata/",
+	}
+	if err := ts.SignTaskRun(ctx, tr); err != nil {
+		t.Fatalf("TaskRunSigner.SignTaskRun() error = %v", err)
+	}
+
+	verifier, err := signature.LoadVerifier(key.Public(), crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.VerifySignature(bytes.NewReader([]byte(backend.storedSignature)), bytes.NewReader(backend.storedPayload)); err != nil {
+		t.Errorf("TaskRun was not signed with the namespace key: %v", err)
+	}
+}
@@ -18,8 +18,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
 	"github.com/tektoncd/chains/pkg/artifacts"
 	"github.com/tektoncd/chains/pkg/chains/formats"
 	"github.com/tektoncd/chains/pkg/chains/formats/intotoite6"
@@ -28,12 +31,16 @@ import (
 	"github.com/tektoncd/chains/pkg/chains/formats/tekton"
 	"github.com/tektoncd/chains/pkg/chains/signing"
 	"github.com/tektoncd/chains/pkg/chains/signing/kms"
+	"github.com/tektoncd/chains/pkg/chains/signing/pkcs11"
+	"github.com/tektoncd/chains/pkg/chains/signing/remote"
 	"github.com/tektoncd/chains/pkg/chains/signing/x509"
 	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/chains/timestamp"
 	"github.com/tektoncd/chains/pkg/config"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	versioned "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes"
 	"knative.dev/pkg/logging"
 )
@@ -46,37 +53,85 @@ type TaskRunSigner struct {
 	KubeClient        kubernetes.Interface
 	Pipelineclientset versioned.Interface
 	SecretPath        string
+
+	// cache keeps the signers between TaskRuns.
+	cache signerCache
+	// secrets tells when the signing secrets in SecretPath change.
+	secrets secretWatcher
 }
 
-// Set this as a var for mocking.
-var getBackends = storage.InitializeBackends
+// Set these as vars for mocking.
+var (
+	getBackends = storage.InitializeBackends
+	getSigners  = allSigners
+)
 
-func allSigners(sp string, cfg config.Config, l *zap.SugaredLogger) map[string]signing.Signer {
+func allSigners(ctx context.Context, sp string, cfg config.Config, l *zap.SugaredLogger) map[string]signing.Signer {
 	all := map[string]signing.Signer{}
-	for _, s := range signing.AllSigners {
-		switch s {
-		case signing.TypeX509:
-			signer, err := x509.NewSigner(sp, cfg, l)
-			if err != nil {
-				l.Warnf("error configuring x509 signer: %s", err)
-				continue
-			}
-			all[s] = signer
-		case signing.TypeKMS:
-			signer, err := kms.NewSigner(cfg.Signers.KMS, l)
-			if err != nil {
-				l.Warnf("error configuring kms signer with config %v: %s", cfg.Signers.KMS, err)
-				continue
-			}
-			all[s] = signer
-		default:
-			// This should never happen, so panic
-			l.Panicf("unsupported signer: %s", s)
+	for _, s := range signerNames(cfg) {
+		signer, err := newSigner(ctx, s, sp, cfg, l)
+		if err != nil {
+			l.Warnf("error configuring %s signer: %s", s, err)
+			continue
 		}
+		all[s] = signer
 	}
 	return all
 }
 
+// signerNames returns every type of signer, and the signers of named x509 keys the artifacts
+// are signed with.
+func signerNames(cfg config.Config) []string {
+	names := append([]string{}, signing.AllSigners...)
+	seen := sets.NewString(names...)
+	for _, a := range []config.Artifact{cfg.Artifacts.TaskRuns, cfg.Artifacts.OCI} {
+		for _, s := range a.Signers {
+			if !seen.Has(s) {
+				seen.Insert(s)
+				names = append(names, s)
+			}
+		}
+	}
+	return names
+}
+
+// newSigner creates the signer of the given type, or of the named x509 key, like x509:<key ID>.
+func newSigner(ctx context.Context, name, sp string, cfg config.Config, l *zap.SugaredLogger) (signing.Signer, error) {
+	signerType, keyID := config.ParseSigner(name)
+	if keyID != "" {
+		cfg.Signers.X509.ActiveKey = keyID
+	}
+	switch signerType {
+	case signing.TypeX509:
+		signer, err := x509.NewSigner(ctx, sp, cfg, l)
+		if err != nil {
+			return nil, err
+		}
+		return signer, nil
+	case signing.TypeKMS:
+		signer, err := kms.NewSigner(sp, cfg.Signers.KMS, l)
+		if err != nil {
+			return nil, errors.Wrapf(err, "with config %v", cfg.Signers.KMS)
+		}
+		return signer, nil
+	case signing.TypePKCS11:
+		signer, err := pkcs11.NewSigner(sp, cfg.Signers.PKCS11, l)
+		if err != nil {
+			return nil, err
+		}
+		return signer, nil
+	case signing.TypeRemote:
+		signer, err := remote.NewSigner(cfg.Signers.Remote, l)
+		if err != nil {
+			return nil, err
+		}
+		return signer, nil
+	}
+	// This should never happen, so panic
+	l.Panicf("unsupported signer: %s", signerType)
+	return nil, nil
+}
+
 func allFormatters(cfg config.Config, l *zap.SugaredLogger) map[formats.PayloadType]formats.Payloader {
 	all := map[formats.PayloadType]formats.Payloader{}
 
@@ -130,13 +185,18 @@ func (ts *TaskRunSigner) SignTaskRun(ctx context.Context, tr *v1beta1.TaskRun) e
 		return err
 	}
 
-	signers := allSigners(ts.SecretPath, cfg, logger)
-	allFormats := allFormatters(cfg, logger)
-
-	rekorClient, err := getRekor(cfg.Transparency.URL, logger)
+	signers, err := ts.signers(ctx, tr, cfg, logger)
 	if err != nil {
 		return err
 	}
+	allFormats := allFormatters(cfg, logger)
+
+	var timestamper *timestamp.Client
+	if cfg.Timestamp.URL != "" {
+		if timestamper, err = timestamp.NewClient(cfg.Timestamp); err != nil {
+			return err
+		}
+	}
 
 	var merr *multierror.Error
 	extraAnnotations := map[string]string{}
@@ -168,59 +228,103 @@ func (ts *TaskRunSigner) SignTaskRun(ctx context.Context, tr *v1beta1.TaskRun) e
 			logger.Infof("Created payload of type %s for TaskRun %s/%s", string(payloadFormat), tr.Namespace, tr.Name)
 
 			// Sign it!
-			signerType := signableType.Signer(cfg)
-			signer, ok := signers[signerType]
-			if !ok {
-				logger.Warnf("No signer %s configured for %s", signerType, signableType.Type())
-				continue
-			}
-
-			if payloader.Wrap() {
-				wrapped, err := signing.Wrap(ctx, signer)
-				if err != nil {
-					return err
-				}
-				logger.Infof("Using wrapped envelope signer for %s", payloader.Type())
-				signer = wrapped
-			}
-
-			logger.Infof("Signing object with %s", signerType)
-			rawPayload, err := json.Marshal(payload)
+			signerTypes := signableType.Signers(cfg)
+			objSigners, err := selectSigners(signers, signerTypes)
 			if err != nil {
-				logger.Warnf("Unable to marshal payload: %v", signerType, obj)
+				// The TaskRun isn't marked signed without its signatures, it is retried instead.
+				logger.Warnf("%s for %s", err, signableType.Type())
+				merr = multierror.Append(merr, errors.Wrapf(err, "signing %s", signableType.Type()))
 				continue
 			}
 
-			signature, err := signer.SignMessage(bytes.NewReader(rawPayload))
+			logger.Infof("Signing object with %s", strings.Join(signerTypes, ", "))
+			rawPayload, err := json.Marshal(payload)
 			if err != nil {
-				logger.Error(err)
+				logger.Warnf("Unable to marshal payload: %v", signerTypes, obj)
 				continue
 			}
 
-			// Now store those!
-			for _, backend := range signableType.StorageBackend(cfg).List() {
-				b := allBackends[backend]
-				storageOpts := config.StorageOpts{
-					Key:           signableType.Key(obj),
-					Cert:          signer.Cert(),
-					Chain:         signer.Chain(),
-					PayloadFormat: payloadFormat,
+			// Wrapped formats get a single envelope holding a signature from every signer,
+			// other formats get a signature from each signer, stored under its own key.
+			key := signableType.Key(obj)
+			var signatures []storedSignature
+			if payloader.Wrap() {
+				wrapped, err := signing.Wrap(ctx, objSigners...)
+				if err != nil {
+					logger.Error(err)
+					merr = multierror.Append(merr, err)
+					continue
 				}
-				if err := b.StorePayload(rawPayload, string(signature), storageOpts); err != nil {
+				logger.Infof("Using wrapped envelope signer for %s", payloader.Type())
+				signature, err := wrapped.SignMessage(bytes.NewReader(rawPayload))
+				if err != nil {
 					logger.Error(err)
 					merr = multierror.Append(merr, err)
+					continue
+				}
+				signatures = append(signatures, storedSignature{key: key, signature: signature, signer: wrapped, signers: objSigners})
+			} else {
+				for i, signer := range objSigners {
+					signature, err := signer.SignMessage(bytes.NewReader(rawPayload))
+					if err != nil {
+						logger.Error(err)
+						merr = multierror.Append(merr, err)
+						continue
+					}
+					signatures = append(signatures, storedSignature{key: signerKey(key, i, signerTypes[i]), signature: signature, signer: signer, signers: []signing.Signer{signer}})
 				}
 			}
 
-			if shouldUploadTlog(cfg, tr) {
-				entry, err := rekorClient.UploadTlog(ctx, signer, signature, rawPayload, signer.Cert(), string(payloadFormat))
+			for _, sig := range signatures {
+				keyID, err := signing.KeyID(sig.signer)
 				if err != nil {
 					logger.Error(err)
 					merr = multierror.Append(merr, err)
-				} else {
-					logger.Infof("Uploaded entry to %s with index %d", cfg.Transparency.URL, *entry.LogIndex)
+					continue
+				}
+				var token []byte
+				if timestamper != nil {
+					if token, err = timestamper.Timestamp(ctx, sig.signature); err != nil {
+						logger.Error(err)
+						merr = multierror.Append(merr, err)
+						continue
+					}
+				}
+				// Now store those!
+				for _, backend := range signableType.StorageBackend(cfg).List() {
+					b := allBackends[backend]
+					storageOpts := config.StorageOpts{
+						Key:           sig.key,
+						Cert:          sig.signer.Cert(),
+						Chain:         sig.signer.Chain(),
+						KeyID:         keyID,
+						Timestamp:     token,
+						PayloadFormat: payloadFormat,
+					}
+					if err := b.StorePayload(rawPayload, string(sig.signature), storageOpts); err != nil {
+						logger.Error(err)
+						merr = multierror.Append(merr, err)
+					}
+				}
 
-					extraAnnotations[ChainsTransparencyAnnotation] = fmt.Sprintf("%s/api/v1/log/entries?logIndex=%d", cfg.Transparency.URL, *entry.LogIndex)
+				transparency := signableType.Transparency(cfg)
+				if !shouldUploadTlog(transparency, tr) {
+					continue
+				}
+				// Every signer gets its own entry, so each signature can be found with its key.
+				for _, signer := range sig.signers {
+					entry, err := uploadTlog(ctx, transparency, logger, signer, sig.signature, rawPayload, string(payloadFormat))
+					if err != nil {
+						logger.Error(err)
+						merr = multierror.Append(merr, err)
+						continue
+					}
+					logger.Infof("Uploaded entry to %s with index %d", transparency.URL, *entry.LogIndex)
+
+					// The annotation records the entry of the primary signer.
+					if _, ok := extraAnnotations[ChainsTransparencyAnnotation]; !ok {
+						extraAnnotations[ChainsTransparencyAnnotation] = fmt.Sprintf("%s/api/v1/log/entries?logIndex=%d", transparency.URL, *entry.LogIndex)
+					}
 				}
 			}
 		}
@@ -233,9 +337,58 @@ func (ts *TaskRunSigner) SignTaskRun(ctx context.Context, tr *v1beta1.TaskRun) e
 	}
 
 	// Now mark the TaskRun as signed
+	extraAnnotations[SignedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
 	return MarkSigned(tr, ts.Pipelineclientset, extraAnnotations)
 }
 
+// storedSignature is a signature to store under key. signer provides the certificate to
+// store alongside it, and signers are the signers whose signatures it holds.
+type storedSignature struct {
+	key       string
+	signature []byte
+	signer    signing.Signer
+	signers   []signing.Signer
+}
+
+// selectSigners returns the signers of the given types, in order.
+func selectSigners(signers map[string]signing.Signer, signerTypes []string) ([]signing.Signer, error) {
+	if len(signerTypes) == 0 {
+		return nil, errors.New("no signer configured")
+	}
+	selected := make([]signing.Signer, 0, len(signerTypes))
+	for _, signerType := range signerTypes {
+		signer, ok := signers[signerType]
+		if !ok {
+			return nil, fmt.Errorf("no signer %s configured", signerType)
+		}
+		selected = append(selected, signer)
+	}
+	return selected, nil
+}
+
+// signerKey returns the key the signature of the i-th signer of an unwrapped payload is
+// stored under. The primary signer uses the key of the artifact, co-signers add their type to it,
+// and the ID of their key for named x509 keys: <key>-x509-<key ID>.
+func signerKey(key string, i int, signerType string) string {
+	if i == 0 {
+		return key
+	}
+	return key + "-" + strings.Replace(signerType, ":", "-", 1)
+}
+
+// signatureKeys returns the keys the signatures for an artifact stored under key are found at.
+// Wrapped payloads have a single envelope, other payloads a signature per signer.
+func signatureKeys(key string, signerTypes []string, wrap bool) []string {
+	if wrap || len(signerTypes) == 0 {
+		return []string{key}
+	}
+	keys := make([]string, 0, len(signerTypes))
+	for i, signerType := range signerTypes {
+		keys = append(keys, signerKey(key, i, signerType))
+	}
+	return keys
+}
+
 func HandleRetry(tr *v1beta1.TaskRun, ps versioned.Interface, annotations map[string]string) error {
 	if RetryAvailable(tr) {
 		return AddRetry(tr, ps, annotations)
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signing
+
+import (
+	"bytes"
+	"crypto"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// LoadCertChain reads the PEM encoded certificate for the key of sv from certPath, and the
+// certificates chaining it up to a root from chainPath. Certificates following the first one
+// in certPath are treated as part of the chain. Both files are optional: if certPath does not
+// exist, no certificate is returned.
+func LoadCertChain(certPath, chainPath string, sv signature.PublicKeyProvider) (cert, chain string, err error) {
+	rawCert, err := ioutil.ReadFile(certPath)
+	if os.IsNotExist(err) {
+		return "", "", nil
+	} else if err != nil {
+		return "", "", err
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(bytes.TrimSpace(rawCert))
+	if err != nil {
+		return "", "", errors.Wrapf(err, "parsing %s", certPath)
+	}
+	if len(certs) == 0 {
+		return "", "", errors.Errorf("no certificate found in %s", certPath)
+	}
+
+	rawChain, err := ioutil.ReadFile(chainPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", "", err
+	}
+	chainCerts, err := cryptoutils.UnmarshalCertificatesFromPEM(bytes.TrimSpace(rawChain))
+	if err != nil {
+		return "", "", errors.Wrapf(err, "parsing %s", chainPath)
+	}
+	chainCerts = append(certs[1:], chainCerts...)
+
+	pub, err := sv.PublicKey()
+	if err != nil {
+		return "", "", err
+	}
+	if k, ok := certs[0].PublicKey.(interface{ Equal(crypto.PublicKey) bool }); !ok || !k.Equal(pub) {
+		return "", "", errors.Errorf("the certificate in %s does not match the signing key", certPath)
+	}
+	for i, c := range chainCerts {
+		parent := certs[0]
+		if i > 0 {
+			parent = chainCerts[i-1]
+		}
+		if err := parent.CheckSignatureFrom(c); err != nil {
+			return "", "", errors.Wrapf(err, "certificate %q is not issued by %q", parent.Subject, c.Subject)
+		}
+	}
+
+	leafPEM, err := cryptoutils.MarshalCertificateToPEM(certs[0])
+	if err != nil {
+		return "", "", err
+	}
+	chainPEM, err := cryptoutils.MarshalCertificatesToPEM(chainCerts)
+	if err != nil {
+		return "", "", err
+	}
+	return string(leafPEM), string(chainPEM), nil
+}
@@ -20,11 +20,15 @@ type Signer interface {
 	Type() string
 	Cert() string
 	Chain() string
+	// KeyID returns the explicit ID of the signing key, if it has one.
+	KeyID() string
 }
 
 const (
-	TypeX509 = "x509"
-	TypeKMS  = "kms"
+	TypeX509   = "x509"
+	TypeKMS    = "kms"
+	TypePKCS11 = "pkcs11"
+	TypeRemote = "remote"
 )
 
-var AllSigners = []string{TypeX509, TypeKMS}
+var AllSigners = []string{TypeX509, TypeKMS, TypePKCS11, TypeRemote}
@@ -0,0 +1,130 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// Signature schemes for RSA keys.
+const (
+	RSASchemePKCS1v15 = "pkcs1v15"
+	RSASchemePSS      = "pss"
+)
+
+// NoKeyError means that no key is configured for a signer, as opposed to a key that can't be used.
+type NoKeyError struct {
+	Reason string
+}
+
+func (e *NoKeyError) Error() string {
+	return e.Reason
+}
+
+// IsNoKey returns whether err is, or wraps, a NoKeyError.
+func IsNoKey(err error) bool {
+	var e *NoKeyError
+	return errors.As(err, &e)
+}
+
+// LoadSignerVerifier returns a SignerVerifier for an ECDSA, RSA or Ed25519 private key.
+// RSA keys sign with rsaScheme, which defaults to PKCS#1 v1.5.
+func LoadSignerVerifier(pk crypto.PrivateKey, rsaScheme string) (signature.SignerVerifier, error) {
+	switch k := pk.(type) {
+	case *ecdsa.PrivateKey:
+		return signature.LoadECDSASignerVerifier(k, crypto.SHA256)
+	case *rsa.PrivateKey:
+		switch rsaScheme {
+		case "", RSASchemePKCS1v15:
+			return signature.LoadRSAPKCS1v15SignerVerifier(k, crypto.SHA256)
+		case RSASchemePSS:
+			return signature.LoadRSAPSSSignerVerifier(k, crypto.SHA256, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		return nil, fmt.Errorf("unsupported RSA signature scheme %q", rsaScheme)
+	case ed25519.PrivateKey:
+		return signature.LoadED25519SignerVerifier(k)
+	}
+	return nil, fmt.Errorf("unsupported private key type %T", pk)
+}
+
+// LoadVerifier returns a Verifier for an ECDSA, RSA or Ed25519 public key.
+// RSA signatures are verified with rsaScheme, which defaults to PKCS#1 v1.5.
+func LoadVerifier(pub crypto.PublicKey, rsaScheme string) (signature.Verifier, error) {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		return signature.LoadECDSAVerifier(k, crypto.SHA256)
+	case *rsa.PublicKey:
+		switch rsaScheme {
+		case "", RSASchemePKCS1v15:
+			return signature.LoadRSAPKCS1v15Verifier(k, crypto.SHA256)
+		case RSASchemePSS:
+			// Accept any salt length, not only the one we sign with.
+			return signature.LoadRSAPSSVerifier(k, crypto.SHA256, nil)
+		}
+		return nil, fmt.Errorf("unsupported RSA signature scheme %q", rsaScheme)
+	case ed25519.PublicKey:
+		return signature.LoadED25519Verifier(k)
+	}
+	return nil, fmt.Errorf("unsupported public key type %T", pub)
+}
+
+// SignerVerifierFromCryptoSigner returns a SignerVerifier for a key that can only be used
+// through a crypto.Signer, like one held in an HSM. RSA keys sign with rsaScheme.
+func SignerVerifierFromCryptoSigner(s crypto.Signer, rsaScheme string) (signature.SignerVerifier, error) {
+	pub := s.Public()
+	verifier, err := LoadVerifier(pub, rsaScheme)
+	if err != nil {
+		return nil, err
+	}
+	var opts crypto.SignerOpts = crypto.SHA256
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		if rsaScheme == RSASchemePSS {
+			opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+		}
+	case ed25519.PublicKey:
+		// Ed25519 signs the message itself.
+		opts = crypto.Hash(0)
+	}
+	return &cryptoSignerVerifier{Verifier: verifier, signer: s, opts: opts}, nil
+}
+
+type cryptoSignerVerifier struct {
+	signature.Verifier
+	signer crypto.Signer
+	opts   crypto.SignerOpts
+}
+
+func (c *cryptoSignerVerifier) SignMessage(message io.Reader, _ ...signature.SignOption) ([]byte, error) {
+	digest, err := ioutil.ReadAll(message)
+	if err != nil {
+		return nil, err
+	}
+	if h := c.opts.HashFunc(); h != crypto.Hash(0) {
+		hasher := h.New()
+		hasher.Write(digest)
+		digest = hasher.Sum(nil)
+	}
+	return c.signer.Sign(rand.Reader, digest, c.opts)
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signing
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+func TestSignerVerifierFromCryptoSigner(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		key    crypto.Signer
+		scheme string
+	}{
+		{name: "ecdsa", key: ecdsaKey},
+		{name: "rsa pkcs1v15", key: rsaKey},
+		{name: "rsa pss", key: rsaKey, scheme: RSASchemePSS},
+		{name: "ed25519", key: ed25519Key},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv, err := SignerVerifierFromCryptoSigner(tt.key, tt.scheme)
+			if err != nil {
+				t.Fatal(err)
+			}
+			message := []byte("hello")
+			sig, err := sv.SignMessage(bytes.NewReader(message))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Verify with a verifier that knows nothing about the signer.
+			verifier, err := LoadVerifier(tt.key.Public(), tt.scheme)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)); err != nil {
+				t.Errorf("VerifySignature() = %v", err)
+			}
+			if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("bye"))); err == nil {
+				t.Error("VerifySignature() expected error for a different message")
+			}
+
+			// The signer can be wrapped in DSSE envelopes like any other.
+			wrapped, err := Wrap(context.Background(), &testSigner{SignerVerifier: sv})
+			if err != nil {
+				t.Fatal(err)
+			}
+			env, err := wrapped.SignMessage(bytes.NewReader(message))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := wrapped.VerifySignature(bytes.NewReader(env), bytes.NewReader(message)); err != nil {
+				t.Errorf("VerifySignature() on envelope = %v", err)
+			}
+		})
+	}
+}
+
+func TestSignerVerifierFromCryptoSigner_UnknownScheme(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SignerVerifierFromCryptoSigner(rsaKey, "foo"); err == nil {
+		t.Error("expected an error for an unknown RSA scheme")
+	}
+}
+
+type testSigner struct {
+	signature.SignerVerifier
+}
+
+func (testSigner) Type() string  { return "test" }
+func (testSigner) Cert() string  { return "" }
+func (testSigner) Chain() string { return "" }
+func (testSigner) KeyID() string { return "" }
@@ -16,6 +16,9 @@ package kms
 import (
 	"context"
 	"crypto"
+	"path/filepath"
+
+	"github.com/pkg/errors"
 
 	"github.com/tektoncd/chains/pkg/config"
 
@@ -28,32 +31,43 @@ import (
 
 // Signer exposes methods to sign payloads using a KMS
 type Signer struct {
+	cert  string
+	chain string
 	signature.SignerVerifier
 	logger *zap.SugaredLogger
 }
 
-// NewSigner returns a configured Signer
-func NewSigner(cfg config.KMSSigner, logger *zap.SugaredLogger) (*Signer, error) {
+// NewSigner returns a configured Signer. A certificate for the KMS key is read
+// from kms.crt and kms-chain.pem in secretPath, if present.
+func NewSigner(secretPath string, cfg config.KMSSigner, logger *zap.SugaredLogger) (*Signer, error) {
 	k, err := kms.Get(context.Background(), cfg.KMSRef, crypto.SHA256)
 	if err != nil {
 		return nil, err
 	}
-	return &Signer{
+	s := &Signer{
 		SignerVerifier: k,
 		logger:         logger,
-	}, nil
+	}
+	s.cert, s.chain, err = signing.LoadCertChain(filepath.Join(secretPath, "kms.crt"), filepath.Join(secretPath, "kms-chain.pem"), k)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading kms.crt")
+	}
+	return s, nil
 }
 
 func (s *Signer) Type() string {
 	return signing.TypeKMS
 }
 
-// there is no cert, return nothing
 func (s *Signer) Cert() string {
-	return ""
+	return s.cert
 }
 
-// there is no cert or chain, return nothing
 func (s *Signer) Chain() string {
+	return s.chain
+}
+
+// KeyID returns nothing, the key is identified by its fingerprint.
+func (s *Signer) KeyID() string {
 	return ""
 }
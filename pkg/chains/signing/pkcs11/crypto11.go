@@ -0,0 +1,79 @@
+//go:build pkcs11key
+// +build pkcs11key
+
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkcs11
+
+import (
+	"crypto"
+	"fmt"
+	"sync"
+
+	"github.com/ThalesIgnite/crypto11"
+	"github.com/pkg/errors"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+// Logging into a token is expensive, and modules limit how often they can be initialized,
+// so contexts are kept open for the life of the process.
+var (
+	contextsMu sync.Mutex
+	contexts   = map[string]*crypto11.Context{}
+)
+
+func findKey(cfg config.PKCS11Signer, pin string, id []byte) (crypto.Signer, error) {
+	ctx, err := getContext(cfg, pin)
+	if err != nil {
+		return nil, err
+	}
+	var label []byte
+	if cfg.KeyLabel != "" {
+		label = []byte(cfg.KeyLabel)
+	}
+	key, err := ctx.FindKeyPair(id, label)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding PKCS#11 key pair")
+	}
+	if key == nil {
+		return nil, fmt.Errorf("no PKCS#11 key pair found with label %q and ID %q", cfg.KeyLabel, cfg.KeyID)
+	}
+	return key, nil
+}
+
+func getContext(cfg config.PKCS11Signer, pin string) (*crypto11.Context, error) {
+	c := &crypto11.Config{
+		Path:       cfg.ModulePath,
+		TokenLabel: cfg.TokenLabel,
+		SlotNumber: cfg.Slot,
+		Pin:        pin,
+	}
+	slot := "-"
+	if cfg.Slot != nil {
+		slot = fmt.Sprint(*cfg.Slot)
+	}
+	key := fmt.Sprintf("%s|%s|%s|%s", c.Path, c.TokenLabel, slot, c.Pin)
+
+	contextsMu.Lock()
+	defer contextsMu.Unlock()
+	if ctx, ok := contexts[key]; ok {
+		return ctx, nil
+	}
+	ctx, err := crypto11.Configure(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening PKCS#11 token")
+	}
+	contexts[key] = ctx
+	return ctx, nil
+}
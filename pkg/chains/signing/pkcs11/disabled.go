@@ -0,0 +1,29 @@
+//go:build !pkcs11key
+// +build !pkcs11key
+
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkcs11
+
+import (
+	"crypto"
+
+	"github.com/pkg/errors"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+// PKCS#11 support needs cgo, so it is only built with the pkcs11key tag.
+func findKey(config.PKCS11Signer, string, []byte) (crypto.Signer, error) {
+	return nil, errors.New("PKCS#11 support is not available in this build, rebuild Chains with `-tags pkcs11key`")
+}
@@ -0,0 +1,39 @@
+//go:build !pkcs11key
+// +build !pkcs11key
+
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkcs11
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tektoncd/chains/pkg/config"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestNewSigner_Disabled(t *testing.T) {
+	d := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(d, "pkcs11.pin"), []byte("1234"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.PKCS11Signer{ModulePath: "/lib/softhsm2.so", TokenLabel: "token", KeyLabel: "key"}
+	_, err := NewSigner(d, cfg, logtesting.TestLogger(t))
+	if err == nil || !strings.Contains(err.Error(), "pkcs11key") {
+		t.Errorf("NewSigner() error = %v, expected it to point at the build tag", err)
+	}
+}
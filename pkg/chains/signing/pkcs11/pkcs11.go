@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkcs11
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/config"
+	"go.uber.org/zap"
+)
+
+// Signer exposes methods to sign payloads with a key held in an HSM, through PKCS#11.
+type Signer struct {
+	cert  string
+	chain string
+	signature.SignerVerifier
+	logger *zap.SugaredLogger
+}
+
+// NewSigner returns a Signer for the key pair selected by cfg. The PIN to log into the
+// token is read from pkcs11.pin in secretPath, and a certificate for the key from
+// pkcs11.crt and pkcs11-chain.pem, if present.
+func NewSigner(secretPath string, cfg config.PKCS11Signer, logger *zap.SugaredLogger) (*Signer, error) {
+	if cfg.ModulePath == "" {
+		return nil, errors.New("no PKCS#11 module configured")
+	}
+	if cfg.TokenLabel == "" && cfg.Slot == nil {
+		return nil, errors.New("one of the token label or slot is required")
+	}
+	if cfg.KeyLabel == "" && cfg.KeyID == "" {
+		return nil, errors.New("one of the key label or key ID is required")
+	}
+	var id []byte
+	if cfg.KeyID != "" {
+		var err error
+		if id, err = hex.DecodeString(cfg.KeyID); err != nil {
+			return nil, errors.Wrap(err, "decoding key ID")
+		}
+	}
+	pin, err := ioutil.ReadFile(filepath.Join(secretPath, "pkcs11.pin"))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading pkcs11.pin file")
+	}
+
+	key, err := findKey(cfg, strings.TrimSpace(string(pin)), id)
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("Found PKCS#11 key %q in %s", cfg.KeyLabel, cfg.ModulePath)
+	sv, err := signing.SignerVerifierFromCryptoSigner(key, cfg.RSAScheme)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Signer{SignerVerifier: sv, logger: logger}
+	s.cert, s.chain, err = signing.LoadCertChain(filepath.Join(secretPath, "pkcs11.crt"), filepath.Join(secretPath, "pkcs11-chain.pem"), sv)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading pkcs11.crt")
+	}
+	return s, nil
+}
+
+func (s *Signer) Type() string {
+	return signing.TypePKCS11
+}
+
+func (s *Signer) Cert() string {
+	return s.cert
+}
+
+func (s *Signer) Chain() string {
+	return s.chain
+}
+
+// KeyID returns nothing, the key is identified by its fingerprint.
+func (s *Signer) KeyID() string {
+	return ""
+}
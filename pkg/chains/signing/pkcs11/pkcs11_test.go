@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkcs11
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/tektoncd/chains/pkg/config"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestNewSigner_InvalidConfig(t *testing.T) {
+	slot := 0
+	tests := []struct {
+		name string
+		cfg  config.PKCS11Signer
+		pin  bool
+	}{
+		{
+			name: "no module",
+			cfg:  config.PKCS11Signer{TokenLabel: "token", KeyLabel: "key"},
+			pin:  true,
+		},
+		{
+			name: "no token",
+			cfg:  config.PKCS11Signer{ModulePath: "/lib/softhsm2.so", KeyLabel: "key"},
+			pin:  true,
+		},
+		{
+			name: "no key",
+			cfg:  config.PKCS11Signer{ModulePath: "/lib/softhsm2.so", Slot: &slot},
+			pin:  true,
+		},
+		{
+			name: "invalid key ID",
+			cfg:  config.PKCS11Signer{ModulePath: "/lib/softhsm2.so", TokenLabel: "token", KeyID: "not hex"},
+			pin:  true,
+		},
+		{
+			name: "no PIN",
+			cfg:  config.PKCS11Signer{ModulePath: "/lib/softhsm2.so", TokenLabel: "token", KeyLabel: "key"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := t.TempDir()
+			if tt.pin {
+				if err := ioutil.WriteFile(filepath.Join(d, "pkcs11.pin"), []byte("1234"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := NewSigner(d, tt.cfg, logtesting.TestLogger(t)); err == nil {
+				t.Error("NewSigner() expected an error")
+			}
+		})
+	}
+}
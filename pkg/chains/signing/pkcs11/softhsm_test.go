@@ -0,0 +1,169 @@
+//go:build pkcs11key
+// +build pkcs11key
+
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pkcs11
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/ThalesIgnite/crypto11"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/config"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+// Run with: go test -tags pkcs11key ./pkg/chains/signing/pkcs11/
+// SoftHSM is looked up in the usual locations, or at $SOFTHSM2_MODULE.
+func softHSM(t *testing.T) string {
+	t.Helper()
+	candidates := []string{
+		os.Getenv("SOFTHSM2_MODULE"),
+		"/usr/lib/softhsm/libsofthsm2.so",
+		"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+		"/usr/local/lib/softhsm/libsofthsm2.so",
+		"/opt/homebrew/lib/softhsm/libsofthsm2.so",
+	}
+	for _, c := range candidates {
+		if c == "" {
+			continue
+		}
+		if _, err := os.Stat(c); err == nil {
+			if _, err := exec.LookPath("softhsm2-util"); err != nil {
+				t.Skip("softhsm2-util not found")
+			}
+			return c
+		}
+	}
+	t.Skip("SoftHSM not found, set SOFTHSM2_MODULE")
+	return ""
+}
+
+func TestNewSigner_SoftHSM(t *testing.T) {
+	module := softHSM(t)
+
+	// Create a token in a fresh SoftHSM store.
+	dir := t.TempDir()
+	tokens := filepath.Join(dir, "tokens")
+	if err := os.Mkdir(tokens, 0700); err != nil {
+		t.Fatal(err)
+	}
+	conf := filepath.Join(dir, "softhsm2.conf")
+	if err := ioutil.WriteFile(conf, []byte(fmt.Sprintf("directories.tokendir = %s\n", tokens)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	oldConf, hadConf := os.LookupEnv("SOFTHSM2_CONF")
+	os.Setenv("SOFTHSM2_CONF", conf)
+	defer func() {
+		if hadConf {
+			os.Setenv("SOFTHSM2_CONF", oldConf)
+		} else {
+			os.Unsetenv("SOFTHSM2_CONF")
+		}
+	}()
+	out, err := exec.Command("softhsm2-util", "--init-token", "--free", "--label", "chains", "--so-pin", "5678", "--pin", "1234").CombinedOutput()
+	if err != nil {
+		t.Fatalf("initializing token: %v: %s", err, out)
+	}
+
+	// Generate the key in the token.
+	ctx, err := crypto11.Configure(&crypto11.Config{Path: module, TokenLabel: "chains", Pin: "1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ctx.GenerateECDSAKeyPairWithLabel([]byte{1}, []byte("release"), elliptic.P256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	secretPath := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(secretPath, "pkcs11.pin"), []byte("1234\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     config.PKCS11Signer
+		wantErr bool
+	}{
+		{
+			name: "by label",
+			cfg:  config.PKCS11Signer{ModulePath: module, TokenLabel: "chains", KeyLabel: "release"},
+		},
+		{
+			name: "by ID",
+			cfg:  config.PKCS11Signer{ModulePath: module, TokenLabel: "chains", KeyID: "01"},
+		},
+		{
+			name:    "missing key",
+			cfg:     config.PKCS11Signer{ModulePath: module, TokenLabel: "chains", KeyLabel: "other"},
+			wantErr: true,
+		},
+		{
+			name:    "missing token",
+			cfg:     config.PKCS11Signer{ModulePath: module, TokenLabel: "other", KeyLabel: "release"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := NewSigner(secretPath, tt.cfg, logtesting.TestLogger(t))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewSigner() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			pub, err := signer.PublicKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !key.Public().(*ecdsa.PublicKey).Equal(pub) {
+				t.Error("unexpected public key")
+			}
+			message := []byte("hello")
+			sig, err := signer.SignMessage(bytes.NewReader(message))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := signer.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)); err != nil {
+				t.Errorf("VerifySignature() = %v", err)
+			}
+
+			wrapped, err := signing.Wrap(context.Background(), signer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			env, err := wrapped.SignMessage(bytes.NewReader(message))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := wrapped.VerifySignature(bytes.NewReader(env), bytes.NewReader(message)); err != nil {
+				t.Errorf("VerifySignature() on envelope = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/rpc"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/pkg/errors"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+)
+
+const (
+	// PluginName is the name the signer is dispensed under.
+	PluginName = "signer"
+
+	// timeout bounds connecting to the remote signer and each call to it.
+	timeout = 30 * time.Second
+)
+
+// KeyService is implemented by signer plugins, which hold the signing key.
+type KeyService interface {
+	// Info returns the public key and the certificates of the signing key.
+	Info() (*Info, error)
+	// Sign signs message. The signature must verify with the public key and SHA-256.
+	Sign(message []byte) ([]byte, error)
+}
+
+// Info describes the signing key of a signer plugin.
+type Info struct {
+	// PublicKey is the PEM encoded public key.
+	PublicKey []byte
+	// Cert and Chain are the PEM encoded certificate of the key and its chain, if it has one.
+	Cert  string
+	Chain string
+	// KeyID is the explicit ID of the key, if it has one.
+	KeyID string
+	// RSAScheme is the signature scheme of RSA keys, pkcs1v15 (the default) or pss.
+	RSAScheme string
+}
+
+// Plugin serves and dispenses a KeyService over net/rpc.
+type Plugin struct {
+	// Impl is the KeyService served by the plugin.
+	Impl KeyService
+}
+
+var _ plugin.Plugin = (*Plugin)(nil)
+
+// Server implements plugin.Plugin.
+func (p *Plugin) Server(*plugin.MuxBroker) (interface{}, error) {
+	return &rpcServer{impl: p.Impl}, nil
+}
+
+// Client implements plugin.Plugin.
+func (p *Plugin) Client(_ *plugin.MuxBroker, c *rpc.Client) (interface{}, error) {
+	return &rpcClient{client: c}, nil
+}
+
+type rpcServer struct {
+	impl KeyService
+}
+
+func (s *rpcServer) Info(_ struct{}, resp *Info) error {
+	info, err := s.impl.Info()
+	if err != nil {
+		return err
+	}
+	*resp = *info
+	return nil
+}
+
+func (s *rpcServer) Sign(message []byte, resp *[]byte) error {
+	sig, err := s.impl.Sign(message)
+	if err != nil {
+		return err
+	}
+	*resp = sig
+	return nil
+}
+
+type rpcClient struct {
+	client *rpc.Client
+}
+
+func (c *rpcClient) Info() (*Info, error) {
+	var info Info
+	if err := c.client.Call("Plugin.Info", struct{}{}, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func (c *rpcClient) Sign(message []byte) ([]byte, error) {
+	var sig []byte
+	if err := c.client.Call("Plugin.Sign", message, &sig); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// Serve serves impl on lis until lis is closed.
+func Serve(lis net.Listener, impl KeyService) error {
+	plugins := map[string]plugin.Plugin{PluginName: &Plugin{Impl: impl}}
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			return err
+		}
+		// The plugin writes nothing to the streams forwarded to the client.
+		server := &plugin.RPCServer{
+			Plugins: plugins,
+			Stdout:  strings.NewReader(""),
+			Stderr:  strings.NewReader(""),
+		}
+		go server.ServeConn(conn)
+	}
+}
+
+// Listen listens on address, which is unix://<path>, tcp://<host>:<port> or tls://<host>:<port>.
+// Anyone who can connect can sign with the key, so tcp:// addresses must be on a loopback
+// interface, and tlsConfig, which is required for tls:// addresses, must verify client certificates.
+func Listen(address string, tlsConfig *tls.Config) (net.Listener, error) {
+	network, addr, useTLS, err := parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if network == "tcp" && !useTLS && !isLoopback(addr) {
+		return nil, fmt.Errorf("refusing to listen on %s without TLS, use a loopback address or tls://", address)
+	}
+	if useTLS {
+		if tlsConfig == nil {
+			return nil, errors.New("a TLS configuration is required to listen on a tls:// address")
+		}
+		if tlsConfig.ClientAuth != tls.RequireAndVerifyClientCert || tlsConfig.ClientCAs == nil {
+			return nil, errors.New("client certificates must be verified to listen on a tls:// address")
+		}
+	}
+	lis, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	if useTLS {
+		lis = tls.NewListener(lis, tlsConfig)
+	}
+	return lis, nil
+}
+
+// isLoopback returns whether the host of addr only resolves to loopback addresses.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// dial connects to the KeyService at address.
+func dial(address string, tlsConfig *tls.Config) (KeyService, func(), error) {
+	network, addr, useTLS, err := parseAddress(address)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := net.DialTimeout(network, addr, timeout)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "connecting to remote signer at %s", address)
+	}
+	// A connection is made for every call, so this bounds the call.
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if useTLS {
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig = tlsConfig.Clone()
+			tlsConfig.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+		conn = tls.Client(conn, tlsConfig)
+	}
+	client, err := plugin.NewRPCClient(conn, map[string]plugin.Plugin{PluginName: &Plugin{}})
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, err := client.Dispense(PluginName)
+	if err != nil {
+		client.Close()
+		return nil, nil, errors.Wrapf(err, "connecting to remote signer at %s", address)
+	}
+	return raw.(KeyService), func() { client.Close() }, nil
+}
+
+func parseAddress(address string) (network, addr string, useTLS bool, err error) {
+	i := strings.Index(address, "://")
+	if i < 0 {
+		return "", "", false, fmt.Errorf("invalid remote signer address %q, expected unix://, tcp:// or tls://", address)
+	}
+	scheme, addr := address[:i], address[i+len("://"):]
+	if addr == "" {
+		return "", "", false, fmt.Errorf("invalid remote signer address %q", address)
+	}
+	switch scheme {
+	case "unix":
+		return "unix", addr, false, nil
+	case "tcp":
+		return "tcp", addr, false, nil
+	case "tls":
+		return "tcp", addr, true, nil
+	}
+	return "", "", false, fmt.Errorf("invalid remote signer address %q, expected unix://, tcp:// or tls://", address)
+}
+
+// NewKeyService returns a KeyService that signs with signer. RSA keys sign with rsaScheme.
+func NewKeyService(signer signing.Signer, rsaScheme string) (KeyService, error) {
+	pub, err := signer.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	pem, err := cryptoutils.MarshalPublicKeyToPEM(pub)
+	if err != nil {
+		return nil, err
+	}
+	return &keyService{
+		signer: signer,
+		info: &Info{
+			PublicKey: pem,
+			Cert:      signer.Cert(),
+			Chain:     signer.Chain(),
+			KeyID:     signer.KeyID(),
+			RSAScheme: rsaScheme,
+		},
+	}, nil
+}
+
+type keyService struct {
+	signer signing.Signer
+	info   *Info
+}
+
+func (k *keyService) Info() (*Info, error) {
+	return k.info, nil
+}
+
+func (k *keyService) Sign(message []byte) ([]byte, error) {
+	return k.signer.SignMessage(bytes.NewReader(message))
+}
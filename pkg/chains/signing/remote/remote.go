@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package remote signs with a key held by a signer plugin in another process, reached
+// over a Unix socket or the network, so no key material is in the Chains controller.
+package remote
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/chains/transport"
+	"github.com/tektoncd/chains/pkg/config"
+	"go.uber.org/zap"
+)
+
+// Signer signs with a signer plugin. Signatures are verified locally with the
+// public key of the plugin.
+type Signer struct {
+	signature.Verifier
+	address   string
+	tlsConfig *tls.Config
+	info      *Info
+	logger    *zap.SugaredLogger
+}
+
+var _ signing.Signer = (*Signer)(nil)
+
+// NewSigner returns a Signer for the signer plugin at cfg.Address.
+func NewSigner(cfg config.RemoteSigner, logger *zap.SugaredLogger) (*Signer, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("no remote signer address configured")
+	}
+	tlsConfig, err := transport.NewTLSConfig(cfg.Client)
+	if err != nil {
+		return nil, err
+	}
+	s := &Signer{
+		address:   cfg.Address,
+		tlsConfig: tlsConfig,
+		logger:    logger,
+	}
+	if err := s.call(func(ks KeyService) error {
+		s.info, err = ks.Info()
+		return err
+	}); err != nil {
+		return nil, errors.Wrap(err, "getting the public key of the remote signer")
+	}
+	pub, err := cryptoutils.UnmarshalPEMToPublicKey(s.info.PublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing the public key of the remote signer")
+	}
+	if s.Verifier, err = signing.LoadVerifier(pub, s.info.RSAScheme); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SignMessage signs message with the signer plugin.
+func (s *Signer) SignMessage(message io.Reader, _ ...signature.SignOption) ([]byte, error) {
+	msg, err := ioutil.ReadAll(message)
+	if err != nil {
+		return nil, err
+	}
+	var sig []byte
+	if err := s.call(func(ks KeyService) error {
+		sig, err = ks.Sign(msg)
+		return err
+	}); err != nil {
+		return nil, errors.Wrap(err, "signing with the remote signer")
+	}
+	// Catch a plugin that changed keys, rather than storing signatures nothing verifies.
+	if err := s.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)); err != nil {
+		return nil, errors.Wrap(err, "verifying the signature of the remote signer")
+	}
+	return sig, nil
+}
+
+// call calls f with a connection to the signer plugin, which is closed afterwards.
+func (s *Signer) call(f func(KeyService) error) error {
+	ks, closeFn, err := dial(s.address, s.tlsConfig)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+	return f(ks)
+}
+
+func (s *Signer) Type() string {
+	return signing.TypeRemote
+}
+
+func (s *Signer) Cert() string {
+	return s.info.Cert
+}
+
+func (s *Signer) Chain() string {
+	return s.info.Chain
+}
+
+// KeyID returns the ID of the key, if the signer plugin gave it one.
+func (s *Signer) KeyID() string {
+	return s.info.KeyID
+}
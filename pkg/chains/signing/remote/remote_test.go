@@ -0,0 +1,311 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	chainsx509 "github.com/tektoncd/chains/pkg/chains/signing/x509"
+	"github.com/tektoncd/chains/pkg/config"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestSigner(t *testing.T) {
+	logger := logtesting.TestLogger(t)
+	key, err := chainsx509.NewSigner(context.Background(), "../x509/testdata/", config.Config{}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := "unix://" + filepath.Join(t.TempDir(), "signer.sock")
+	serve(t, address, nil, key)
+
+	s, err := NewSigner(config.RemoteSigner{Address: address}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Type() != signing.TypeRemote {
+		t.Errorf("unexpected type %q", s.Type())
+	}
+	msg := []byte("payload")
+	sig, err := s.SignMessage(bytes.NewReader(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The signature is the one of the key in the plugin.
+	if err := key.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)); err != nil {
+		t.Errorf("signature doesn't verify with the key of the plugin: %v", err)
+	}
+	if err := s.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("other"))); err == nil {
+		t.Error("expected the signature of another payload to be invalid")
+	}
+}
+
+func TestSigner_RSAPSS(t *testing.T) {
+	logger := logtesting.TestLogger(t)
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(d, "x509.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Config{}
+	cfg.Signers.X509.RSAScheme = signing.RSASchemePSS
+	key, err := chainsx509.NewSigner(context.Background(), d, cfg, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, err := NewKeyService(key, signing.RSASchemePSS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := "unix://" + filepath.Join(t.TempDir(), "signer.sock")
+	lis, err := Listen(address, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go Serve(lis, ks)
+	t.Cleanup(func() { lis.Close() })
+
+	s, err := NewSigner(config.RemoteSigner{Address: address}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("payload")
+	sig, err := s.SignMessage(bytes.NewReader(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The plugin reports its scheme, so its PSS signatures verify without calling it.
+	h := sha256.Sum256(msg)
+	if err := rsa.VerifyPSS(&priv.PublicKey, crypto.SHA256, h[:], sig, nil); err != nil {
+		t.Errorf("invalid PSS signature: %v", err)
+	}
+	if err := s.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)); err != nil {
+		t.Errorf("VerifySignature() = %v", err)
+	}
+}
+
+func TestSigner_TLS(t *testing.T) {
+	logger := logtesting.TestLogger(t)
+	key, err := chainsx509.NewSigner(context.Background(), "../x509/testdata/", config.Config{}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	serverCert, serverCA := newCert(t, dir, "server")
+	_, clientCA := newCert(t, dir, "client")
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(mustReadFile(t, clientCA))
+	lis := serve(t, "tls://127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}, key)
+	address := "tls://" + lis.Addr().String()
+
+	if _, err := NewSigner(config.RemoteSigner{Address: address}, logger); err == nil {
+		t.Error("expected an error without trusting the certificate of the plugin")
+	}
+	if _, err := NewSigner(config.RemoteSigner{Address: address, Client: config.ClientConfig{CAPath: serverCA}}, logger); err == nil {
+		t.Error("expected an error without a client certificate")
+	}
+	client := config.ClientConfig{
+		CAPath:   serverCA,
+		CertPath: clientCA,
+		KeyPath:  filepath.Join(dir, "client.key"),
+	}
+	s, err := NewSigner(config.RemoteSigner{Address: address, Client: client}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SignMessage(bytes.NewReader([]byte("payload"))); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListen(t *testing.T) {
+	serverCert, _ := newCert(t, t.TempDir(), "server")
+	verifyClients := &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    x509.NewCertPool(),
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	tests := []struct {
+		address   string
+		tlsConfig *tls.Config
+		wantErr   bool
+	}{
+		{address: "tcp://127.0.0.1:0"},
+		{address: "tcp://localhost:0"},
+		{address: "tcp://[::1]:0"},
+		{address: "tcp://:0", wantErr: true},
+		{address: "tcp://0.0.0.0:0", wantErr: true},
+		{address: "tcp://example.com:0", wantErr: true},
+		{address: "tls://127.0.0.1:0", tlsConfig: verifyClients},
+		{address: "tls://127.0.0.1:0", wantErr: true},
+		{address: "tls://127.0.0.1:0", tlsConfig: &tls.Config{Certificates: []tls.Certificate{serverCert}}, wantErr: true},
+		{address: "tls://127.0.0.1:0", tlsConfig: &tls.Config{Certificates: []tls.Certificate{serverCert}, ClientAuth: tls.RequestClientCert}, wantErr: true},
+	}
+	for _, tt := range tests {
+		lis, err := Listen(tt.address, tt.tlsConfig)
+		if err == nil {
+			lis.Close()
+		}
+		if tt.address == "tcp://[::1]:0" && err != nil && !tt.wantErr {
+			// The host may not have IPv6.
+			continue
+		}
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Listen(%q) error = %v, wantErr %t", tt.address, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSigner_Errors(t *testing.T) {
+	logger := logtesting.TestLogger(t)
+	for _, address := range []string{"", "signer.sock", "http://signer:80", "unix://" + filepath.Join(t.TempDir(), "missing.sock")} {
+		if _, err := NewSigner(config.RemoteSigner{Address: address}, logger); err == nil {
+			t.Errorf("expected an error for address %q", address)
+		}
+	}
+
+	// The plugin returns signatures that don't verify with its public key.
+	key, err := chainsx509.NewSigner(context.Background(), "../x509/testdata/", config.Config{}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := mustKeyService(t, key)
+	address := "unix://" + filepath.Join(t.TempDir(), "signer.sock")
+	lis, err := Listen(address, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go Serve(lis, &badKeyService{KeyService: ks})
+	t.Cleanup(func() { lis.Close() })
+	s, err := NewSigner(config.RemoteSigner{Address: address}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SignMessage(bytes.NewReader([]byte("payload"))); err == nil {
+		t.Error("expected an error for an invalid signature")
+	}
+
+	// The plugin is gone.
+	lis.Close()
+	if _, err := s.SignMessage(bytes.NewReader([]byte("payload"))); err == nil {
+		t.Error("expected an error when the plugin is unavailable")
+	}
+}
+
+type badKeyService struct {
+	KeyService
+}
+
+func (b *badKeyService) Sign(message []byte) ([]byte, error) {
+	return []byte("not a signature"), nil
+}
+
+func serve(t *testing.T, address string, tlsConfig *tls.Config, key signing.Signer) net.Listener {
+	t.Helper()
+	lis, err := Listen(address, tlsConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		if err := Serve(lis, mustKeyService(t, key)); err != nil && !errors.Is(err, net.ErrClosed) {
+			t.Error(err)
+		}
+	}()
+	t.Cleanup(func() { lis.Close() })
+	return lis
+}
+
+func mustKeyService(t *testing.T, key signing.Signer) KeyService {
+	t.Helper()
+	ks, err := NewKeyService(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ks
+}
+
+// newCert returns a self-signed certificate for 127.0.0.1, and writes it to <dir>/<name>.crt and its key to <dir>/<name>.key.
+func newCert(t *testing.T, dir, name string) (tls.Certificate, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile := filepath.Join(dir, name+".crt")
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, certFile
+}
+
+func mustReadFile(t *testing.T, path string) []byte {
+	t.Helper()
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
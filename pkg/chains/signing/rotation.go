@@ -0,0 +1,67 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signing
+
+import (
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+	"golang.org/x/crypto/ssh"
+)
+
+// RetiredKey is a key that no longer signs, but still verifies signatures made before ValidUntil.
+type RetiredKey struct {
+	KeyID      string
+	Verifier   signature.Verifier
+	ValidUntil time.Time
+}
+
+// KeyRing is implemented by signers that keep their retired keys, so rotating
+// the signing key doesn't break the verification of older signatures.
+type KeyRing interface {
+	RetiredKeys() []RetiredKey
+}
+
+// KeyID returns the explicit ID of the key of s, or the SSH fingerprint of its public key
+// if it doesn't have one.
+func KeyID(s Signer) (string, error) {
+	if id := s.KeyID(); id != "" {
+		return id, nil
+	}
+	pub, err := s.PublicKey()
+	if err != nil {
+		return "", err
+	}
+	sshpk, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	return ssh.FingerprintSHA256(sshpk), nil
+}
+
+// ValidRetiredKeys returns the retired keys of v, if it is a KeyRing, that still verify
+// signatures made at signedAt.
+func ValidRetiredKeys(v signature.Verifier, signedAt time.Time) []RetiredKey {
+	kr, ok := v.(KeyRing)
+	if !ok {
+		return nil
+	}
+	valid := []RetiredKey{}
+	for _, k := range kr.RetiredKeys() {
+		if !signedAt.After(k.ValidUntil) {
+			valid = append(valid, k)
+		}
+	}
+	return valid
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signing
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// WrapVerifier returns a Verifier for DSSE envelopes signed by v, the counterpart of Wrap.
+// The signature passed to VerifySignature is the JSON envelope, and the message is the
+// payload it is expected to contain.
+func WrapVerifier(v signature.Verifier) (signature.Verifier, error) {
+	pub, err := v.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return &envelopeVerifier{wrapped: v, pub: pub}, nil
+}
+
+type envelopeVerifier struct {
+	wrapped signature.Verifier
+	pub     crypto.PublicKey
+}
+
+func (v *envelopeVerifier) PublicKey(opts ...signature.PublicKeyOption) (crypto.PublicKey, error) {
+	return v.pub, nil
+}
+
+func (v *envelopeVerifier) VerifySignature(sig, message io.Reader, opts ...signature.VerifyOption) error {
+	rawEnvelope, err := ioutil.ReadAll(sig)
+	if err != nil {
+		return err
+	}
+	env := dsse.Envelope{}
+	if err := json.Unmarshal(rawEnvelope, &env); err != nil {
+		return errors.Wrap(err, "decoding envelope")
+	}
+	if err := verifyEnvelope(v.wrapped, &env); err != nil {
+		return errors.Wrap(err, "verifying envelope")
+	}
+
+	// Make sure the envelope contains the payload we were asked to verify.
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return errors.Wrap(err, "decoding envelope payload")
+	}
+	m, err := ioutil.ReadAll(message)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(payload, m) {
+		return fmt.Errorf("envelope payload does not match")
+	}
+	return nil
+}
+
+// verifyEnvelope checks that one of the signatures in env was made by v. Signatures are
+// not matched by key ID, since those are either explicit IDs chosen for the keys or
+// fingerprints of the public keys, depending on how the envelope was signed.
+func verifyEnvelope(v signature.Verifier, env *dsse.Envelope) error {
+	if len(env.Signatures) == 0 {
+		return dsse.ErrNoSignature
+	}
+	body, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return errors.Wrap(err, "decoding envelope payload")
+	}
+	pae := dsse.PAE(env.PayloadType, body)
+	for _, s := range env.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err != nil {
+			return errors.Wrap(err, "decoding signature")
+		}
+		if err = v.VerifySignature(bytes.NewReader(sig), bytes.NewReader(pae)); err == nil {
+			return nil
+		}
+	}
+	return errors.New("no signature in the envelope was made by the key")
+}
+
+// CertVerifyOptions are the checks a certificate has to pass before its key verifies signatures.
+type CertVerifyOptions struct {
+	// Roots are trusted to issue the certificate, through its chain.
+	Roots *x509.CertPool
+	// SignedAt is when the signature was made. The certificate must have been valid then.
+	SignedAt time.Time
+	// Identities are the identities the certificate may have been issued to. One of its email,
+	// URI or DNS SANs must be one of them, so no certificate verifies without any.
+	Identities []string
+}
+
+// VerifyCert parses the PEM encoded cert, and checks that it chains up to opts.Roots through
+// the PEM encoded chain, and that it was valid for one of opts.Identities at opts.SignedAt.
+func VerifyCert(cert, chain []byte, opts CertVerifyOptions) (*x509.Certificate, error) {
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(cert)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing cert")
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate found")
+	}
+	leaf := certs[0]
+
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+	if len(chain) > 0 {
+		chainCerts, err := cryptoutils.UnmarshalCertificatesFromPEM(chain)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing chain")
+		}
+		for _, c := range chainCerts {
+			intermediates.AddCert(c)
+		}
+	}
+
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		// Short-lived certificates (like the ones from Fulcio) will have expired by
+		// the time we verify, so check the chain as of when the signature was made.
+		CurrentTime:   opts.SignedAt,
+		Roots:         opts.Roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}); err != nil {
+		return nil, errors.Wrap(err, "verifying cert chain")
+	}
+
+	if len(opts.Identities) == 0 {
+		return nil, errors.New("no certificate identities are trusted")
+	}
+	got := CertIdentities(leaf)
+	for _, want := range opts.Identities {
+		for _, id := range got {
+			if id == want {
+				return leaf, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("certificate identities %v are not trusted", got)
+}
+
+// CertIdentities returns the email, URI and DNS SANs of c.
+func CertIdentities(c *x509.Certificate) []string {
+	ids := append([]string{}, c.EmailAddresses...)
+	for _, u := range c.URIs {
+		ids = append(ids, u.String())
+	}
+	return append(ids, c.DNSNames...)
+}
+
+// VerifierFromCert returns a Verifier for the public key in the PEM encoded cert, after checking
+// it with VerifyCert. RSA signatures are verified with rsaScheme.
+func VerifierFromCert(cert, chain []byte, opts CertVerifyOptions, rsaScheme string) (signature.Verifier, error) {
+	leaf, err := VerifyCert(cert, chain, opts)
+	if err != nil {
+		return nil, err
+	}
+	return LoadVerifier(leaf.PublicKey, rsaScheme)
+}
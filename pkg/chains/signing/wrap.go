@@ -18,47 +18,75 @@ import (
 	"context"
 	"crypto"
 	"encoding/json"
-	"fmt"
 	"io"
 	"io/ioutil"
 
 	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/pkg/errors"
 	"github.com/secure-systems-lab/go-securesystemslib/dsse"
 	"github.com/sigstore/sigstore/pkg/signature"
 
 	"golang.org/x/crypto/ssh"
 )
 
-func Wrap(ctx context.Context, s Signer) (Signer, error) {
-	pub, err := s.PublicKey()
-	if err != nil {
-		return nil, err
+// Wrap returns a Signer that signs payloads in a DSSE envelope. If more than one signer is
+// passed, the envelope holds a signature from each of them. The first signer is the primary
+// one: its type and public key are the ones of the returned Signer, which carries the
+// certificate of the first signer that has one.
+func Wrap(ctx context.Context, signers ...Signer) (Signer, error) {
+	if len(signers) == 0 {
+		return nil, errors.New("no signers to wrap")
+	}
+	adapters := make([]dsse.SignVerifier, 0, len(signers))
+	for _, s := range signers {
+		pub, err := s.PublicKey()
+		if err != nil {
+			return nil, err
+		}
+		sshpk, err := ssh.NewPublicKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		// The explicit key ID, or the public key fingerprint.
+		keyID, err := KeyID(s)
+		if err != nil {
+			return nil, err
+		}
+
+		adapters = append(adapters, &sslAdapter{
+			wrapped: s,
+			keyID:   keyID,
+			pk:      sshpk,
+		})
 	}
 
-	// Generate public key fingerprint
-	sshpk, err := ssh.NewPublicKey(pub)
+	envelope, err := dsse.NewEnvelopeSigner(adapters...)
 	if err != nil {
 		return nil, err
 	}
-	fingerprint := ssh.FingerprintSHA256(sshpk)
-
-	adapter := sslAdapter{
-		wrapped: s,
-		keyID:   fingerprint,
-		pk:      sshpk,
+	primary := signers[0]
+	pub, err := primary.PublicKey()
+	if err != nil {
+		return nil, err
 	}
-
-	envelope, err := dsse.NewEnvelopeSigner(&adapter)
+	verifier, err := WrapVerifier(primary)
 	if err != nil {
 		return nil, err
 	}
-	return &sslSigner{
-		wrapper: envelope,
-		typ:     s.Type(),
-		pub:     pub,
-		cert:    s.Cert(),
-		chain:   s.Chain(),
-	}, nil
+	w := &sslSigner{
+		wrapper:  envelope,
+		verifier: verifier,
+		typ:      primary.Type(),
+		keyID:    primary.KeyID(),
+		pub:      pub,
+	}
+	for _, s := range signers {
+		if s.Cert() != "" {
+			w.cert, w.chain = s.Cert(), s.Chain()
+			break
+		}
+	}
+	return w, nil
 }
 
 // sslAdapter converts our signing objects into the type expected by the Envelope signer for wrapping.
@@ -82,16 +110,18 @@ func (w *sslAdapter) Public() crypto.PublicKey {
 }
 
 func (w *sslAdapter) Verify(data, sig []byte) error {
-	panic("unimplemented")
+	return w.wrapped.VerifySignature(bytes.NewReader(sig), bytes.NewReader(data))
 }
 
 // sslSigner converts the EnvelopeSigners back into our types, after wrapping.
 type sslSigner struct {
-	wrapper *dsse.EnvelopeSigner
-	typ     string
-	pub     crypto.PublicKey
-	cert    string
-	chain   string
+	wrapper  *dsse.EnvelopeSigner
+	verifier signature.Verifier
+	typ      string
+	keyID    string
+	pub      crypto.PublicKey
+	cert     string
+	chain    string
 }
 
 func (s *sslSigner) Type() string {
@@ -137,6 +167,10 @@ func (w *sslSigner) Chain() string {
 	return w.chain
 }
 
+func (w *sslSigner) KeyID() string {
+	return w.keyID
+}
+
 func (w *sslSigner) VerifySignature(signature, message io.Reader, opts ...signature.VerifyOption) error {
-	return fmt.Errorf("not implemented")
+	return w.verifier.VerifySignature(signature, message, opts...)
 }
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signing
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+)
+
+func TestWrap_MultipleSigners(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers := []Signer{}
+	for _, key := range []crypto.PrivateKey{ecdsaKey, ed25519Key, otherKey} {
+		sv, err := LoadSignerVerifier(key, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		signers = append(signers, &testSigner{SignerVerifier: sv})
+	}
+
+	wrapped, err := Wrap(context.Background(), signers[0], signers[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("hello")
+	raw, err := wrapped.SignMessage(bytes.NewReader(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := dsse.Envelope{}
+	if err := json.Unmarshal(raw, &env); err != nil {
+		t.Fatal(err)
+	}
+	if len(env.Signatures) != 2 || env.Signatures[0].KeyID == env.Signatures[1].KeyID {
+		t.Fatalf("expected a signature from each signer, got %+v", env.Signatures)
+	}
+
+	// Each signer verifies the envelope on its own.
+	for i, s := range signers {
+		v, err := WrapVerifier(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = v.VerifySignature(bytes.NewReader(raw), bytes.NewReader(message))
+		if i < 2 && err != nil {
+			t.Errorf("VerifySignature() with signer %d = %v", i, err)
+		}
+		if i == 2 && err == nil {
+			t.Error("VerifySignature() expected error for a key that did not sign")
+		}
+	}
+
+	if _, err := Wrap(context.Background()); err == nil {
+		t.Error("Wrap() expected error without signers")
+	}
+}
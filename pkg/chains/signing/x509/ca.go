@@ -0,0 +1,202 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package x509
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	cx509 "crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/kms"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/config"
+	"go.uber.org/zap"
+)
+
+const (
+	// ServiceAccountURIFormat is the URI SAN of the ServiceAccount a TaskRun ran as, the
+	// same one Fulcio certifies Kubernetes ServiceAccount tokens with.
+	ServiceAccountURIFormat = "https://kubernetes.io/namespaces/%s/serviceaccounts/%s"
+	// TaskRunURIFormat is the URI SAN of the TaskRun a certificate is issued for.
+	TaskRunURIFormat = "https://tekton.dev/namespaces/%s/taskruns/%s"
+)
+
+// CertIdentity is the TaskRun the local CA issues a certificate for.
+type CertIdentity struct {
+	Namespace      string
+	TaskRun        string
+	ServiceAccount string
+}
+
+type certIdentityKey struct{}
+
+// WithCertIdentity returns a copy of ctx carrying the TaskRun that the local CA
+// certifies the signing key for.
+func WithCertIdentity(ctx context.Context, id CertIdentity) context.Context {
+	return context.WithValue(ctx, certIdentityKey{}, id)
+}
+
+func certIdentity(ctx context.Context) (CertIdentity, bool) {
+	id, ok := ctx.Value(certIdentityKey{}).(CertIdentity)
+	return id, ok
+}
+
+// uris returns the URI SANs of the identity.
+func (id CertIdentity) uris() ([]*url.URL, error) {
+	var uris []*url.URL
+	for _, s := range []string{
+		fmt.Sprintf(TaskRunURIFormat, id.Namespace, id.TaskRun),
+		fmt.Sprintf(ServiceAccountURIFormat, id.Namespace, id.ServiceAccount),
+	} {
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		uris = append(uris, u)
+	}
+	return uris, nil
+}
+
+// localCA is an intermediate CA that issues the signing certificates itself.
+type localCA struct {
+	cert   *cx509.Certificate
+	signer crypto.Signer
+	// chain is the PEM encoded CA certificate, followed by its own chain.
+	chain string
+}
+
+// caSigner signs with a new key, certified by the local CA for the TaskRun in ctx.
+func caSigner(ctx context.Context, secretPath string, cfg config.X509Signer, logger *zap.SugaredLogger) (*Signer, error) {
+	id, ok := certIdentity(ctx)
+	if !ok {
+		return nil, errors.New("no TaskRun to issue a certificate for")
+	}
+	ca, err := loadCA(ctx, secretPath, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading the local CA")
+	}
+
+	priv, err := cosign.GeneratePrivateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "generating key")
+	}
+	sv, err := signature.LoadECDSASignerVerifier(priv, crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	uris, err := id.uris()
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 159))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	notAfter := now.Add(cfg.CAValidity)
+	if notAfter.After(ca.cert.NotAfter) {
+		notAfter = ca.cert.NotAfter
+	}
+	if !now.Before(notAfter) {
+		return nil, fmt.Errorf("the local CA certificate expired at %s", ca.cert.NotAfter)
+	}
+	// The subject is left empty, the identity is in the SANs, like in Fulcio certificates.
+	der, err := cx509.CreateCertificate(rand.Reader, &cx509.Certificate{
+		SerialNumber: serial,
+		NotBefore:    now,
+		NotAfter:     notAfter,
+		KeyUsage:     cx509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []cx509.ExtKeyUsage{cx509.ExtKeyUsageCodeSigning},
+		URIs:         uris,
+	}, ca.cert, priv.Public(), ca.signer)
+	if err != nil {
+		return nil, errors.Wrap(err, "issuing certificate")
+	}
+	cert, err := cryptoutils.MarshalCertificateToPEM(&cx509.Certificate{Raw: der})
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("Issued certificate for TaskRun %s/%s, valid until %s", id.Namespace, id.TaskRun, notAfter)
+	return &Signer{
+		SignerVerifier: sv,
+		cert:           string(cert),
+		chain:          ca.chain,
+		logger:         logger,
+	}, nil
+}
+
+// loadCA loads the CA certificate from ca.crt and ca-chain.pem in secretPath, and its
+// key from KMS if cfg.CAKMSRef is set, or from ca.key otherwise.
+func loadCA(ctx context.Context, secretPath string, cfg config.X509Signer) (*localCA, error) {
+	var signer crypto.Signer
+	var pub signature.PublicKeyProvider
+	if cfg.CAKMSRef != "" {
+		k, err := kms.Get(ctx, cfg.CAKMSRef, crypto.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		if signer, _, err = k.CryptoSigner(ctx, func(error) {}); err != nil {
+			return nil, err
+		}
+		pub = k
+	} else {
+		contents, err := ioutil.ReadFile(filepath.Join(secretPath, "ca.key"))
+		if err != nil {
+			return nil, errors.Wrap(err, "reading ca.key file")
+		}
+		password, err := ioutil.ReadFile(filepath.Join(secretPath, "ca.password"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrap(err, "reading ca.password file")
+		}
+		pk, err := parsePrivateKey(contents, password)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing ca.key")
+		}
+		var ok bool
+		if signer, ok = pk.(crypto.Signer); !ok {
+			return nil, fmt.Errorf("unsupported CA key of type %T", pk)
+		}
+		if pub, err = signing.LoadSignerVerifier(pk, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	cert, chain, err := signing.LoadCertChain(filepath.Join(secretPath, "ca.crt"), filepath.Join(secretPath, "ca-chain.pem"), pub)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading ca.crt")
+	}
+	if cert == "" {
+		return nil, errors.New("no ca.crt found")
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(cert))
+	if err != nil {
+		return nil, err
+	}
+	if !certs[0].IsCA || certs[0].KeyUsage&cx509.KeyUsageCertSign == 0 {
+		return nil, errors.New("ca.crt is not a CA certificate allowed to sign certificates")
+	}
+	return &localCA{cert: certs[0], signer: signer, chain: cert + chain}, nil
+}
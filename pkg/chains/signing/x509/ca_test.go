@@ -0,0 +1,200 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package x509
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	cx509 "crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/config"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func caConfig(validity time.Duration) config.Config {
+	cfg := config.Config{}
+	cfg.Signers.X509.CAEnabled = true
+	cfg.Signers.X509.CAValidity = validity
+	return cfg
+}
+
+// writeCA writes a CA with cert and key to a new directory, in the format of the signing secrets.
+func writeCA(t *testing.T, cert, chain []byte, key *ecdsa.PrivateKey) string {
+	t.Helper()
+	der, err := cx509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := t.TempDir()
+	for name, contents := range map[string][]byte{
+		"ca.crt":       cert,
+		"ca-chain.pem": chain,
+		"ca.key":       pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}),
+	} {
+		if err := ioutil.WriteFile(filepath.Join(d, name), contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return d
+}
+
+func TestSigner_LocalCA(t *testing.T) {
+	root, rootKey := newCert(t, "root", nil, nil, nil)
+	intermediate, intermediateKey := newCert(t, "intermediate", nil, root, rootKey)
+	d := writeCA(t, intermediate, root, intermediateKey)
+
+	ctx := WithCertIdentity(context.Background(), CertIdentity{Namespace: "ns", TaskRun: "build", ServiceAccount: "builder"})
+	signer, err := NewSigner(ctx, d, caConfig(10*time.Minute), logtesting.TestLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer.Chain() != string(intermediate)+string(root) {
+		t.Errorf("unexpected chain %q", signer.Chain())
+	}
+
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(signer.Cert()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := certs[0]
+	var uris []string
+	for _, u := range cert.URIs {
+		uris = append(uris, u.String())
+	}
+	want := []string{
+		"https://tekton.dev/namespaces/ns/taskruns/build",
+		"https://kubernetes.io/namespaces/ns/serviceaccounts/builder",
+	}
+	if diff := cmp.Diff(want, uris); diff != "" {
+		t.Errorf("unexpected SANs (-want, +got): %s", diff)
+	}
+	if validity := cert.NotAfter.Sub(cert.NotBefore); validity != 10*time.Minute {
+		t.Errorf("unexpected validity %s", validity)
+	}
+
+	// The signature verifies with the certificate, which chains up to the root.
+	roots := cx509.NewCertPool()
+	rootCerts, err := cryptoutils.UnmarshalCertificatesFromPEM(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots.AddCert(rootCerts[0])
+	opts := signing.CertVerifyOptions{Roots: roots, SignedAt: time.Now(), Identities: want[1:]}
+	v, err := signing.VerifierFromCert([]byte(signer.Cert()), []byte(signer.Chain()), opts, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("payload")
+	sig, err := signer.SignMessage(bytes.NewReader(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)); err != nil {
+		t.Errorf("signature doesn't verify with the certificate: %v", err)
+	}
+
+	// The certificate is only trusted while it's valid, and for the expected identities.
+	for name, o := range map[string]signing.CertVerifyOptions{
+		"expired":        {Roots: roots, SignedAt: time.Now().Add(time.Hour), Identities: want},
+		"not yet valid":  {Roots: roots, SignedAt: time.Now().Add(-time.Hour), Identities: want},
+		"other identity": {Roots: roots, SignedAt: time.Now(), Identities: []string{"https://kubernetes.io/namespaces/ns/serviceaccounts/other"}},
+		"no identity":    {Roots: roots, SignedAt: time.Now()},
+	} {
+		if _, err := signing.VerifierFromCert([]byte(signer.Cert()), []byte(signer.Chain()), o, ""); err == nil {
+			t.Errorf("VerifierFromCert() expected error for a certificate that is %s", name)
+		}
+	}
+
+	// Every signer has its own key.
+	other, err := NewSigner(ctx, d, caConfig(10*time.Minute), logtesting.TestLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Cert() == signer.Cert() {
+		t.Error("expected a new certificate for every signer")
+	}
+}
+
+func TestSigner_LocalCAValidity(t *testing.T) {
+	root, rootKey := newCert(t, "root", nil, nil, nil)
+	d := writeCA(t, root, nil, rootKey)
+	ctx := WithCertIdentity(context.Background(), CertIdentity{Namespace: "ns", TaskRun: "build", ServiceAccount: "default"})
+
+	// Certificates don't outlive the CA.
+	signer, err := NewSigner(ctx, d, caConfig(24*time.Hour), logtesting.TestLogger(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(signer.Cert()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCerts, err := cryptoutils.UnmarshalCertificatesFromPEM(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !certs[0].NotAfter.Equal(caCerts[0].NotAfter) {
+		t.Errorf("certificate expires at %s, after the CA at %s", certs[0].NotAfter, caCerts[0].NotAfter)
+	}
+}
+
+func TestSigner_LocalCAInvalid(t *testing.T) {
+	root, rootKey := newCert(t, "root", nil, nil, nil)
+	_, otherKey := newCert(t, "other", nil, nil, nil)
+	leaf, leafKey := newCert(t, "leaf", otherKey, root, rootKey)
+	ctx := WithCertIdentity(context.Background(), CertIdentity{Namespace: "ns", TaskRun: "build", ServiceAccount: "default"})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		dir  string
+	}{
+		{
+			name: "no TaskRun",
+			ctx:  context.Background(),
+			dir:  writeCA(t, root, nil, rootKey),
+		},
+		{
+			name: "no CA",
+			ctx:  ctx,
+			dir:  t.TempDir(),
+		},
+		{
+			name: "key doesn't match the certificate",
+			ctx:  ctx,
+			dir:  writeCA(t, root, nil, otherKey),
+		},
+		{
+			name: "not a CA certificate",
+			ctx:  ctx,
+			dir:  writeCA(t, leaf, root, leafKey),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSigner(tt.ctx, tt.dir, caConfig(10*time.Minute), logtesting.TestLogger(t)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package x509
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	cx509 "crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/sigstore/cosign/cmd/cosign/cli/fulcio"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/fulcio/pkg/api"
+	"github.com/sigstore/sigstore/pkg/oauthflow"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/tektoncd/chains/pkg/chains/transport"
+	"github.com/tektoncd/chains/pkg/config"
+	"golang.org/x/oauth2"
+)
+
+const (
+	signingCertPath = "/api/v1/signingCert"
+	rootCertPath    = "/api/v1/rootCert"
+
+	// The Authorization header carries the OIDC token for Fulcio,
+	// so any token for a gateway in front of it goes in Proxy-Authorization.
+	fulcioAuthHeader = "Proxy-Authorization"
+)
+
+type identityTokenKey struct{}
+
+// WithIdentityToken returns a copy of ctx carrying an OIDC token, which Fulcio
+// certificates are then requested with instead of the controller's identity.
+func WithIdentityToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, identityTokenKey{}, token)
+}
+
+func identityToken(ctx context.Context) (string, bool) {
+	tok, ok := ctx.Value(identityTokenKey{}).(string)
+	return tok, ok && tok != ""
+}
+
+// requestCert generates a key pair and requests a certificate for it from Fulcio,
+// for the identity of the OIDC token.
+func requestCert(fClient api.Client, tok string) (*Signer, error) {
+	priv, err := cosign.GeneratePrivateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "generating key")
+	}
+	sv, err := signature.LoadECDSASignerVerifier(priv, crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := cx509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fulcio verifies the token itself, it is only parsed here to prove
+	// possession of the key by signing its subject.
+	idToken, err := (&oauthflow.StaticTokenGetter{RawToken: tok}).GetIDToken(nil, oauth2.Config{})
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256([]byte(idToken.Subject))
+	proof, err := ecdsa.SignASN1(rand.Reader, priv, h[:])
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := fClient.SigningCert(api.CertificateRequest{
+		PublicKey: api.Key{
+			Algorithm: "ecdsa",
+			Content:   pub,
+		},
+		SignedEmailAddress: proof,
+	}, tok)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{
+		SignerVerifier: sv,
+		cert:           string(resp.CertPEM),
+		chain:          string(resp.ChainPEM),
+	}, nil
+}
+
+// newFulcioClient returns a Fulcio API client, using the configured TLS and auth settings if there are any.
+func newFulcioClient(addr string, cfg config.ClientConfig) (api.Client, error) {
+	if cfg == (config.ClientConfig{}) {
+		return fulcio.NewClient(addr)
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := transport.NewClient(cfg, fulcioAuthHeader)
+	if err != nil {
+		return nil, err
+	}
+	return &fulcioClient{baseURL: u, client: httpClient}, nil
+}
+
+// fulcioClient is a copy of the upstream Fulcio API client, which doesn't allow
+// configuring its http.Client.
+type fulcioClient struct {
+	baseURL *url.URL
+	client  *http.Client
+}
+
+var _ api.Client = (*fulcioClient)(nil)
+
+// SigningCert implements api.Client
+func (c *fulcioClient) SigningCert(cr api.CertificateRequest, token string) (*api.CertificateResponse, error) {
+	endpoint := *c.baseURL
+	endpoint.Path = path.Join(endpoint.Path, signingCertPath)
+
+	b, err := json.Marshal(cr)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint.String(), bytes.NewBuffer(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, errors.New(string(body))
+	}
+
+	sct, err := base64.StdEncoding.DecodeString(resp.Header.Get("SCT"))
+	if err != nil {
+		return nil, err
+	}
+	certBlock, chainPem := pem.Decode(body)
+	if certBlock == nil {
+		return nil, errors.New("did not find a cert from Fulcio")
+	}
+	return &api.CertificateResponse{
+		CertPEM:  pem.EncodeToMemory(certBlock),
+		ChainPEM: chainPem,
+		SCT:      sct,
+	}, nil
+}
+
+// RootCert implements api.Client
+func (c *fulcioClient) RootCert() (*api.RootResponse, error) {
+	endpoint := *c.baseURL
+	endpoint.Path = path.Join(endpoint.Path, rootCertPath)
+
+	resp, err := c.client.Get(endpoint.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(string(body))
+	}
+	return &api.RootResponse{ChainPEM: body}, nil
+}
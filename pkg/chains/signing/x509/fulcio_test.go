@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package x509
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	cx509 "crypto/x509"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/tektoncd/chains/pkg/chains/signing/x509/fulciotest"
+	"github.com/tektoncd/chains/pkg/config"
+	logtesting "knative.dev/pkg/logging/testing"
+)
+
+func TestSigner_FulcioIdentityToken(t *testing.T) {
+	fulcio := fulciotest.NewServer(t)
+	cfg := config.Config{}
+	cfg.Signers.X509.FulcioEnabled = true
+	cfg.Signers.X509.FulcioAddr = fulcio.URL
+
+	subject := "system:serviceaccount:team-a:builder"
+	token := fulciotest.Token(subject)
+	ctx := WithIdentityToken(context.Background(), token)
+	s, err := NewSigner(ctx, "", cfg, logtesting.TestLogger(t))
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	if got := fulcio.Tokens(); len(got) != 1 || got[0] != token {
+		t.Errorf("expected the certificate to be requested with the identity token, got %v", got)
+	}
+
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(s.Cert()))
+	if err != nil || len(certs) != 1 {
+		t.Fatalf("expected a certificate, got %q: %v", s.Cert(), err)
+	}
+	cert := certs[0]
+	if len(cert.URIs) != 1 || cert.URIs[0].String() != subject {
+		t.Errorf("expected a certificate for %s, got %v", subject, cert.URIs)
+	}
+	pub, err := s.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.(interface{ Equal(crypto.PublicKey) bool }).Equal(cert.PublicKey) {
+		t.Error("certificate is not for the signing key")
+	}
+	roots := cx509.NewCertPool()
+	roots.AddCert(fulcio.Root)
+	if _, err := cert.Verify(cx509.VerifyOptions{Roots: roots, KeyUsages: []cx509.ExtKeyUsage{cx509.ExtKeyUsageCodeSigning}}); err != nil {
+		t.Errorf("certificate doesn't chain to the Fulcio root: %v", err)
+	}
+	if !bytes.Contains([]byte(s.Chain()), []byte("CERTIFICATE")) {
+		t.Errorf("expected the Fulcio chain, got %q", s.Chain())
+	}
+
+	sig, err := s.SignMessage(bytes.NewReader([]byte("payload")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("payload"))); err != nil {
+		t.Errorf("VerifySignature() error = %v", err)
+	}
+}
+
+func TestSigner_FulcioInvalidToken(t *testing.T) {
+	fulcio := fulciotest.NewServer(t)
+	cfg := config.Config{}
+	cfg.Signers.X509.FulcioEnabled = true
+	cfg.Signers.X509.FulcioAddr = fulcio.URL
+
+	ctx := WithIdentityToken(context.Background(), "not-a-token")
+	if _, err := NewSigner(ctx, "", cfg, logtesting.TestLogger(t)); err == nil {
+		t.Error("NewSigner() expected an error for an invalid token")
+	}
+}
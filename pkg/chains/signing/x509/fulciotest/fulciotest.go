@@ -0,0 +1,194 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fulciotest provides a mock Fulcio server for tests.
+package fulciotest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sigstore/fulcio/pkg/api"
+)
+
+// Server is a mock Fulcio server. Unlike Fulcio, it doesn't verify the signatures of
+// OIDC tokens, and certifies keys for whatever identity the token claims.
+type Server struct {
+	*httptest.Server
+	// Root is the CA certificate that issues the certificates.
+	Root *x509.Certificate
+	// Validity is how long issued certificates are valid for, 10 minutes by default.
+	Validity time.Duration
+
+	rootKey *ecdsa.PrivateKey
+	mu      sync.Mutex
+	tokens  []string
+}
+
+// NewServer starts a mock Fulcio server, which is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fulciotest"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{Root: root, Validity: 10 * time.Minute, rootKey: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/signingCert", s.signingCert)
+	mux.HandleFunc("/api/v1/rootCert", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(s.rootPEM())
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Tokens returns the OIDC tokens that certificates were requested with.
+func (s *Server) Tokens() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.tokens...)
+}
+
+// Token returns an OIDC token for subject. It is not signed, so only
+// the mock server accepts it.
+func Token(subject string) string {
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"ES256"}`))
+	claims, _ := json.Marshal(map[string]interface{}{
+		"sub": subject,
+		"aud": "sigstore",
+		"exp": time.Now().Add(10 * time.Minute).Unix(),
+	})
+	return header + "." + enc.EncodeToString(claims) + "." + enc.EncodeToString([]byte("unsigned"))
+}
+
+func (s *Server) rootPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Root.Raw})
+}
+
+func (s *Server) signingCert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	subject, err := subjectOf(token)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var cr api.CertificateRequest
+	if err := json.NewDecoder(r.Body).Decode(&cr); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pub, err := x509.ParsePKIXPublicKey(cr.PublicKey.Content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ecdsaPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		http.Error(w, "only ecdsa keys are supported", http.StatusBadRequest)
+		return
+	}
+	h := sha256.Sum256([]byte(subject))
+	if !ecdsa.VerifyASN1(ecdsaPub, h[:], cr.SignedEmailAddress) {
+		http.Error(w, "invalid proof of possession of the key", http.StatusBadRequest)
+		return
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(s.Validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	if strings.Contains(subject, "@") {
+		tmpl.EmailAddresses = []string{subject}
+	} else if u, err := url.Parse(subject); err == nil {
+		tmpl.URIs = []*url.URL{u}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, s.Root, pub, s.rootKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.mu.Lock()
+	s.tokens = append(s.tokens, token)
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	w.Write(s.rootPEM())
+}
+
+// subjectOf returns the subject claimed by an OIDC token, without verifying it.
+func subjectOf(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", err
+	}
+	var claims struct {
+		Subject string `json:"sub"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", err
+	}
+	if claims.Subject == "" {
+		return "", fmt.Errorf("no subject in token")
+	}
+	return claims.Subject, nil
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package x509
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"hash"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Encrypted PKCS#8 keys are decrypted with PBES2 (RFC 8018), deriving the key with
+// PBKDF2 or scrypt (RFC 7914) and decrypting with AES-CBC, which covers what
+// `openssl pkcs8 -topk8 -v2 ...` produces.
+var (
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidScrypt = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11591, 4, 11}
+
+	prfs = map[string]func() hash.Hash{
+		asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}.String():  sha1.New,
+		asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 8}.String():  sha256.New224,
+		asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}.String():  sha256.New,
+		asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}.String(): sha512.New384,
+		asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}.String(): sha512.New,
+	}
+	aesKeySizes = map[string]int{
+		asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}.String():  16,
+		asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}.String(): 24,
+		asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}.String(): 32,
+	}
+)
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+type scryptParams struct {
+	Salt            []byte
+	CostParameter   int
+	BlockSize       int
+	Parallelization int
+	KeyLength       int `asn1:"optional"`
+}
+
+// decryptPKCS8 decrypts a DER encoded EncryptedPrivateKeyInfo, returning the DER encoded PrivateKeyInfo.
+func decryptPKCS8(der, password []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, errors.Wrap(err, "parsing encrypted private key")
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported encryption %s, only PBES2 is supported", info.Algorithm.Algorithm)
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, errors.Wrap(err, "parsing PBES2 parameters")
+	}
+
+	keySize, ok := aesKeySizes[params.EncryptionScheme.Algorithm.String()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported cipher %s, only AES-CBC is supported", params.EncryptionScheme.Algorithm)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, errors.Wrap(err, "parsing cipher parameters")
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid IV length %d", len(iv))
+	}
+
+	key, err := deriveKey(params.KeyDerivationFunc, password, keySize)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	data := info.EncryptedData
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("invalid encrypted data length")
+	}
+	decrypted := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, data)
+
+	// Remove the PKCS#7 padding. A bad padding almost always means a wrong password.
+	pad := int(decrypted[len(decrypted)-1])
+	if pad == 0 || pad > aes.BlockSize || !bytes.Equal(decrypted[len(decrypted)-pad:], bytes.Repeat([]byte{byte(pad)}, pad)) {
+		return nil, errors.New("decryption failed, the password is probably incorrect")
+	}
+	return decrypted[:len(decrypted)-pad], nil
+}
+
+func deriveKey(kdf pkix.AlgorithmIdentifier, password []byte, keySize int) ([]byte, error) {
+	switch {
+	case kdf.Algorithm.Equal(oidPBKDF2):
+		var params pbkdf2Params
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, errors.Wrap(err, "parsing PBKDF2 parameters")
+		}
+		if params.KeyLength != 0 && params.KeyLength != keySize {
+			return nil, fmt.Errorf("PBKDF2 key length %d does not match the cipher", params.KeyLength)
+		}
+		prf := sha1.New
+		if len(params.PRF.Algorithm) > 0 {
+			var ok bool
+			if prf, ok = prfs[params.PRF.Algorithm.String()]; !ok {
+				return nil, fmt.Errorf("unsupported PBKDF2 PRF %s", params.PRF.Algorithm)
+			}
+		}
+		return pbkdf2.Key(password, params.Salt, params.IterationCount, keySize, prf), nil
+	case kdf.Algorithm.Equal(oidScrypt):
+		var params scryptParams
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, errors.Wrap(err, "parsing scrypt parameters")
+		}
+		if params.KeyLength != 0 && params.KeyLength != keySize {
+			return nil, fmt.Errorf("scrypt key length %d does not match the cipher", params.KeyLength)
+		}
+		return scrypt.Key(password, params.Salt, params.CostParameter, params.BlockSize, params.Parallelization, keySize)
+	}
+	return nil, fmt.Errorf("unsupported key derivation function %s", kdf.Algorithm)
+}
@@ -16,18 +16,19 @@ package x509
 import (
 	"context"
 	"crypto"
-	"crypto/ecdsa"
 	cx509 "crypto/x509"
 	"encoding/pem"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/pkg/errors"
-	"github.com/sigstore/cosign/cmd/cosign/cli/fulcio"
 	"github.com/sigstore/cosign/pkg/cosign"
 	"github.com/sigstore/cosign/pkg/providers"
 
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature"
 	"github.com/tektoncd/chains/pkg/chains/signing"
 	"github.com/tektoncd/chains/pkg/config"
@@ -35,7 +36,6 @@ import (
 )
 
 const (
-	defaultOIDCIssuer   = "https://oauth2.sigstore.dev/auth"
 	defaultOIDCClientID = "sigstore"
 )
 
@@ -43,78 +43,187 @@ const (
 type Signer struct {
 	cert  string
 	chain string
+	keyID string
+	// retired are the keys that were rotated out, and still verify older signatures.
+	retired []signing.RetiredKey
 	signature.SignerVerifier
 	logger *zap.SugaredLogger
 }
 
-// NewSigner returns a configured Signer
-func NewSigner(secretPath string, cfg config.Config, logger *zap.SugaredLogger) (*Signer, error) {
-	x509PrivateKeyPath := filepath.Join(secretPath, "x509.pem")
-	cosignPrivateKeypath := filepath.Join(secretPath, "cosign.key")
-
+// NewSigner returns a configured Signer. It signs with the active key, or the unnamed
+// one if no key is active, and keeps the retired keys to verify older signatures.
+// With Fulcio enabled, it signs with a new key certified for the identity token in
+// ctx, if there is one, or for the controller's identity. With the local CA enabled,
+// it signs with a new key certified by the CA for the TaskRun in ctx.
+func NewSigner(ctx context.Context, secretPath string, cfg config.Config, logger *zap.SugaredLogger) (*Signer, error) {
 	if cfg.Signers.X509.FulcioEnabled {
-		return fulcioSigner(cfg.Signers.X509.FulcioAddr, logger)
-	} else if contents, err := ioutil.ReadFile(x509PrivateKeyPath); err == nil {
-		return x509Signer(contents, logger)
-	} else if contents, err := ioutil.ReadFile(cosignPrivateKeypath); err == nil {
-		return cosignSigner(secretPath, contents, logger)
+		return fulcioSigner(ctx, cfg.Signers.X509, logger)
+	}
+	if cfg.Signers.X509.CAEnabled {
+		return caSigner(ctx, secretPath, cfg.Signers.X509, logger)
 	}
-	return nil, errors.New("no valid private key found, looked for: [x509.pem, cosign.key]")
+
+	s, err := loadKey(secretPath, cfg.Signers.X509.ActiveKey, cfg.Signers.X509, logger)
+	if err != nil {
+		return nil, err
+	}
+	s.keyID = cfg.Signers.X509.ActiveKey
+
+	for keyID, retiredAt := range cfg.Signers.X509.RetiredKeys {
+		v, err := loadRetiredKey(secretPath, keyID, cfg.Signers.X509, logger)
+		if err != nil {
+			return nil, errors.Wrapf(err, "loading retired key %s", keyID)
+		}
+		s.retired = append(s.retired, signing.RetiredKey{
+			KeyID:      keyID,
+			Verifier:   v,
+			ValidUntil: retiredAt.Add(cfg.Signers.X509.GracePeriod),
+		})
+	}
+	sort.Slice(s.retired, func(i, j int) bool {
+		return s.retired[i].KeyID < s.retired[j].KeyID
+	})
+	return s, nil
 }
 
-func fulcioSigner(addr string, logger *zap.SugaredLogger) (*Signer, error) {
-	ctx := context.Background()
+// keyFile returns the path to a file of the key with keyID in secretPath. The files of
+// named keys are the ones of the unnamed key, prefixed with the key ID: <keyID>.x509.pem.
+func keyFile(secretPath, keyID, name string) string {
+	if keyID != "" {
+		name = keyID + "." + name
+	}
+	return filepath.Join(secretPath, name)
+}
 
-	if !providers.Enabled(ctx) {
-		return nil, fmt.Errorf("no auth provider for fulcio is enabled")
+// loadKey loads the private key with keyID, along with its certificate.
+func loadKey(secretPath, keyID string, cfg config.X509Signer, logger *zap.SugaredLogger) (*Signer, error) {
+	x509PrivateKeyPath := keyFile(secretPath, keyID, "x509.pem")
+	x509PasswordPath := keyFile(secretPath, keyID, "x509.password")
+	cosignPrivateKeypath := keyFile(secretPath, keyID, "cosign.key")
+
+	var s *Signer
+	var err error
+	if contents, rerr := ioutil.ReadFile(x509PrivateKeyPath); rerr == nil {
+		// The password is optional, and only needed for encrypted keys.
+		password, perr := ioutil.ReadFile(x509PasswordPath)
+		if perr != nil && !os.IsNotExist(perr) {
+			return nil, errors.Wrapf(perr, "reading %s file", filepath.Base(x509PasswordPath))
+		}
+		s, err = x509Signer(contents, password, cfg, logger)
+	} else if contents, rerr := ioutil.ReadFile(cosignPrivateKeypath); rerr == nil {
+		s, err = cosignSigner(keyFile(secretPath, keyID, "cosign.password"), contents, logger)
+	} else {
+		err := fmt.Errorf("no valid private key found, looked for: [%s, %s]", filepath.Base(x509PrivateKeyPath), filepath.Base(cosignPrivateKeypath))
+		if keyID == "" {
+			// Nothing is configured, unlike a named key that is missing.
+			return nil, &signing.NoKeyError{Reason: err.Error()}
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	tok, err := providers.Provide(ctx, defaultOIDCClientID)
+	// Attach the certificate issued for the key, if one was mounted next to it.
+	certPath := keyFile(secretPath, keyID, "x509.crt")
+	s.cert, s.chain, err = signing.LoadCertChain(certPath, keyFile(secretPath, keyID, "x509-chain.pem"), s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading %s", filepath.Base(certPath))
+	}
+	return s, nil
+}
+
+// loadRetiredKey loads a verifier for the retired key with keyID. Since it no longer
+// signs, its private key can be replaced by the PEM encoded public key, in <keyID>.pub.
+func loadRetiredKey(secretPath, keyID string, cfg config.X509Signer, logger *zap.SugaredLogger) (signature.Verifier, error) {
+	contents, err := ioutil.ReadFile(keyFile(secretPath, keyID, "pub"))
+	if os.IsNotExist(err) {
+		return loadKey(secretPath, keyID, cfg, logger)
+	} else if err != nil {
+		return nil, err
+	}
+	pub, err := cryptoutils.UnmarshalPEMToPublicKey(contents)
 	if err != nil {
-		return nil, errors.Wrap(err, "getting provider")
+		return nil, errors.Wrapf(err, "parsing %s.pub", keyID)
+	}
+	return signing.LoadVerifier(pub, cfg.RSAScheme)
+}
+
+func fulcioSigner(ctx context.Context, cfg config.X509Signer, logger *zap.SugaredLogger) (*Signer, error) {
+	tok, ok := identityToken(ctx)
+	if !ok {
+		if !providers.Enabled(ctx) {
+			return nil, fmt.Errorf("no auth provider for fulcio is enabled")
+		}
+		var err error
+		tok, err = providers.Provide(ctx, defaultOIDCClientID)
+		if err != nil {
+			return nil, errors.Wrap(err, "getting provider")
+		}
 	}
 	logger.Info("Signing with fulcio ...")
 
-	fClient, err := fulcio.NewClient(addr)
+	fClient, err := newFulcioClient(cfg.FulcioAddr, cfg.FulcioClient)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating Fulcio client")
 	}
-	k, err := fulcio.NewSigner(ctx, tok, defaultOIDCIssuer, defaultOIDCClientID, "", fClient)
+	s, err := requestCert(fClient, tok)
 	if err != nil {
-		return nil, errors.Wrap(err, "new signer")
-	}
-	return &Signer{
-		SignerVerifier: k.ECDSASignerVerifier,
-		cert:           string(k.Cert),
-		chain:          string(k.Chain),
-		logger:         logger,
-	}, nil
+		return nil, errors.Wrap(err, "retrieving cert")
+	}
+	s.logger = logger
+	return s, nil
 }
 
-func x509Signer(privateKey []byte, logger *zap.SugaredLogger) (*Signer, error) {
+func x509Signer(privateKey, password []byte, cfg config.X509Signer, logger *zap.SugaredLogger) (*Signer, error) {
 	logger.Info("Found x509 key...")
 
-	p, _ := pem.Decode(privateKey)
-	if p.Type != "PRIVATE KEY" {
-		return nil, fmt.Errorf("expected private key, found object of type %s", p.Type)
-	}
-	pk, err := cx509.ParsePKCS8PrivateKey(p.Bytes)
+	pk, err := parsePrivateKey(privateKey, password)
 	if err != nil {
-		return nil, err
+		return nil, errors.Wrap(err, "parsing x509.pem")
 	}
-	signer, err := signature.LoadECDSASignerVerifier(pk.(*ecdsa.PrivateKey), crypto.SHA256)
+	signer, err := signing.LoadSignerVerifier(pk, cfg.RSAScheme)
 	if err != nil {
 		return nil, err
 	}
 	return &Signer{SignerVerifier: signer, logger: logger}, nil
 }
 
-func cosignSigner(secretPath string, privateKey []byte, logger *zap.SugaredLogger) (*Signer, error) {
+// parsePrivateKey parses a PEM encoded PKCS#8, SEC1 (EC) or PKCS#1 (RSA) private key.
+// Encrypted PKCS#8 keys are decrypted with password.
+func parsePrivateKey(contents, password []byte) (crypto.PrivateKey, error) {
+	p, _ := pem.Decode(contents)
+	if p == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	if _, ok := p.Headers["Proc-Type"]; ok {
+		return nil, errors.New("legacy PEM encryption is not supported, convert the key to an encrypted PKCS#8 key with `openssl pkcs8 -topk8 -v2 aes-256-cbc`")
+	}
+	switch p.Type {
+	case "ENCRYPTED PRIVATE KEY":
+		if password == nil {
+			return nil, errors.New("the key is encrypted, but no x509.password was found")
+		}
+		der, err := decryptPKCS8(p.Bytes, password)
+		if err != nil {
+			return nil, err
+		}
+		return cx509.ParsePKCS8PrivateKey(der)
+	case "PRIVATE KEY":
+		return cx509.ParsePKCS8PrivateKey(p.Bytes)
+	case "EC PRIVATE KEY":
+		return cx509.ParseECPrivateKey(p.Bytes)
+	case "RSA PRIVATE KEY":
+		return cx509.ParsePKCS1PrivateKey(p.Bytes)
+	}
+	return nil, fmt.Errorf("expected private key, found object of type %s", p.Type)
+}
+
+func cosignSigner(passwordPath string, privateKey []byte, logger *zap.SugaredLogger) (*Signer, error) {
 	logger.Info("Found cosign key...")
-	cosignPasswordPath := filepath.Join(secretPath, "cosign.password")
-	password, err := ioutil.ReadFile(cosignPasswordPath)
+	password, err := ioutil.ReadFile(passwordPath)
 	if err != nil {
-		return nil, errors.Wrap(err, "reading cosign.password file")
+		return nil, errors.Wrapf(err, "reading %s file", filepath.Base(passwordPath))
 	}
 	signer, err := cosign.LoadPrivateKey(privateKey, password)
 	if err != nil {
@@ -131,7 +240,15 @@ func (s *Signer) Cert() string {
 	return s.cert
 }
 
-// there is no cert or chain, return nothing
 func (s *Signer) Chain() string {
 	return s.chain
 }
+
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+// RetiredKeys returns the keys that were rotated out.
+func (s *Signer) RetiredKeys() []signing.RetiredKey {
+	return s.retired
+}
@@ -15,13 +15,22 @@ package chains
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"errors"
-	"fmt"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/sigstore/pkg/signature"
 	"github.com/tektoncd/chains/pkg/chains/signing"
 	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/chains/timestamp"
+	"github.com/tektoncd/chains/pkg/chains/timestamp/timestamptest"
 	"github.com/tektoncd/chains/pkg/config"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	versioned "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
@@ -171,7 +180,7 @@ func TestTaskRunSigner_SignTaskRun(t *testing.T) {
 					TaskRuns: config.Artifact{
 						Format:         "tekton",
 						StorageBackend: sets.NewString(tt.configuredBackend),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 				},
 			})
@@ -237,7 +246,7 @@ func TestTaskRunSigner_Transparency(t *testing.T) {
 				TaskRuns: config.Artifact{
 					Format:         format,
 					StorageBackend: sets.NewString("mock"),
-					Signer:         "x509",
+					Signers:        []string{"x509"},
 				},
 			},
 			Transparency: config.TransparencyConfig{
@@ -319,6 +328,370 @@ func TestTaskRunSigner_Transparency(t *testing.T) {
 	}
 }
 
+func TestTaskRunSigner_ArtifactTransparency(t *testing.T) {
+	rekor := &mockRekor{}
+	backends := []*mockBackend{{backendType: "mock"}}
+	cleanup := setupMocks(backends, rekor)
+	defer cleanup()
+
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+
+	// Transparency is disabled globally, but enabled for TaskRuns with a private log.
+	cfg := &config.Config{
+		Artifacts: config.ArtifactConfigs{
+			TaskRuns: config.Artifact{
+				Format:         "in-toto",
+				StorageBackend: sets.NewString("mock"),
+				Signers:        []string{"x509"},
+				Transparency: &config.TransparencyConfig{
+					Enabled: true,
+					URL:     "https://rekor.internal",
+				},
+			},
+		},
+		Transparency: config.TransparencyConfig{
+			URL: "https://rekor.sigstore.dev",
+		},
+	}
+	ctx = config.ToContext(ctx, cfg)
+
+	ts := &TaskRunSigner{
+		Pipelineclientset: ps,
+		SecretPath:        "./signing/x509/testdata/",
+	}
+
+	tr := &v1beta1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "foo",
+		},
+	}
+	if _, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Create(ctx, tr, metav1.CreateOptions{}); err != nil {
+		t.Errorf("error creating fake taskrun: %v", err)
+	}
+	if err := ts.SignTaskRun(ctx, tr); err != nil {
+		t.Errorf("TaskRunSigner.SignTaskRun() error = %v", err)
+	}
+
+	if len(rekor.entries) != 1 {
+		t.Fatal("expected transparency log entry!")
+	}
+	signed, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Get(ctx, tr.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Errorf("error fetching fake taskrun: %v", err)
+	}
+	want := "https://rekor.internal/api/v1/log/entries?logIndex=0"
+	if got := signed.Annotations[ChainsTransparencyAnnotation]; got != want {
+		t.Errorf("got transparency annotation %q, expected %q", got, want)
+	}
+}
+
+func TestTaskRunSigner_MultipleSigners(t *testing.T) {
+	tests := []struct {
+		format   string
+		wantKeys []string
+		wantSigs int
+	}{
+		{
+			// One envelope, with a signature from each signer.
+			format:   "in-toto",
+			wantKeys: []string{"taskrun-uid"},
+			wantSigs: 2,
+		},
+		{
+			// A signature from each signer, under its own key.
+			format:   "tekton",
+			wantKeys: []string{"taskrun-uid", "taskrun-uid-kms"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			rekor := &mockRekor{}
+			backend := &mockBackend{backendType: "mock"}
+			cleanup := setupMocks([]*mockBackend{backend}, rekor)
+			defer cleanup()
+			defer setupCosigner(t)()
+
+			ctx, _ := rtesting.SetupFakeContext(t)
+			ps := fakepipelineclient.Get(ctx)
+			ctx = config.ToContext(ctx, &config.Config{
+				Artifacts: config.ArtifactConfigs{
+					TaskRuns: config.Artifact{
+						Format:         tt.format,
+						StorageBackend: sets.NewString("mock"),
+						Signers:        []string{"x509", "kms"},
+					},
+				},
+				Transparency: config.TransparencyConfig{
+					Enabled: true,
+					URL:     "https://rekor.internal",
+				},
+			})
+
+			tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: "uid"}}
+			if _, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Create(ctx, tr, metav1.CreateOptions{}); err != nil {
+				t.Fatalf("error creating fake taskrun: %v", err)
+			}
+			ts := &TaskRunSigner{
+				Pipelineclientset: ps,
+				SecretPath:        "./signing/x509/testdata/",
+			}
+			if err := ts.SignTaskRun(ctx, tr); err != nil {
+				t.Fatalf("TaskRunSigner.SignTaskRun() error = %v", err)
+			}
+
+			if diff := cmp.Diff(tt.wantKeys, backend.storedKeys); diff != "" {
+				t.Errorf("stored keys (-want +got): %s", diff)
+			}
+			if tt.wantSigs > 0 {
+				env, ok := asEnvelope(backend.storedSignature)
+				if !ok {
+					t.Fatalf("stored signature is not an envelope: %s", backend.storedSignature)
+				}
+				if len(env.Signatures) != tt.wantSigs {
+					t.Errorf("got %d signatures in the envelope, want %d", len(env.Signatures), tt.wantSigs)
+				}
+			}
+			// Every signer gets its own transparency log entry, the annotation records the primary one.
+			if len(rekor.entries) != 2 {
+				t.Errorf("got %d transparency log entries, want 2", len(rekor.entries))
+			}
+			signed, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Get(ctx, tr.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error fetching fake taskrun: %v", err)
+			}
+			want := "https://rekor.internal/api/v1/log/entries?logIndex=0"
+			if got := signed.Annotations[ChainsTransparencyAnnotation]; got != want {
+				t.Errorf("got transparency annotation %q, expected %q", got, want)
+			}
+		})
+	}
+}
+
+func TestTaskRunSigner_NamedKeys(t *testing.T) {
+	// The old and new keys co-sign during a rotation.
+	dir := t.TempDir()
+	writeKey(t, filepath.Join(dir, "old.x509.pem"))
+	writeKey(t, filepath.Join(dir, "new.x509.pem"))
+
+	backend := &mockBackend{backendType: "mock"}
+	cleanup := setupMocks([]*mockBackend{backend}, &mockRekor{})
+	defer cleanup()
+
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	cfg, err := config.NewConfigFromMap(map[string]string{
+		"artifacts.taskrun.format":  "tekton",
+		"artifacts.taskrun.storage": "tekton",
+		"artifacts.taskrun.signer":  "x509:new,x509:old",
+		"artifacts.oci.storage":     "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Artifacts.TaskRuns.StorageBackend = sets.NewString("mock")
+	ctx = config.ToContext(ctx, cfg)
+
+	tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: "uid"}}
+	if _, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Create(ctx, tr, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error creating fake taskrun: %v", err)
+	}
+	ts := &TaskRunSigner{Pipelineclientset: ps, SecretPath: dir}
+	if err := ts.SignTaskRun(ctx, tr); err != nil {
+		t.Fatalf("TaskRunSigner.SignTaskRun() error = %v", err)
+	}
+	if diff := cmp.Diff([]string{"taskrun-uid", "taskrun-uid-x509-old"}, backend.storedKeys); diff != "" {
+		t.Errorf("stored keys (-want +got): %s", diff)
+	}
+	if diff := cmp.Diff([]string{"new", "old"}, backend.storedKeyIDs); diff != "" {
+		t.Errorf("stored key IDs (-want +got): %s", diff)
+	}
+}
+
+func TestTaskRunSigner_MissingCosigner(t *testing.T) {
+	backend := &mockBackend{backendType: "mock"}
+	cleanup := setupMocks([]*mockBackend{backend}, &mockRekor{})
+	defer cleanup()
+
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	ctx = config.ToContext(ctx, &config.Config{
+		Artifacts: config.ArtifactConfigs{
+			TaskRuns: config.Artifact{
+				Format:         "tekton",
+				StorageBackend: sets.NewString("mock"),
+				Signers:        []string{"x509", "kms"},
+			},
+		},
+	})
+	tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+	if _, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Create(ctx, tr, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error creating fake taskrun: %v", err)
+	}
+	ts := &TaskRunSigner{
+		Pipelineclientset: ps,
+		SecretPath:        "./signing/x509/testdata/",
+	}
+	if err := ts.SignTaskRun(ctx, tr); err == nil {
+		t.Fatal("TaskRunSigner.SignTaskRun() expected an error without the kms co-signer")
+	}
+	// Nothing is signed when one of the signers is not available, and the TaskRun is retried.
+	if backend.storedPayload != nil {
+		t.Error("expected no payload to be stored without the kms co-signer")
+	}
+	tr, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Get(ctx, tr.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Reconciled(tr) {
+		t.Error("expected the TaskRun not to be marked signed")
+	}
+	if _, ok := tr.Annotations[RetryAnnotation]; !ok {
+		t.Error("expected the TaskRun to be retried")
+	}
+}
+
+func TestTaskRunSigner_Timestamp(t *testing.T) {
+	tsa := timestamptest.NewServer(t)
+	backend := &mockBackend{backendType: "mock"}
+	cleanup := setupMocks([]*mockBackend{backend}, &mockRekor{})
+	defer cleanup()
+
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	ctx = config.ToContext(ctx, &config.Config{
+		Artifacts: config.ArtifactConfigs{
+			TaskRuns: config.Artifact{
+				Format:         "tekton",
+				StorageBackend: sets.NewString("mock"),
+				Signers:        []string{"x509"},
+			},
+		},
+		Timestamp: config.TimestampConfig{URL: tsa.URL},
+	})
+	tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+	if _, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Create(ctx, tr, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error creating fake taskrun: %v", err)
+	}
+	ts := &TaskRunSigner{
+		Pipelineclientset: ps,
+		SecretPath:        "./signing/x509/testdata/",
+	}
+	if err := ts.SignTaskRun(ctx, tr); err != nil {
+		t.Fatalf("TaskRunSigner.SignTaskRun() error = %v", err)
+	}
+	if _, err := timestamp.Verify(backend.storedTimestamp, []byte(backend.storedSignature), tsa.Roots()); err != nil {
+		t.Errorf("expected a timestamp of the stored signature: %v", err)
+	}
+
+	// Signatures aren't stored without a timestamp, the TaskRun is retried instead.
+	backend.storedPayload = nil
+	tsa.Close()
+	if err := ts.SignTaskRun(ctx, tr); err == nil {
+		t.Error("expected an error when the timestamp authority is unavailable")
+	}
+	if backend.storedPayload != nil {
+		t.Error("expected no payload to be stored without a timestamp")
+	}
+}
+
+// setupCosigner adds a kms signer with a generated key to the configured signers.
+func setupCosigner(t *testing.T) func() {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv, err := signing.LoadSignerVerifier(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldSigners := getSigners
+	getSigners = func(ctx context.Context, sp string, cfg config.Config, l *zap.SugaredLogger) map[string]signing.Signer {
+		all := oldSigners(ctx, sp, cfg, l)
+		all[signing.TypeKMS] = &mockSigner{SignerVerifier: sv, typ: signing.TypeKMS}
+		return all
+	}
+	return func() {
+		getSigners = oldSigners
+	}
+}
+
+func TestTaskRunSigner_RetriesKeyErrors(t *testing.T) {
+	// The key ID is needed to store signatures, and to wrap them in an envelope.
+	for _, format := range []string{"tekton", "in-toto"} {
+		t.Run(format, func(t *testing.T) {
+			backend := &mockBackend{backendType: "mock"}
+			cleanup := setupMocks([]*mockBackend{backend}, &mockRekor{})
+			defer cleanup()
+			oldSigners := getSigners
+			getSigners = func(ctx context.Context, sp string, cfg config.Config, l *zap.SugaredLogger) map[string]signing.Signer {
+				all := oldSigners(ctx, sp, cfg, l)
+				all[signing.TypeX509] = &brokenKeySigner{all[signing.TypeX509]}
+				return all
+			}
+			defer func() { getSigners = oldSigners }()
+
+			ctx, _ := rtesting.SetupFakeContext(t)
+			ps := fakepipelineclient.Get(ctx)
+			ctx = config.ToContext(ctx, &config.Config{
+				Artifacts: config.ArtifactConfigs{
+					TaskRuns: config.Artifact{
+						Format:         format,
+						StorageBackend: sets.NewString("mock"),
+						Signers:        []string{"x509"},
+					},
+				},
+			})
+			tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+			if _, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Create(ctx, tr, metav1.CreateOptions{}); err != nil {
+				t.Fatalf("error creating fake taskrun: %v", err)
+			}
+			ts := &TaskRunSigner{Pipelineclientset: ps, SecretPath: "./signing/x509/testdata/"}
+			if err := ts.SignTaskRun(ctx, tr); err == nil {
+				t.Fatal("TaskRunSigner.SignTaskRun() expected an error without the public key")
+			}
+			tr, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Get(ctx, tr.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := tr.Annotations[RetryAnnotation]; !ok {
+				t.Error("expected the TaskRun to be retried, and eventually marked failed")
+			}
+		})
+	}
+}
+
+// brokenKeySigner signs, but can't return its public key.
+type brokenKeySigner struct {
+	signing.Signer
+}
+
+func (s *brokenKeySigner) PublicKey(...signature.PublicKeyOption) (crypto.PublicKey, error) {
+	return nil, errors.New("public key unavailable")
+}
+
+type mockSigner struct {
+	signature.SignerVerifier
+	typ string
+}
+
+func (s *mockSigner) Type() string {
+	return s.typ
+}
+
+func (s *mockSigner) Cert() string {
+	return ""
+}
+
+func (s *mockSigner) Chain() string {
+	return ""
+}
+
+func (s *mockSigner) KeyID() string {
+	return ""
+}
+
 func setupMocks(backends []*mockBackend, rekor *mockRekor) func() {
 	oldGet := getBackends
 	getBackends = func(ps versioned.Interface, _ kubernetes.Interface, logger *zap.SugaredLogger, _ *v1beta1.TaskRun, _ config.Config) (map[string]storage.Backend, error) {
@@ -330,7 +703,7 @@ func setupMocks(backends []*mockBackend, rekor *mockRekor) func() {
 	}
 
 	oldRekor := getRekor
-	getRekor = func(_ string, _ *zap.SugaredLogger) (rekorClient, error) {
+	getRekor = func(_ config.TransparencyConfig, _ *zap.SugaredLogger) (rekorClient, error) {
 		return rekor, nil
 	}
 
@@ -342,20 +715,48 @@ func setupMocks(backends []*mockBackend, rekor *mockRekor) func() {
 
 type mockRekor struct {
 	entries [][]byte
+	// integratedTimes are when the entries were uploaded.
+	integratedTimes []int64
+	// unavailable makes CheckTlog fail.
+	unavailable bool
+}
+
+func (r *mockRekor) CheckTlog(ctx context.Context) error {
+	if r.unavailable {
+		return errors.New("transparency log unavailable")
+	}
+	return nil
+}
+
+func (r *mockRekor) VerifyTlog(ctx context.Context, pkoc, signature, rawPayload []byte, payloadFormat string) (*models.LogEntryAnon, error) {
+	for i, e := range r.entries {
+		if string(e) == string(signature) {
+			index := int64(i)
+			return &models.LogEntryAnon{LogIndex: &index, IntegratedTime: &r.integratedTimes[i]}, nil
+		}
+	}
+	return nil, errors.New("signature not found in transparency log")
 }
 
 func (r *mockRekor) UploadTlog(ctx context.Context, signer signing.Signer, signature, rawPayload []byte, cert, payloadFormat string) (*models.LogEntryAnon, error) {
 	r.entries = append(r.entries, signature)
+	r.integratedTimes = append(r.integratedTimes, time.Now().Unix())
 	index := int64(len(r.entries) - 1)
 	return &models.LogEntryAnon{
-		LogIndex: &index,
+		LogIndex:       &index,
+		IntegratedTime: &r.integratedTimes[index],
 	}, nil
 }
 
 type mockBackend struct {
-	storedPayload []byte
-	shouldErr     bool
-	backendType   string
+	storedPayload   []byte
+	storedSignature string
+	storedCert      string
+	storedKeys      []string
+	storedKeyIDs    []string
+	storedTimestamp []byte
+	shouldErr       bool
+	backendType     string
 }
 
 // StorePayload implements the Payloader interface.
@@ -364,6 +765,11 @@ func (b *mockBackend) StorePayload(signed []byte, signature string, opts config.
 		return errors.New("mock error storing")
 	}
 	b.storedPayload = signed
+	b.storedSignature = signature
+	b.storedCert = opts.Cert + opts.Chain
+	b.storedKeys = append(b.storedKeys, opts.Key)
+	b.storedKeyIDs = append(b.storedKeyIDs, opts.KeyID)
+	b.storedTimestamp = opts.Timestamp
 	return nil
 }
 
@@ -372,9 +778,29 @@ func (b *mockBackend) Type() string {
 }
 
 func (b *mockBackend) RetrievePayloads(opts config.StorageOpts) (map[string]string, error) {
-	return nil, fmt.Errorf("not implemented")
+	if b.storedPayload == nil {
+		return map[string]string{}, nil
+	}
+	return map[string]string{opts.Key: string(b.storedPayload)}, nil
 }
 
 func (b *mockBackend) RetrieveSignatures(opts config.StorageOpts) (map[string][]string, error) {
-	return nil, fmt.Errorf("not implemented")
+	if b.storedSignature == "" {
+		return map[string][]string{}, nil
+	}
+	return map[string][]string{opts.Key: {b.storedSignature}}, nil
+}
+
+func (b *mockBackend) RetrieveCerts(opts config.StorageOpts) (map[string]string, error) {
+	if b.storedCert == "" {
+		return map[string]string{}, nil
+	}
+	return map[string]string{opts.Key: b.storedCert}, nil
+}
+
+func (b *mockBackend) RetrieveTimestamps(opts config.StorageOpts) (map[string][]string, error) {
+	if b.storedTimestamp == nil {
+		return map[string][]string{}, nil
+	}
+	return map[string][]string{opts.Key: {string(b.storedTimestamp)}}, nil
 }
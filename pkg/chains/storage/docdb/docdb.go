@@ -43,6 +43,8 @@ type SignedDocument struct {
 	Signature string
 	Cert      string
 	Chain     string
+	KeyID     string
+	Timestamp []byte
 	Object    interface{}
 	Name      string
 }
@@ -80,6 +82,8 @@ func (b *Backend) StorePayload(signed []byte, signature string, opts config.Stor
 		Name:      opts.Key,
 		Cert:      opts.Cert,
 		Chain:     opts.Chain,
+		KeyID:     opts.KeyID,
+		Timestamp: opts.Timestamp,
 	}
 
 	if err := b.coll.Put(context.Background(), &entry); err != nil {
@@ -126,6 +130,36 @@ func (b *Backend) RetrievePayloads(opts config.StorageOpts) (map[string]string,
 	return m, nil
 }
 
+func (b *Backend) RetrieveCerts(opts config.StorageOpts) (map[string]string, error) {
+	documents, err := b.retrieveDocuments(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]string)
+	for _, d := range documents {
+		if d.Cert != "" {
+			m[d.Name] = d.Cert + d.Chain
+		}
+	}
+	return m, nil
+}
+
+func (b *Backend) RetrieveTimestamps(opts config.StorageOpts) (map[string][]string, error) {
+	documents, err := b.retrieveDocuments(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string][]string)
+	for _, d := range documents {
+		if len(d.Timestamp) > 0 {
+			m[d.Name] = []string{string(d.Timestamp)}
+		}
+	}
+	return m, nil
+}
+
 func (b *Backend) retrieveDocuments(opts config.StorageOpts) ([]SignedDocument, error) {
 	d := SignedDocument{Name: opts.Key}
 	if err := b.coll.Get(context.Background(), &d); err != nil {
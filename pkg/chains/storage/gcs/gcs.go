@@ -15,6 +15,7 @@ package gcs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -33,6 +34,8 @@ const (
 	PayloadNameFormat   = "taskrun-%s-%s/%s.payload"
 	CertNameFormat      = "taskrun-%s-%s/%s.cert"
 	ChainNameFormat     = "taskrun-%s-%s/%s.chain"
+	KeyIDNameFormat     = "taskrun-%s-%s/%s.keyid"
+	TimestampNameFormat = "taskrun-%s-%s/%s.timestamp"
 )
 
 // Backend is a storage backend that stores signed payloads in the TaskRun metadata as an annotation.
@@ -88,6 +91,28 @@ func (b *Backend) StorePayload(rawPayload []byte, signature string, opts config.
 		return err
 	}
 
+	if opts.KeyID != "" {
+		keyIDObj := b.writer.GetWriter(b.keyIDName(opts))
+		defer keyIDObj.Close()
+		if _, err := keyIDObj.Write([]byte(opts.KeyID)); err != nil {
+			return err
+		}
+		if err := keyIDObj.Close(); err != nil {
+			return err
+		}
+	}
+
+	if opts.Timestamp != nil {
+		timestampObj := b.writer.GetWriter(b.timestampName(opts))
+		defer timestampObj.Close()
+		if _, err := timestampObj.Write(opts.Timestamp); err != nil {
+			return err
+		}
+		if err := timestampObj.Close(); err != nil {
+			return err
+		}
+	}
+
 	if opts.Cert == "" {
 		return nil
 	}
@@ -147,26 +172,57 @@ func (r *reader) GetReader(object string) (io.ReadCloser, error) {
 }
 
 func (b *Backend) RetrieveSignatures(opts config.StorageOpts) (map[string][]string, error) {
-	object := b.sigName(opts)
-	signature, err := b.retrieveObject(object)
+	signature, err := b.retrieveObject(b.sigName(opts))
 	if err != nil {
 		return nil, err
 	}
 
 	m := make(map[string][]string)
-	m[object] = []string{signature}
+	m[opts.Key] = []string{signature}
 	return m, nil
 }
 
 func (b *Backend) RetrievePayloads(opts config.StorageOpts) (map[string]string, error) {
-	object := b.payloadName(opts)
-	m := make(map[string]string)
-	payload, err := b.retrieveObject(object)
+	payload, err := b.retrieveObject(b.payloadName(opts))
 	if err != nil {
 		return nil, err
 	}
 
-	m[object] = payload
+	m := make(map[string]string)
+	m[opts.Key] = payload
+	return m, nil
+}
+
+func (b *Backend) RetrieveCerts(opts config.StorageOpts) (map[string]string, error) {
+	m := make(map[string]string)
+	cert, err := b.retrieveObject(b.certName(opts))
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		// Certs are only stored for signers that have them.
+		return m, nil
+	} else if err != nil {
+		return nil, err
+	}
+	chain, err := b.retrieveObject(b.chainName(opts))
+	if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, err
+	}
+
+	m[opts.Key] = cert + chain
+	return m, nil
+}
+
+// RetrieveTimestamps retrieves the timestamp token stored for the signature, if any.
+func (b *Backend) RetrieveTimestamps(opts config.StorageOpts) (map[string][]string, error) {
+	m := make(map[string][]string)
+	token, err := b.retrieveObject(b.timestampName(opts))
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		// Timestamps are only stored when a timestamp authority is configured.
+		return m, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	m[opts.Key] = []string{token}
 	return m, nil
 }
 
@@ -199,3 +255,11 @@ func (b *Backend) certName(opts config.StorageOpts) string {
 func (b *Backend) chainName(opts config.StorageOpts) string {
 	return fmt.Sprintf(ChainNameFormat, b.tr.Namespace, b.tr.Name, opts.Key)
 }
+
+func (b *Backend) keyIDName(opts config.StorageOpts) string {
+	return fmt.Sprintf(KeyIDNameFormat, b.tr.Namespace, b.tr.Name, opts.Key)
+}
+
+func (b *Backend) timestampName(opts config.StorageOpts) string {
+	return fmt.Sprintf(TimestampNameFormat, b.tr.Namespace, b.tr.Name, opts.Key)
+}
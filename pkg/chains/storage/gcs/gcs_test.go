@@ -18,6 +18,7 @@ import (
 	"io"
 	"testing"
 
+	"cloud.google.com/go/storage"
 	"github.com/tektoncd/chains/pkg/chains/formats"
 
 	"github.com/tektoncd/chains/pkg/config"
@@ -52,7 +53,7 @@ func TestBackend_StorePayload(t *testing.T) {
 				},
 				signed:    []byte("signed"),
 				signature: "signature",
-				opts:      config.StorageOpts{Key: "foo.uuid", PayloadFormat: formats.PayloadTypeInTotoIte6},
+				opts:      config.StorageOpts{Key: "foo.uuid", KeyID: "2022-06", Timestamp: []byte("timestamp"), PayloadFormat: formats.PayloadTypeInTotoIte6},
 			},
 		},
 		{
@@ -87,22 +88,36 @@ func TestBackend_StorePayload(t *testing.T) {
 				t.Errorf("Backend.StorePayload() error = %v, wantErr %v", err, tt.wantErr)
 			}
 
-			objectSig := b.sigName(tt.args.opts)
-			objectPayload := b.payloadName(tt.args.opts)
+			key := tt.args.opts.Key
 			got, err := b.RetrieveSignatures(tt.args.opts)
 			if err != nil {
 				t.Fatal(err)
 			}
-			if got[objectSig][0] != tt.args.signature {
-				t.Errorf("wrong signature, expected %q, got %q", tt.args.signature, got[objectSig][0])
+			if got[key][0] != tt.args.signature {
+				t.Errorf("wrong signature, expected %q, got %q", tt.args.signature, got[key][0])
 			}
 			var got_payload map[string]string
 			got_payload, err = b.RetrievePayloads(tt.args.opts)
 			if err != nil {
 				t.Fatal(err)
 			}
-			if got_payload[objectPayload] != string(tt.args.signed) {
-				t.Errorf("wrong signature, expected %s, got %s", tt.args.signed, got_payload[objectPayload])
+			if got_payload[key] != string(tt.args.signed) {
+				t.Errorf("wrong signature, expected %s, got %s", tt.args.signed, got_payload[key])
+			}
+			keyID, ok := mockGcsWrite.objects[b.keyIDName(tt.args.opts)]
+			if (tt.args.opts.KeyID != "") != ok {
+				t.Errorf("key ID stored = %t, want %t", ok, tt.args.opts.KeyID != "")
+			} else if ok && keyID.String() != tt.args.opts.KeyID {
+				t.Errorf("wrong key ID, expected %q, got %q", tt.args.opts.KeyID, keyID.String())
+			}
+			timestamps, err := b.RetrieveTimestamps(tt.args.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tokens, ok := timestamps[key]; (tt.args.opts.Timestamp != nil) != ok {
+				t.Errorf("timestamp stored = %t, want %t", ok, tt.args.opts.Timestamp != nil)
+			} else if ok && tokens[0] != string(tt.args.opts.Timestamp) {
+				t.Errorf("wrong timestamp, expected %q, got %q", tt.args.opts.Timestamp, tokens[0])
 			}
 		})
 	}
@@ -132,7 +147,10 @@ type mockGcsReader struct {
 }
 
 func (m *mockGcsReader) GetReader(object string) (io.ReadCloser, error) {
-	buf := m.objects[object]
+	buf, ok := m.objects[object]
+	if !ok {
+		return nil, storage.ErrObjectNotExist
+	}
 	return &ReaderCloser{buf}, nil
 }
 
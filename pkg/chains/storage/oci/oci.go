@@ -15,6 +15,7 @@ package oci
 
 import (
 	"context"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -34,6 +35,7 @@ import (
 	ociremote "github.com/sigstore/cosign/pkg/oci/remote"
 	"github.com/sigstore/cosign/pkg/oci/static"
 	"github.com/sigstore/cosign/pkg/types"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/tektoncd/chains/pkg/artifacts"
 	"github.com/tektoncd/chains/pkg/chains/formats/simple"
 	"github.com/tektoncd/chains/pkg/config"
@@ -44,6 +46,10 @@ import (
 
 const (
 	StorageBackendOCI = "oci"
+	// KeyIDAnnotation holds the ID of the key a signature or attestation was made with.
+	KeyIDAnnotation = "dev.tekton.chains/keyid"
+	// TimestampAnnotation holds the base64 encoded RFC 3161 timestamp token over a signature or attestation.
+	TimestampAnnotation = "dev.tekton.chains/timestamp"
 )
 
 type Backend struct {
@@ -118,6 +124,9 @@ func (b *Backend) uploadSignature(format simple.SimpleContainerImage, rawPayload
 	}
 
 	sigOpts := []static.Option{}
+	if ann := annotations(storageOpts); len(ann) > 0 {
+		sigOpts = append(sigOpts, static.WithAnnotations(ann))
+	}
 	if storageOpts.Cert != "" {
 		sigOpts = append(sigOpts, static.WithCertChain([]byte(storageOpts.Cert), []byte(storageOpts.Chain)))
 	}
@@ -174,6 +183,9 @@ func (b *Backend) uploadAttestation(attestation in_toto.Statement, signature str
 		}
 		// Create the new attestation for this entity.
 		attOpts := []static.Option{static.WithLayerMediaType(types.DssePayloadType)}
+		if ann := annotations(storageOpts); len(ann) > 0 {
+			attOpts = append(attOpts, static.WithAnnotations(ann))
+		}
 		if storageOpts.Cert != "" {
 			attOpts = append(attOpts, static.WithCertChain([]byte(storageOpts.Cert), []byte(storageOpts.Chain)))
 		}
@@ -206,18 +218,14 @@ func (b *Backend) RetrieveSignatures(opts config.StorageOpts) (map[string][]stri
 
 	m := make(map[string][]string)
 	for ref, img := range images {
-		sigImage, err := img.Signatures()
-		if err != nil {
-			return nil, err
-		}
-		sigs, err := sigImage.Get()
+		sigs, err := b.signatures(img, opts)
 		if err != nil {
 			return nil, err
 		}
 
 		signatures := []string{}
 		for _, s := range sigs {
-			if sig, err := s.Base64Signature(); err == nil {
+			if sig, err := rawSignature(s, opts); err == nil {
 				signatures = append(signatures, sig)
 			}
 		}
@@ -227,60 +235,151 @@ func (b *Backend) RetrieveSignatures(opts config.StorageOpts) (map[string][]stri
 }
 
 func (b *Backend) RetrievePayloads(opts config.StorageOpts) (map[string]string, error) {
-	var err error
 	images, err := b.RetrieveArtifact(opts)
 	if err != nil {
 		return nil, err
 	}
 
 	m := make(map[string]string)
-	var attImage oci.Signatures
 	for ref, img := range images {
-		if opts.PayloadFormat == formats.PayloadTypeSimpleSigning {
-			attImage, err = img.Signatures()
-		} else {
-			attImage, err = img.Attestations()
-		}
+		sigs, err := b.signatures(img, opts)
 		if err != nil {
 			return nil, err
 		}
-		atts, err := attImage.Get()
+
+		for _, s := range sigs {
+			payload, err := s.Payload()
+			if err != nil {
+				continue
+			}
+			if opts.PayloadFormat == formats.PayloadTypeSimpleSigning {
+				m[ref] = string(payload)
+				continue
+			}
+			envelope := dsse.Envelope{}
+			if err := json.Unmarshal(payload, &envelope); err != nil {
+				return nil, fmt.Errorf("cannot decode the envelope: %s", err)
+			}
+
+			decodedPayload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+			if err != nil {
+				return nil, fmt.Errorf("error decoding the payload: %s", err)
+			}
+			m[ref] = string(decodedPayload)
+		}
+	}
+	return m, nil
+}
+
+func (b *Backend) RetrieveCerts(opts config.StorageOpts) (map[string]string, error) {
+	images, err := b.RetrieveArtifact(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]string)
+	for ref, img := range images {
+		sigs, err := b.signatures(img, opts)
 		if err != nil {
 			return nil, err
 		}
 
-		for _, s := range atts {
-			if payload, err := s.Payload(); err == nil {
-				envelope := dsse.Envelope{}
-				if err := json.Unmarshal(payload, &envelope); err != nil {
-					return nil, fmt.Errorf("cannot decode the envelope: %s", err)
-				}
+		for _, s := range sigs {
+			cert, err := s.Cert()
+			if err != nil || cert == nil {
+				continue
+			}
+			chain, err := s.Chain()
+			if err != nil {
+				continue
+			}
+			pem, err := cryptoutils.MarshalCertificatesToPEM(append([]*x509.Certificate{cert}, chain...))
+			if err != nil {
+				return nil, err
+			}
+			m[ref] = string(pem)
+		}
+	}
+	return m, nil
+}
+
+func (b *Backend) RetrieveTimestamps(opts config.StorageOpts) (map[string][]string, error) {
+	images, err := b.RetrieveArtifact(opts)
+	if err != nil {
+		return nil, err
+	}
 
-				var decodedPayload []byte
-				decodedPayload, err = base64.StdEncoding.DecodeString(envelope.Payload)
-				if err != nil {
-					return nil, fmt.Errorf("error decoding the payload: %s", err)
-				}
+	m := make(map[string][]string)
+	for ref, img := range images {
+		sigs, err := b.signatures(img, opts)
+		if err != nil {
+			return nil, err
+		}
 
-				m[ref] = string(decodedPayload)
+		for _, s := range sigs {
+			ann, err := s.Annotations()
+			if err != nil || ann[TimestampAnnotation] == "" {
+				continue
 			}
-
+			token, err := base64.StdEncoding.DecodeString(ann[TimestampAnnotation])
+			if err != nil {
+				return nil, fmt.Errorf("error decoding the timestamp: %s", err)
+			}
+			m[ref] = append(m[ref], string(token))
 		}
 	}
 	return m, nil
 }
 
+// signatures returns the signatures or attestations attached to img, depending on the payload format.
+func (b *Backend) signatures(img oci.SignedImage, opts config.StorageOpts) ([]oci.Signature, error) {
+	var sigs oci.Signatures
+	var err error
+	if opts.PayloadFormat == formats.PayloadTypeSimpleSigning {
+		sigs, err = img.Signatures()
+	} else {
+		sigs, err = img.Attestations()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sigs.Get()
+}
+
+// rawSignature returns the signature the way the signer produced it. For attestations,
+// this is the DSSE envelope itself.
+func rawSignature(s oci.Signature, opts config.StorageOpts) (string, error) {
+	if opts.PayloadFormat != formats.PayloadTypeSimpleSigning {
+		envelope, err := s.Payload()
+		return string(envelope), err
+	}
+	b64sig, err := s.Base64Signature()
+	if err != nil {
+		return "", err
+	}
+	sig, err := base64.StdEncoding.DecodeString(b64sig)
+	return string(sig), err
+}
+
 func (b *Backend) RetrieveArtifact(opts config.StorageOpts) (map[string]oci.SignedImage, error) {
 	// Given the TaskRun, retrieve the OCI images.
 	images := artifacts.ExtractOCIImagesFromResults(b.tr, b.logger)
 	m := make(map[string]oci.SignedImage)
 
+	ociOpts := []ociremote.Option{ociremote.WithRemoteOptions(b.auth)}
+	if b.cfg.Storage.OCI.Repository != "" {
+		repo, err := name.NewRepository(b.cfg.Storage.OCI.Repository)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s is not a valid repository", b.cfg.Storage.OCI.Repository)
+		}
+		ociOpts = append(ociOpts, ociremote.WithTargetRepository(repo))
+	}
 	for _, image := range images {
 		ref, ok := image.(name.Digest)
 		if !ok {
 			return nil, errors.New("error parsing image")
 		}
-		img, err := ociremote.SignedImage(ref)
+		img, err := ociremote.SignedImage(ref, ociOpts...)
 		if err != nil {
 			return nil, err
 		}
@@ -289,3 +388,15 @@ func (b *Backend) RetrieveArtifact(opts config.StorageOpts) (map[string]oci.Sign
 
 	return m, nil
 }
+
+// annotations returns the annotations of a signature or attestation stored with storageOpts.
+func annotations(storageOpts config.StorageOpts) map[string]string {
+	ann := map[string]string{}
+	if storageOpts.KeyID != "" {
+		ann[KeyIDAnnotation] = storageOpts.KeyID
+	}
+	if storageOpts.Timestamp != nil {
+		ann[TimestampAnnotation] = base64.StdEncoding.EncodeToString(storageOpts.Timestamp)
+	}
+	return ann
+}
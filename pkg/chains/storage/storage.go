@@ -32,6 +32,10 @@ type Backend interface {
 	RetrievePayloads(opts config.StorageOpts) (map[string]string, error)
 	// RetrieveSignatures maps [ref]:[list of signatures] for a TaskRun
 	RetrieveSignatures(opts config.StorageOpts) (map[string][]string, error)
+	// RetrieveCerts maps [ref]:[PEM cert followed by its chain] for a TaskRun, if a cert was stored
+	RetrieveCerts(opts config.StorageOpts) (map[string]string, error)
+	// RetrieveTimestamps maps [ref]:[list of DER encoded RFC 3161 timestamp tokens] for a TaskRun, if any were stored
+	RetrieveTimestamps(opts config.StorageOpts) (map[string][]string, error)
 	// Type is the string representation of the backend
 	Type() string
 }
@@ -17,7 +17,6 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
-	"strings"
 
 	"github.com/tektoncd/chains/pkg/config"
 
@@ -35,6 +34,8 @@ const (
 	SignatureAnnotationFormat = "chains.tekton.dev/signature-%s"
 	CertAnnotationsFormat     = "chains.tekton.dev/cert-%s"
 	ChainAnnotationFormat     = "chains.tekton.dev/chain-%s"
+	KeyIDAnnotationFormat     = "chains.tekton.dev/keyid-%s"
+	TimestampAnnotationFormat = "chains.tekton.dev/timestamp-%s"
 )
 
 // Backend is a storage backend that stores signed payloads in the TaskRun metadata as an annotation.
@@ -58,14 +59,22 @@ func NewStorageBackend(ps versioned.Interface, logger *zap.SugaredLogger, tr *v1
 func (b *Backend) StorePayload(rawPayload []byte, signature string, opts config.StorageOpts) error {
 	b.logger.Infof("Storing payload on TaskRun %s/%s", b.tr.Namespace, b.tr.Name)
 
-	// Use patch instead of update to prevent race conditions.
-	patchBytes, err := patch.GetAnnotationsPatch(map[string]string{
+	annotations := map[string]string{
 		// Base64 encode both the signature and the payload
 		fmt.Sprintf(PayloadAnnotationFormat, opts.Key):   base64.StdEncoding.EncodeToString(rawPayload),
 		fmt.Sprintf(SignatureAnnotationFormat, opts.Key): base64.StdEncoding.EncodeToString([]byte(signature)),
 		fmt.Sprintf(CertAnnotationsFormat, opts.Key):     base64.StdEncoding.EncodeToString([]byte(opts.Cert)),
 		fmt.Sprintf(ChainAnnotationFormat, opts.Key):     base64.StdEncoding.EncodeToString([]byte(opts.Chain)),
-	})
+	}
+	// The key ID is stored as is, so it can be read at a glance.
+	if opts.KeyID != "" {
+		annotations[fmt.Sprintf(KeyIDAnnotationFormat, opts.Key)] = opts.KeyID
+	}
+	if opts.Timestamp != nil {
+		annotations[b.TimestampName(opts)] = base64.StdEncoding.EncodeToString(opts.Timestamp)
+	}
+	// Use patch instead of update to prevent race conditions.
+	patchBytes, err := patch.GetAnnotationsPatch(annotations)
 	if err != nil {
 		return err
 	}
@@ -110,41 +119,67 @@ func (b *Backend) retrieveAnnotationValue(annotationKey string, decode bool) (st
 	return annotationValue, nil
 }
 
-// RetrieveSignature retrieve the signature stored in the taskrun.
+// RetrieveSignatures retrieve the signature stored in the taskrun.
 func (b *Backend) RetrieveSignatures(opts config.StorageOpts) (map[string][]string, error) {
 	b.logger.Infof("Retrieving signature on TaskRun %s/%s", b.tr.Namespace, b.tr.Name)
-	signatureAnnotation := b.SigName(opts)
-	signature, err := b.retrieveAnnotationValue(signatureAnnotation, true)
+	signature, err := b.retrieveAnnotationValue(b.SigName(opts), true)
 	if err != nil {
 		return nil, err
 	}
 
 	m := make(map[string][]string)
-	for _, res := range b.tr.Status.TaskRunResults {
-		if strings.HasSuffix(res.Name, "IMAGE_URL") {
-			m[signatureAnnotation] = []string{signature}
-			break
-		}
+	if signature != "" {
+		m[opts.Key] = []string{signature}
 	}
 	return m, nil
 }
 
-// RetrievePayload retrieve the payload stored in the taskrun.
+// RetrievePayloads retrieve the payload stored in the taskrun.
 func (b *Backend) RetrievePayloads(opts config.StorageOpts) (map[string]string, error) {
 	b.logger.Infof("Retrieving payload on TaskRun %s/%s", b.tr.Namespace, b.tr.Name)
-	payloadAnnotation := b.PayloadName(opts)
-	payload, err := b.retrieveAnnotationValue(payloadAnnotation, true)
+	payload, err := b.retrieveAnnotationValue(b.PayloadName(opts), true)
 	if err != nil {
 		return nil, err
 	}
+
 	m := make(map[string]string)
-	for _, res := range b.tr.Status.TaskRunResults {
-		if strings.HasSuffix(res.Name, "IMAGE_URL") {
-			m[payloadAnnotation] = payload
-			break
-		}
+	if payload != "" {
+		m[opts.Key] = payload
+	}
+	return m, nil
+}
+
+// RetrieveCerts retrieve the cert and chain stored in the taskrun.
+func (b *Backend) RetrieveCerts(opts config.StorageOpts) (map[string]string, error) {
+	b.logger.Infof("Retrieving cert on TaskRun %s/%s", b.tr.Namespace, b.tr.Name)
+	cert, err := b.retrieveAnnotationValue(b.CertName(opts), true)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := b.retrieveAnnotationValue(b.ChainName(opts), true)
+	if err != nil {
+		return nil, err
 	}
 
+	m := make(map[string]string)
+	if cert != "" {
+		m[opts.Key] = cert + chain
+	}
+	return m, nil
+}
+
+// RetrieveTimestamps retrieve the timestamp token stored in the taskrun.
+func (b *Backend) RetrieveTimestamps(opts config.StorageOpts) (map[string][]string, error) {
+	b.logger.Infof("Retrieving timestamp on TaskRun %s/%s", b.tr.Namespace, b.tr.Name)
+	token, err := b.retrieveAnnotationValue(b.TimestampName(opts), true)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string][]string)
+	if token != "" {
+		m[opts.Key] = []string{token}
+	}
 	return m, nil
 }
 
@@ -163,3 +198,7 @@ func (b *Backend) CertName(opts config.StorageOpts) string {
 func (b *Backend) ChainName(opts config.StorageOpts) string {
 	return fmt.Sprintf(ChainAnnotationFormat, opts.Key)
 }
+
+func (b *Backend) TimestampName(opts config.StorageOpts) string {
+	return fmt.Sprintf(TimestampAnnotationFormat, opts.Key)
+}
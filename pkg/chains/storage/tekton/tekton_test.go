@@ -72,7 +72,7 @@ func TestBackend_StorePayload(t *testing.T) {
 			if err != nil {
 				t.Errorf("error marshaling json: %v", err)
 			}
-			opts := config.StorageOpts{Key: "mockpayload"}
+			opts := config.StorageOpts{Key: "mockpayload", KeyID: "2022-06", Timestamp: []byte("mocktimestamp")}
 			mockSignature := "mocksignature"
 			if err := b.StorePayload(payload, mockSignature, opts); (err != nil) != tt.wantErr {
 				t.Errorf("Backend.StorePayload() error = %v, wantErr %v", err, tt.wantErr)
@@ -83,14 +83,13 @@ func TestBackend_StorePayload(t *testing.T) {
 				return
 			}
 
-			payloadAnnotation := b.PayloadName(opts)
 			payloads, err := b.RetrievePayloads(opts)
 			if err != nil {
 				t.Errorf("error base64 decoding: %v", err)
 			}
 
 			mp := mockPayload{}
-			if err := json.Unmarshal([]byte(payloads[payloadAnnotation]), &mp); err != nil {
+			if err := json.Unmarshal([]byte(payloads[opts.Key]), &mp); err != nil {
 				t.Errorf("error json decoding: %v", err)
 			}
 
@@ -100,15 +99,32 @@ func TestBackend_StorePayload(t *testing.T) {
 			}
 
 			// Compare the signature.
-			signatureAnnotation := b.SigName(opts)
 			sigs, err := b.RetrieveSignatures(opts)
 			if err != nil {
 				t.Fatal(err)
 			}
-			if diff := cmp.Diff(mockSignature, sigs[signatureAnnotation][0]); diff != "" {
+			if diff := cmp.Diff(mockSignature, sigs[opts.Key][0]); diff != "" {
 				t.Errorf("unexpected signature: (-want, +got): %s", diff)
 			}
 
+			// The key ID is stored in plain text.
+			keyID, err := b.retrieveAnnotationValue("chains.tekton.dev/keyid-mockpayload", false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if keyID != opts.KeyID {
+				t.Errorf("unexpected key ID %q, want %q", keyID, opts.KeyID)
+			}
+
+			// Compare the timestamp token.
+			tokens, err := b.RetrieveTimestamps(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]string{string(opts.Timestamp)}, tokens[opts.Key]); diff != "" {
+				t.Errorf("unexpected timestamp: (-want, +got): %s", diff)
+			}
+
 		})
 	}
 }
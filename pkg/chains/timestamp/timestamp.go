@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package timestamp requests and verifies RFC 3161 timestamp tokens over signatures,
+// which prove that the signatures existed at the time of the token.
+package timestamp
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sassoftware/relic/lib/pkcs7"
+	"github.com/sassoftware/relic/lib/pkcs9"
+	"github.com/tektoncd/chains/pkg/chains/transport"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+// maxResponseSize limits the size of the responses of the timestamp authority.
+const maxResponseSize = 1 << 20
+
+// Client requests timestamp tokens from a timestamp authority.
+type Client struct {
+	url    string
+	client *http.Client
+}
+
+// NewClient returns a Client for the configured timestamp authority.
+func NewClient(cfg config.TimestampConfig) (*Client, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("no timestamp authority configured")
+	}
+	client, err := transport.NewClient(cfg.Client, "Authorization")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating timestamp authority client")
+	}
+	return &Client{url: cfg.URL, client: client}, nil
+}
+
+// Timestamp returns a DER encoded timestamp token over the SHA-256 digest of signature.
+func (c *Client) Timestamp(ctx context.Context, signature []byte) ([]byte, error) {
+	digest := sha256.Sum256(signature)
+	msg, req, err := pkcs9.NewRequest(c.url, crypto.SHA256, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "requesting timestamp")
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("requesting timestamp: %s: %s", resp.Status, body)
+	}
+	token, err := msg.ParseResponse(body)
+	if err != nil {
+		return nil, err
+	}
+	return token.Marshal()
+}
+
+// Verify checks that token is a timestamp token over signature, signed by a timestamp
+// authority with a certificate chaining up to roots, and returns the time in it.
+func Verify(token, signature []byte, roots *x509.CertPool) (time.Time, error) {
+	tst, err := pkcs7.Unmarshal(token)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "parsing timestamp token")
+	}
+	cs, err := pkcs9.Verify(tst, signature, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := cs.VerifyChain(roots, nil); err != nil {
+		return time.Time{}, errors.Wrap(err, "verifying timestamp authority certificate")
+	}
+	return cs.SigningTime, nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package timestamp
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/chains/timestamp/timestamptest"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+func TestTimestamp(t *testing.T) {
+	tsa := timestamptest.NewServer(t)
+	c, err := NewClient(config.TimestampConfig{URL: tsa.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature := []byte("signature")
+	before := time.Now().Add(-time.Second)
+	token, err := c.Timestamp(context.Background(), signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Verify(token, signature, tsa.Roots())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Before(before.Truncate(time.Second)) || got.After(time.Now()) {
+		t.Errorf("expected the time of the timestamp to be now, got %s", got)
+	}
+
+	if _, err := Verify(token, []byte("other signature"), tsa.Roots()); err == nil {
+		t.Error("expected the timestamp of another signature to be invalid")
+	}
+	if _, err := Verify(token, signature, x509.NewCertPool()); err == nil {
+		t.Error("expected the timestamp to be invalid without the root of the timestamp authority")
+	}
+}
+
+func TestTimestamp_Errors(t *testing.T) {
+	if _, err := NewClient(config.TimestampConfig{}); err == nil {
+		t.Error("expected an error without a timestamp authority")
+	}
+
+	tsa := timestamptest.NewServer(t)
+	c, err := NewClient(config.TimestampConfig{URL: tsa.URL + "/unknown"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tsa.Close()
+	if _, err := c.Timestamp(context.Background(), []byte("signature")); err == nil {
+		t.Error("expected an error when the timestamp authority is unavailable")
+	}
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package timestamptest provides a local RFC 3161 timestamp authority for tests.
+package timestamptest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sassoftware/relic/lib/pkcs7"
+	"github.com/sassoftware/relic/lib/pkcs9"
+)
+
+// policy is the TSA policy of the issued tokens, under the OID arc reserved for examples.
+var policy = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 32473, 1}
+
+// Server is a local timestamp authority. It timestamps whatever it is asked to.
+type Server struct {
+	*httptest.Server
+	// Root is the CA certificate that issues the certificate of the timestamp authority.
+	Root *x509.Certificate
+
+	cert   *x509.Certificate
+	key    *ecdsa.PrivateKey
+	mu     sync.Mutex
+	serial int64
+}
+
+// NewServer starts a local timestamp authority, which is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "timestamptest root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	root := createCertificate(t, rootTmpl, rootTmpl, rootKey, rootKey)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := createCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "timestamptest"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}, root, key, rootKey)
+
+	s := &Server{Root: root, cert: cert, key: key}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.timestamp))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Roots returns a pool with the root certificate of the timestamp authority.
+func (s *Server) Roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(s.Root)
+	return pool
+}
+
+// Requests returns how many timestamps were issued.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int(s.serial)
+}
+
+func (s *Server) timestamp(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req pkcs9.TimeStampReq
+	if _, err := asn1.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.serial++
+	serial := s.serial
+	s.mu.Unlock()
+
+	genTime, err := asn1.MarshalWithParams(time.Now().UTC().Truncate(time.Second), "generalized")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	info, err := asn1.Marshal(pkcs9.TSTInfo{
+		Version:        1,
+		Policy:         policy,
+		MessageImprint: req.MessageImprint,
+		SerialNumber:   big.NewInt(serial),
+		GenTime:        asn1.RawValue{FullBytes: genTime},
+		Nonce:          req.Nonce,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	builder := pkcs7.NewBuilder(s.key, []*x509.Certificate{s.cert, s.Root}, crypto.SHA256)
+	if err := builder.SetContent(pkcs9.OidTSTInfo, info); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	token, err := builder.Sign()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp, err := asn1.Marshal(pkcs9.TimeStampResp{
+		Status:         pkcs9.PKIStatusInfo{Status: pkcs9.StatusGranted},
+		TimeStampToken: *token,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/timestamp-reply")
+	w.Write(resp)
+}
+
+func createCertificate(t testing.TB, tmpl, parent *x509.Certificate, key, parentKey *ecdsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+// NewClient returns an http.Client that trusts the CA bundle, presents the client certificate
+// and sends the bearer token in the configured files. The token is sent in authHeader.
+func NewClient(cfg config.ClientConfig, authHeader string) (*http.Client, error) {
+	tlsConfig, err := NewTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = tlsConfig
+
+	var rt http.RoundTripper = t
+	if cfg.TokenPath != "" {
+		token, err := ioutil.ReadFile(cfg.TokenPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading token")
+		}
+		rt = &tokenRoundTripper{
+			inner:  t,
+			header: authHeader,
+			token:  strings.TrimSpace(string(token)),
+		}
+	}
+	return &http.Client{Transport: rt}, nil
+}
+
+// NewTLSConfig returns a TLS configuration that trusts the CA bundle and presents
+// the client certificate in the configured files.
+func NewTLSConfig(cfg config.ClientConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if cfg.CAPath != "" {
+		ca, err := ioutil.ReadFile(cfg.CAPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading CA bundle")
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", cfg.CAPath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.CertPath != "" || cfg.KeyPath != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertPath, cfg.KeyPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "loading client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// tokenRoundTripper adds a bearer token to every request.
+type tokenRoundTripper struct {
+	inner  http.RoundTripper
+	header string
+	token  string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *tokenRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(t.header, "Bearer "+t.token)
+	return t.inner.RoundTrip(req)
+}
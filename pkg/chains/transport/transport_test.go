@@ -0,0 +1,170 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/config"
+)
+
+func TestNewClient(t *testing.T) {
+	d := t.TempDir()
+	clientCert, clientKey := generateClientCert(t)
+	writeFile(t, filepath.Join(d, "client.crt"), clientCert)
+	writeFile(t, filepath.Join(d, "client.key"), clientKey)
+	writeFile(t, filepath.Join(d, "token"), []byte("my-token\n"))
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(clientCert)
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer my-token" {
+			t.Errorf("got Authorization header %q, expected the bearer token", got)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	s.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	s.StartTLS()
+	defer s.Close()
+	writeFile(t, filepath.Join(d, "ca.pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw}))
+
+	tests := []struct {
+		name    string
+		cfg     config.ClientConfig
+		wantErr bool
+	}{
+		{
+			name: "ca, client cert and token",
+			cfg: config.ClientConfig{
+				CAPath:    filepath.Join(d, "ca.pem"),
+				CertPath:  filepath.Join(d, "client.crt"),
+				KeyPath:   filepath.Join(d, "client.key"),
+				TokenPath: filepath.Join(d, "token"),
+			},
+		},
+		{
+			name: "missing ca",
+			cfg: config.ClientConfig{
+				CertPath:  filepath.Join(d, "client.crt"),
+				KeyPath:   filepath.Join(d, "client.key"),
+				TokenPath: filepath.Join(d, "token"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing client cert",
+			cfg: config.ClientConfig{
+				CAPath:    filepath.Join(d, "ca.pem"),
+				TokenPath: filepath.Join(d, "token"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient(tt.cfg, "Authorization")
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			resp, err := c.Get(s.URL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				resp.Body.Close()
+			}
+		})
+	}
+}
+
+func TestNewClientInvalidFiles(t *testing.T) {
+	d := t.TempDir()
+	writeFile(t, filepath.Join(d, "ca.pem"), []byte("not a cert"))
+
+	tests := []struct {
+		name string
+		cfg  config.ClientConfig
+	}{
+		{
+			name: "invalid ca",
+			cfg:  config.ClientConfig{CAPath: filepath.Join(d, "ca.pem")},
+		},
+		{
+			name: "missing key",
+			cfg:  config.ClientConfig{CertPath: filepath.Join(d, "ca.pem")},
+		},
+		{
+			name: "missing token",
+			cfg:  config.ClientConfig{TokenPath: filepath.Join(d, "token")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewClient(tt.cfg, "Authorization"); err == nil {
+				t.Error("NewClient() expected error")
+			}
+		})
+	}
+}
+
+func generateClientCert(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "chains"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key})
+}
+
+func writeFile(t *testing.T, path string, contents []byte) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, contents, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
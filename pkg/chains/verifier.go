@@ -14,13 +14,25 @@ limitations under the License.
 package chains
 
 import (
+	"bytes"
 	"context"
+	"crypto/x509"
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/pkg/errors"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
 	"github.com/tektoncd/chains/pkg/artifacts"
+	"github.com/tektoncd/chains/pkg/chains/formats"
+	"github.com/tektoncd/chains/pkg/chains/signing"
+	"github.com/tektoncd/chains/pkg/chains/storage"
+	"github.com/tektoncd/chains/pkg/chains/timestamp"
 	"github.com/tektoncd/chains/pkg/config"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	versioned "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	"go.uber.org/zap"
 	"k8s.io/client-go/kubernetes"
 	"knative.dev/pkg/logging"
 )
@@ -32,7 +44,20 @@ type Verifier interface {
 type TaskRunVerifier struct {
 	KubeClient        kubernetes.Interface
 	Pipelineclientset versioned.Interface
-	SecretPath        string
+	// SecretPath is where the keys of the configured signers are, see TaskRunSigner. If it and
+	// Verifier are unset, only signatures with a certificate are verified.
+	SecretPath string
+	// Verifier, if set, is used to verify signatures instead of the configured signers.
+	Verifier signature.Verifier
+	// Roots are trusted to issue the certificates stored alongside signatures, like the ones from Fulcio.
+	// Signatures with a certificate fail verification if Roots is nil.
+	Roots *x509.CertPool
+	// Identities are the identities the certificates may have been issued to, matching one of
+	// their email, URI or DNS SANs. Signatures with a certificate fail verification without any.
+	Identities []string
+	// TimestampRoots are trusted to issue the certificates of timestamp authorities. If set, the
+	// timestamps stored alongside signatures are verified, and tell when the signatures were made.
+	TimestampRoots *x509.CertPool
 }
 
 func (tv *TaskRunVerifier) VerifyTaskRun(ctx context.Context, tr *v1beta1.TaskRun) error {
@@ -52,39 +77,319 @@ func (tv *TaskRunVerifier) VerifyTaskRun(ctx context.Context, tr *v1beta1.TaskRu
 	if err != nil {
 		return err
 	}
-	signers := allSigners(tv.SecretPath, cfg, logger)
+	var signers map[string]signing.Signer
+	if tv.Verifier == nil && tv.SecretPath != "" {
+		signers, err = signersFor(ctx, tv.KubeClient, tv.SecretPath, tr, cfg, logger)
+		if err != nil {
+			return err
+		}
+	}
+	allFormats := allFormatters(cfg, logger)
 
 	for _, signableType := range enabledSignableTypes {
 		if !signableType.Enabled(cfg) {
 			continue
 		}
-		// Verify the signature.
-		signerType := signableType.Signer(cfg)
-		signer, ok := signers[signerType]
+		payloadFormat := signableType.PayloadFormat(cfg)
+		payloader, ok := allFormats[payloadFormat]
 		if !ok {
-			logger.Warnf("No signer %s configured for %s", signerType, signableType.Type())
+			logger.Warnf("Format %s configured for TaskRun: %v %s was not found", payloadFormat, tr, signableType.Type())
 			continue
 		}
 
-		for _, backend := range signableType.StorageBackend(cfg).List() {
-			b := allBackends[backend]
-			signatures, err := b.RetrieveSignatures(config.StorageOpts{})
+		v := &artifactVerifier{
+			roots:         tv.Roots,
+			identities:    tv.Identities,
+			tsaRoots:      tv.TimestampRoots,
+			rsaScheme:     cfg.Signers.X509.RSAScheme,
+			wrap:          payloader.Wrap(),
+			transparency:  signableType.Transparency(cfg),
+			checkTlog:     shouldCheckTlog(signableType.Transparency(cfg), tr),
+			signedAt:      signedAt(tr),
+			payloadFormat: string(payloadFormat),
+			logger:        logger,
+		}
+		signerTypes := signableType.Signers(cfg)
+		for _, obj := range signableType.ExtractObjects(tr) {
+			keys := signatureKeys(signableType.Key(obj), signerTypes, v.wrap)
+			for _, backend := range signableType.StorageBackend(cfg).List() {
+				b, ok := allBackends[backend]
+				if !ok {
+					return fmt.Errorf("storage backend %s is not configured", backend)
+				}
+
+				// The key we were given must have signed the artifact, as any of its signers.
+				if tv.Verifier != nil {
+					if err := v.verifyAny(ctx, b, keys, payloadFormat, tv.Verifier); err != nil {
+						return errors.Wrapf(err, "verifying %s signature for %s in %s", signableType.Type(), keys[0], backend)
+					}
+					continue
+				}
+
+				// Otherwise, every signer must have signed it with its key.
+				for i, signerType := range signerTypes {
+					var verifier signature.Verifier
+					if signer, ok := signers[signerType]; ok {
+						verifier = signer
+					} else {
+						logger.Warnf("No signer %s configured for %s", signerType, signableType.Type())
+					}
+					opts := config.StorageOpts{
+						Key:           keys[0],
+						PayloadFormat: payloadFormat,
+					}
+					if !v.wrap {
+						opts.Key = keys[i]
+					}
+					if err := v.verify(ctx, b, opts, verifier); err != nil {
+						return errors.Wrapf(err, "verifying %s signature from %s for %s in %s", signableType.Type(), signerType, opts.Key, backend)
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// artifactVerifier verifies the signatures stored for a single type of artifact.
+type artifactVerifier struct {
+	roots        *x509.CertPool
+	identities   []string
+	tsaRoots     *x509.CertPool
+	rsaScheme    string
+	wrap         bool
+	transparency config.TransparencyConfig
+	checkTlog    bool
+	// signedAt is when the TaskRun was signed, to tell which retired keys still verify it.
+	signedAt      time.Time
+	payloadFormat string
+	logger        *zap.SugaredLogger
+}
+
+// verifyAny checks that verifier verifies the signatures stored under any of the keys.
+func (v *artifactVerifier) verifyAny(ctx context.Context, b storage.Backend, keys []string, payloadFormat formats.PayloadType, verifier signature.Verifier) error {
+	var err error
+	for _, key := range keys {
+		opts := config.StorageOpts{
+			Key:           key,
+			PayloadFormat: payloadFormat,
+		}
+		if err = v.verify(ctx, b, opts, verifier); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (v *artifactVerifier) verify(ctx context.Context, b storage.Backend, opts config.StorageOpts, kv signature.Verifier) error {
+	signatures, err := b.RetrieveSignatures(opts)
+	if err != nil {
+		return err
+	}
+	payloads, err := b.RetrievePayloads(opts)
+	if err != nil {
+		return err
+	}
+	certs, err := b.RetrieveCerts(opts)
+	if err != nil {
+		return err
+	}
+	timestamps, err := b.RetrieveTimestamps(opts)
+	if err != nil {
+		return err
+	}
+	if len(signatures) == 0 {
+		return errors.New("no signatures found")
+	}
+
+	for ref, sigs := range signatures {
+		payload, ok := payloads[ref]
+		if !ok {
+			return fmt.Errorf("no payload found for %s", ref)
+		}
+
+		verifier := kv
+		cert, hasCert := certs[ref]
+		if hasCert && verifier != nil {
+			// The certificate may belong to a co-signer of the same envelope.
+			other, err := certForOtherKey(cert, verifier)
+			if err != nil {
+				return errors.Wrapf(err, "parsing certificate for %s", ref)
+			}
+			hasCert = !other
+		}
+
+		// Signatures with a certificate are verified with its key, others with the
+		// key we were given, or one that was retired after the TaskRun was signed.
+		var candidates []signature.Verifier
+		if hasCert {
+			if v.roots == nil {
+				return fmt.Errorf("found certificate for %s, but no trusted roots are configured", ref)
+			}
+			// The certificate itself is checked once we know when the signature was made.
+			certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(cert))
+			if err != nil {
+				return errors.Wrapf(err, "parsing certificate for %s", ref)
+			}
+			if len(certs) == 0 {
+				return fmt.Errorf("no certificate found for %s", ref)
+			}
+			certVerifier, err := signing.LoadVerifier(certs[0].PublicKey, v.rsaScheme)
 			if err != nil {
 				return err
 			}
-			payload, err := b.RetrievePayloads(config.StorageOpts{})
+			candidates = append(candidates, certVerifier)
+		} else if verifier != nil {
+			candidates = append(candidates, verifier)
+			for _, k := range signing.ValidRetiredKeys(verifier, v.signedAt) {
+				candidates = append(candidates, k.Verifier)
+			}
+		}
+		if len(candidates) == 0 {
+			return fmt.Errorf("no key or certificate to verify %s with", ref)
+		}
+
+		var sig string
+		for _, candidate := range candidates {
+			wv := candidate
+			if v.wrap {
+				if wv, err = signing.WrapVerifier(candidate); err != nil {
+					return err
+				}
+			}
+			if sig, err = verifySignatures(wv, sigs, payload); err == nil {
+				verifier = candidate
+				break
+			}
+		}
+		if err != nil {
+			return errors.Wrapf(err, "verifying %s", ref)
+		}
+
+		pkoc := []byte(cert)
+		if !hasCert {
+			pub, err := verifier.PublicKey()
 			if err != nil {
 				return err
 			}
-			for image, sigs := range signatures {
-				for _, sig := range sigs {
-					if err := signer.VerifySignature(strings.NewReader(sig), strings.NewReader(payload[image])); err != nil {
-						return err
-					}
+			if pkoc, err = cryptoutils.MarshalPublicKeyToPEM(pub); err != nil {
+				return err
+			}
+		}
+
+		// Certificates are checked at a time the signer vouches for: of the transparency log entry,
+		// or of a timestamp. The annotations of the TaskRun can be edited by its owner.
+		var certTime time.Time
+		if v.checkTlog {
+			rekorClient, err := getRekor(v.transparency, v.logger)
+			if err != nil {
+				return err
+			}
+			entry, err := rekorClient.VerifyTlog(ctx, pkoc, []byte(sig), []byte(payload), v.payloadFormat)
+			if err != nil {
+				return errors.Wrapf(err, "verifying transparency log inclusion for %s", ref)
+			}
+			v.logger.Infof("Verified transparency log inclusion for %s with index %d", ref, *entry.LogIndex)
+			if entry.IntegratedTime != nil {
+				certTime = time.Unix(*entry.IntegratedTime, 0)
+			}
+		}
+
+		if tokens := timestamps[ref]; len(tokens) > 0 {
+			if v.tsaRoots == nil {
+				v.logger.Debugf("Not verifying the timestamp for %s, no timestamp authority roots are configured", ref)
+			} else {
+				if certTime, err = verifyTimestamps(tokens, sig, v.tsaRoots); err != nil {
+					return errors.Wrapf(err, "verifying timestamp for %s", ref)
 				}
+				v.logger.Infof("Verified timestamp for %s at %s", ref, certTime)
 			}
 		}
-	}
 
+		if hasCert {
+			if certTime.IsZero() {
+				return fmt.Errorf("no transparency log entry or trusted timestamp tells when %s was signed with its certificate", ref)
+			}
+			opts := signing.CertVerifyOptions{Roots: v.roots, SignedAt: certTime, Identities: v.identities}
+			if _, err := signing.VerifyCert([]byte(cert), nil, opts); err != nil {
+				return errors.Wrapf(err, "verifying certificate for %s", ref)
+			}
+		}
+		v.logger.Infof("Verified signature for %s", ref)
+	}
 	return nil
 }
+
+// signedAt returns when tr was signed, which is when it completed.
+func signedAt(tr *v1beta1.TaskRun) time.Time {
+	if tr.Status.CompletionTime != nil {
+		return tr.Status.CompletionTime.Time
+	}
+	return time.Now()
+}
+
+// shouldCheckTlog returns whether the signatures of tr must be in the transparency log. It is
+// decided by the configuration, unless uploads are requested by annotating TaskRuns.
+func shouldCheckTlog(cfg config.TransparencyConfig, tr *v1beta1.TaskRun) bool {
+	if !cfg.Enabled {
+		return false
+	}
+	if !cfg.VerifyAnnotation {
+		return true
+	}
+	_, ok := tr.Annotations[ChainsTransparencyAnnotation]
+	return ok
+}
+
+// verifyTimestamps returns the time of the first timestamp token that is over sig and
+// issued by a timestamp authority trusted by roots.
+func verifyTimestamps(tokens []string, sig string, roots *x509.CertPool) (time.Time, error) {
+	var err error
+	for _, token := range tokens {
+		var t time.Time
+		if t, err = timestamp.Verify([]byte(token), []byte(sig), roots); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// certForOtherKey returns whether the leaf of the PEM encoded certificates is for a key other
+// than the one of verifier.
+func certForOtherKey(cert string, verifier signature.Verifier) (bool, error) {
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(cert))
+	if err != nil {
+		return false, err
+	}
+	if len(certs) == 0 {
+		return false, errors.New("no certificate found")
+	}
+	pub, err := verifier.PublicKey()
+	if err != nil {
+		return false, err
+	}
+	want, err := cryptoutils.MarshalPublicKeyToPEM(pub)
+	if err != nil {
+		return false, err
+	}
+	got, err := cryptoutils.MarshalPublicKeyToPEM(certs[0].PublicKey)
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(got, want), nil
+}
+
+// verifySignatures returns the first signature that verifies the payload.
+func verifySignatures(verifier signature.Verifier, sigs []string, payload string) (string, error) {
+	if len(sigs) == 0 {
+		return "", errors.New("no signatures found")
+	}
+	var err error
+	for _, sig := range sigs {
+		if err = verifier.VerifySignature(strings.NewReader(sig), strings.NewReader(payload)); err == nil {
+			return sig, nil
+		}
+	}
+	return "", err
+}
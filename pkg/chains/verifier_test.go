@@ -0,0 +1,561 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chains
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+
+	"github.com/tektoncd/chains/pkg/chains/timestamp"
+	"github.com/tektoncd/chains/pkg/chains/timestamp/timestamptest"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	fakepipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/logging"
+	rtesting "knative.dev/pkg/reconciler/testing"
+)
+
+func TestTaskRunVerifier_VerifyTaskRun(t *testing.T) {
+	tests := []struct {
+		name         string
+		format       string
+		transparency bool
+		tamper       func(b *mockBackend, r *mockRekor)
+		wantErr      bool
+	}{
+		{
+			name:   "tekton",
+			format: "tekton",
+		},
+		{
+			name:   "in-toto",
+			format: "in-toto",
+		},
+		{
+			name:         "in-toto with transparency",
+			format:       "in-toto",
+			transparency: true,
+		},
+		{
+			name:   "tekton, modified payload",
+			format: "tekton",
+			tamper: func(b *mockBackend, _ *mockRekor) {
+				b.storedPayload = []byte(`{"foo": "bar"}`)
+			},
+			wantErr: true,
+		},
+		{
+			name:   "in-toto, modified payload",
+			format: "in-toto",
+			tamper: func(b *mockBackend, _ *mockRekor) {
+				b.storedPayload = []byte(`{"foo": "bar"}`)
+			},
+			wantErr: true,
+		},
+		{
+			name:   "in-toto, not an envelope",
+			format: "in-toto",
+			tamper: func(b *mockBackend, _ *mockRekor) {
+				b.storedSignature = "not an envelope"
+			},
+			wantErr: true,
+		},
+		{
+			name:   "nothing stored",
+			format: "tekton",
+			tamper: func(b *mockBackend, _ *mockRekor) {
+				b.storedPayload = nil
+				b.storedSignature = ""
+			},
+			wantErr: true,
+		},
+		{
+			name:   "unexpected cert",
+			format: "tekton",
+			tamper: func(b *mockBackend, _ *mockRekor) {
+				b.storedCert = "cert"
+			},
+			wantErr: true,
+		},
+		{
+			name:         "transparency, entry missing",
+			format:       "tekton",
+			transparency: true,
+			tamper: func(_ *mockBackend, r *mockRekor) {
+				r.entries = nil
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &mockBackend{backendType: "mock"}
+			rekor := &mockRekor{}
+			cleanup := setupMocks([]*mockBackend{backend}, rekor)
+			defer cleanup()
+
+			ctx, _ := rtesting.SetupFakeContext(t)
+			ps := fakepipelineclient.Get(ctx)
+			ctx = config.ToContext(ctx, &config.Config{
+				Artifacts: config.ArtifactConfigs{
+					TaskRuns: config.Artifact{
+						Format:         tt.format,
+						StorageBackend: sets.NewString("mock"),
+						Signers:        []string{"x509"},
+					},
+				},
+				Transparency: config.TransparencyConfig{
+					Enabled: tt.transparency,
+				},
+			})
+
+			tr := &v1beta1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "foo",
+				},
+			}
+			if _, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Create(ctx, tr, metav1.CreateOptions{}); err != nil {
+				t.Fatalf("error creating fake taskrun: %v", err)
+			}
+			ts := &TaskRunSigner{
+				Pipelineclientset: ps,
+				SecretPath:        "./signing/x509/testdata/",
+			}
+			if err := ts.SignTaskRun(ctx, tr); err != nil {
+				t.Fatalf("TaskRunSigner.SignTaskRun() error = %v", err)
+			}
+			signed, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Get(ctx, tr.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error fetching fake taskrun: %v", err)
+			}
+
+			if tt.tamper != nil {
+				tt.tamper(backend, rekor)
+			}
+			tv := &TaskRunVerifier{
+				Pipelineclientset: ps,
+				SecretPath:        "./signing/x509/testdata/",
+			}
+			if err := tv.VerifyTaskRun(ctx, signed); (err != nil) != tt.wantErr {
+				t.Errorf("TaskRunVerifier.VerifyTaskRun() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTaskRunVerifier_CertChain(t *testing.T) {
+	// Issue a certificate for the test key, through an intermediate.
+	rawKey, err := ioutil.ReadFile("./signing/x509/testdata/x509.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := cryptoutils.UnmarshalPEMToPrivateKey(rawKey, cryptoutils.SkipPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	intermediateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := issueCert(t, "root", true, &rootKey.PublicKey, nil, rootKey)
+	intermediate := issueCert(t, "intermediate", true, &intermediateKey.PublicKey, root, rootKey)
+	leaf := issueCert(t, "leaf", false, key.(crypto.Signer).Public(), intermediate, intermediateKey)
+
+	secretPath := t.TempDir()
+	files := map[string][]byte{
+		"x509.pem":       rawKey,
+		"x509.crt":       certPEM(t, leaf),
+		"x509-chain.pem": certPEM(t, intermediate),
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(secretPath, name), contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	otherRoots := x509.NewCertPool()
+	otherRoots.AddCert(intermediate)
+
+	for _, format := range []string{"tekton", "in-toto"} {
+		t.Run(format, func(t *testing.T) {
+			backend := &mockBackend{backendType: "mock"}
+			rekor := &mockRekor{}
+			cleanup := setupMocks([]*mockBackend{backend}, rekor)
+			defer cleanup()
+
+			ctx, _ := rtesting.SetupFakeContext(t)
+			ps := fakepipelineclient.Get(ctx)
+			ctx = config.ToContext(ctx, &config.Config{
+				Artifacts: config.ArtifactConfigs{
+					TaskRuns: config.Artifact{
+						Format:         format,
+						StorageBackend: sets.NewString("mock"),
+						Signers:        []string{"x509"},
+					},
+				},
+				Transparency: config.TransparencyConfig{Enabled: true},
+			})
+			tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+			if _, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Create(ctx, tr, metav1.CreateOptions{}); err != nil {
+				t.Fatalf("error creating fake taskrun: %v", err)
+			}
+			ts := &TaskRunSigner{Pipelineclientset: ps, SecretPath: secretPath}
+			if err := ts.SignTaskRun(ctx, tr); err != nil {
+				t.Fatalf("TaskRunSigner.SignTaskRun() error = %v", err)
+			}
+			if want := string(files["x509.crt"]) + string(files["x509-chain.pem"]); backend.storedCert != want {
+				t.Errorf("stored cert = %q, want %q", backend.storedCert, want)
+			}
+
+			// The stored certificate is verified against the roots, not the configured key.
+			tv := &TaskRunVerifier{Pipelineclientset: ps, Roots: roots, Identities: []string{"leaf@example.com"}}
+			if err := tv.VerifyTaskRun(ctx, tr); err != nil {
+				t.Errorf("TaskRunVerifier.VerifyTaskRun() error = %v", err)
+			}
+			tv.Roots = otherRoots
+			if err := tv.VerifyTaskRun(ctx, tr); err != nil {
+				t.Errorf("TaskRunVerifier.VerifyTaskRun() error = %v, trusting the intermediate", err)
+			}
+			tv.Roots = x509.NewCertPool()
+			if err := tv.VerifyTaskRun(ctx, tr); err == nil {
+				t.Error("TaskRunVerifier.VerifyTaskRun() expected error for untrusted roots")
+			}
+			tv.Roots = roots
+
+			// The certificate must be issued to a trusted identity.
+			tv.Identities = []string{"other@example.com"}
+			if err := tv.VerifyTaskRun(ctx, tr); err == nil {
+				t.Error("TaskRunVerifier.VerifyTaskRun() expected error for an untrusted identity")
+			}
+			tv.Identities = []string{"leaf@example.com"}
+
+			// The transparency log entry is required by the configuration, not by the annotation.
+			tr, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Get(ctx, tr.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			delete(tr.Annotations, ChainsTransparencyAnnotation)
+			tr.Annotations[SignedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+			if err := tv.VerifyTaskRun(ctx, tr); err != nil {
+				t.Errorf("TaskRunVerifier.VerifyTaskRun() error = %v", err)
+			}
+			entries := rekor.entries
+			rekor.entries = nil
+			if err := tv.VerifyTaskRun(ctx, tr); err == nil {
+				t.Error("TaskRunVerifier.VerifyTaskRun() expected error without a transparency log entry")
+			}
+			rekor.entries = entries
+
+			// The certificate must have been valid at the time of the entry.
+			for i := range rekor.integratedTimes {
+				rekor.integratedTimes[i] = time.Now().Add(2 * time.Hour).Unix()
+			}
+			if err := tv.VerifyTaskRun(ctx, tr); err == nil {
+				t.Error("TaskRunVerifier.VerifyTaskRun() expected error for a certificate that had expired")
+			}
+		})
+	}
+}
+
+func TestTaskRunVerifier_Timestamp(t *testing.T) {
+	rawKey, err := ioutil.ReadFile("./signing/x509/testdata/x509.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := cryptoutils.UnmarshalPEMToPrivateKey(rawKey, cryptoutils.SkipPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := issueCert(t, "root", true, &rootKey.PublicKey, nil, rootKey)
+	leaf := issueCert(t, "leaf", false, key.(crypto.Signer).Public(), root, rootKey)
+	secretPath := t.TempDir()
+	for name, contents := range map[string][]byte{"x509.pem": rawKey, "x509.crt": certPEM(t, leaf)} {
+		if err := ioutil.WriteFile(filepath.Join(secretPath, name), contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	tsa := timestamptest.NewServer(t)
+	backend := &mockBackend{backendType: "mock"}
+	cleanup := setupMocks([]*mockBackend{backend}, &mockRekor{})
+	defer cleanup()
+
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ps := fakepipelineclient.Get(ctx)
+	ctx = config.ToContext(ctx, &config.Config{
+		Artifacts: config.ArtifactConfigs{
+			TaskRuns: config.Artifact{
+				Format:         "tekton",
+				StorageBackend: sets.NewString("mock"),
+				Signers:        []string{"x509"},
+			},
+		},
+		Timestamp: config.TimestampConfig{URL: tsa.URL},
+	})
+	tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+	if _, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Create(ctx, tr, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error creating fake taskrun: %v", err)
+	}
+	ts := &TaskRunSigner{Pipelineclientset: ps, SecretPath: secretPath}
+	if err := ts.SignTaskRun(ctx, tr); err != nil {
+		t.Fatalf("TaskRunSigner.SignTaskRun() error = %v", err)
+	}
+	tr, err = ps.TektonV1beta1().TaskRuns(tr.Namespace).Get(ctx, tr.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a transparency log, the certificate is checked at the time of the timestamp.
+	tv := &TaskRunVerifier{Pipelineclientset: ps, Roots: roots, Identities: []string{"leaf@example.com"}}
+	if err := tv.VerifyTaskRun(ctx, tr); err == nil {
+		t.Error("TaskRunVerifier.VerifyTaskRun() expected error without trusting the timestamp authority")
+	}
+	tv.TimestampRoots = tsa.Roots()
+	if err := tv.VerifyTaskRun(ctx, tr); err != nil {
+		t.Errorf("TaskRunVerifier.VerifyTaskRun() error = %v", err)
+	}
+
+	// The timestamp must be from a trusted timestamp authority, over the signature.
+	tv.TimestampRoots = roots
+	if err := tv.VerifyTaskRun(ctx, tr); err == nil {
+		t.Error("TaskRunVerifier.VerifyTaskRun() expected error for an untrusted timestamp authority")
+	}
+	tv.TimestampRoots = tsa.Roots()
+	client, err := timestamp.NewClient(config.TimestampConfig{URL: tsa.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backend.storedTimestamp, err = client.Timestamp(ctx, []byte("other signature")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tv.VerifyTaskRun(ctx, tr); err == nil {
+		t.Error("TaskRunVerifier.VerifyTaskRun() expected error for a timestamp over another signature")
+	}
+}
+
+func TestTaskRunVerifier_MultipleSigners(t *testing.T) {
+	for _, format := range []string{"tekton", "in-toto"} {
+		t.Run(format, func(t *testing.T) {
+			// The signatures are stored on the TaskRun, so co-signers get their own annotations.
+			defer setupCosigner(t)()
+
+			ctx, _ := rtesting.SetupFakeContext(t)
+			ps := fakepipelineclient.Get(ctx)
+			cfg := &config.Config{
+				Artifacts: config.ArtifactConfigs{
+					TaskRuns: config.Artifact{
+						Format:         format,
+						StorageBackend: sets.NewString("tekton"),
+						Signers:        []string{"x509", "kms"},
+					},
+				},
+			}
+			ctx = config.ToContext(ctx, cfg)
+			tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: "uid"}}
+			if _, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Create(ctx, tr, metav1.CreateOptions{}); err != nil {
+				t.Fatalf("error creating fake taskrun: %v", err)
+			}
+			ts := &TaskRunSigner{Pipelineclientset: ps, SecretPath: "./signing/x509/testdata/"}
+			if err := ts.SignTaskRun(ctx, tr); err != nil {
+				t.Fatalf("TaskRunSigner.SignTaskRun() error = %v", err)
+			}
+			signed, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Get(ctx, tr.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error fetching fake taskrun: %v", err)
+			}
+
+			tv := &TaskRunVerifier{Pipelineclientset: ps, SecretPath: "./signing/x509/testdata/"}
+			if err := tv.VerifyTaskRun(ctx, signed); err != nil {
+				t.Errorf("TaskRunVerifier.VerifyTaskRun() error = %v", err)
+			}
+
+			// A key that signed as any of the signers verifies the TaskRun on its own.
+			tv.Verifier = getSigners(ctx, "", *cfg, logging.FromContext(ctx))["kms"]
+			if err := tv.VerifyTaskRun(ctx, signed); err != nil {
+				t.Errorf("TaskRunVerifier.VerifyTaskRun() error = %v, with the co-signer key", err)
+			}
+
+			// Every configured signer must have signed it otherwise.
+			defer setupCosigner(t)()
+			tv.Verifier = nil
+			if err := tv.VerifyTaskRun(ctx, signed); err == nil {
+				t.Error("TaskRunVerifier.VerifyTaskRun() expected error for a different co-signer key")
+			}
+		})
+	}
+}
+
+func TestTaskRunVerifier_KeyRotation(t *testing.T) {
+	oldKey, err := ioutil.ReadFile("./signing/x509/testdata/x509.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newDER, err := x509.MarshalPKCS8PrivateKey(newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secretPath := t.TempDir()
+	files := map[string][]byte{
+		"old.x509.pem": oldKey,
+		"new.x509.pem": pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: newDER}),
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(secretPath, name), contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	completed := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, format := range []string{"tekton", "in-toto"} {
+		t.Run(format, func(t *testing.T) {
+			ctx, _ := rtesting.SetupFakeContext(t)
+			ps := fakepipelineclient.Get(ctx)
+			cfg := &config.Config{
+				Artifacts: config.ArtifactConfigs{
+					TaskRuns: config.Artifact{
+						Format:         format,
+						StorageBackend: sets.NewString("tekton"),
+						Signers:        []string{"x509"},
+					},
+				},
+			}
+			cfg.Signers.X509.ActiveKey = "old"
+			tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: "uid"}}
+			tr.Status.CompletionTime = &metav1.Time{Time: completed}
+			if _, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Create(ctx, tr, metav1.CreateOptions{}); err != nil {
+				t.Fatalf("error creating fake taskrun: %v", err)
+			}
+			ts := &TaskRunSigner{Pipelineclientset: ps, SecretPath: secretPath}
+			if err := ts.SignTaskRun(config.ToContext(ctx, cfg), tr); err != nil {
+				t.Fatalf("TaskRunSigner.SignTaskRun() error = %v", err)
+			}
+			signed, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Get(ctx, tr.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error fetching fake taskrun: %v", err)
+			}
+
+			// The key ID is recorded with the signature, and in the envelope.
+			if got := signed.Annotations["chains.tekton.dev/keyid-taskrun-uid"]; got != "old" {
+				t.Errorf("got key ID annotation %q, want old", got)
+			}
+			if format == "in-toto" {
+				sig, err := base64.StdEncoding.DecodeString(signed.Annotations["chains.tekton.dev/signature-taskrun-uid"])
+				if err != nil {
+					t.Fatal(err)
+				}
+				env, ok := asEnvelope(string(sig))
+				if !ok || len(env.Signatures) != 1 || env.Signatures[0].KeyID != "old" {
+					t.Errorf("expected an envelope signed by key old, got %s", sig)
+				}
+			}
+
+			// Rotate to the new key.
+			rotated := cfg.DeepCopy()
+			rotated.Signers.X509.ActiveKey = "new"
+			rotated.Signers.X509.GracePeriod = time.Hour
+			tv := &TaskRunVerifier{Pipelineclientset: ps, SecretPath: secretPath}
+			tests := []struct {
+				name      string
+				retiredAt time.Time
+				wantErr   bool
+			}{
+				{name: "retired after completion", retiredAt: completed.Add(24 * time.Hour)},
+				{name: "retired within grace period", retiredAt: completed.Add(-30 * time.Minute)},
+				{name: "retired before completion", retiredAt: completed.Add(-2 * time.Hour), wantErr: true},
+			}
+			for _, tt := range tests {
+				rotated.Signers.X509.RetiredKeys = map[string]time.Time{"old": tt.retiredAt}
+				if err := tv.VerifyTaskRun(config.ToContext(ctx, rotated), signed); (err != nil) != tt.wantErr {
+					t.Errorf("%s: TaskRunVerifier.VerifyTaskRun() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+				}
+			}
+
+			// Once the key is dropped, its signatures don't verify anymore.
+			rotated.Signers.X509.RetiredKeys = nil
+			if err := tv.VerifyTaskRun(config.ToContext(ctx, rotated), signed); err == nil {
+				t.Error("TaskRunVerifier.VerifyTaskRun() expected error without the retired key")
+			}
+		})
+	}
+}
+
+func issueCert(t *testing.T, cn string, ca bool, pub crypto.PublicKey, parent *x509.Certificate, parentKey crypto.Signer) *x509.Certificate {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		IsCA:                  ca,
+		BasicConstraintsValid: true,
+	}
+	if ca {
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		tmpl.EmailAddresses = []string{cn + "@example.com"}
+	}
+	if parent == nil {
+		parent = tmpl
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, pub, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func certPEM(t *testing.T, cert *x509.Certificate) []byte {
+	t.Helper()
+	b, err := cryptoutils.MarshalCertificateToPEM(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -18,10 +18,14 @@ package config
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/sets"
 	cm "knative.dev/pkg/configmap"
 )
@@ -32,6 +36,13 @@ type Config struct {
 	Signers      SignerConfigs
 	Builder      BuilderConfig
 	Transparency TransparencyConfig
+	Timestamp    TimestampConfig
+	// NamespaceOverrides limits what the chains-config ConfigMaps of namespaces can override.
+	NamespaceOverrides NamespaceOverridesConfig
+	// Selection decides which TaskRuns are signed.
+	Selection SelectionConfig
+	// SignOn decides whether TaskRuns that didn't succeed are signed, SignOnAlways or SignOnSuccess.
+	SignOn string
 }
 
 // ArtifactConfig contains the configuration for how to sign/store/format the signatures for each artifact type
@@ -44,7 +55,12 @@ type ArtifactConfigs struct {
 type Artifact struct {
 	Format         string
 	StorageBackend sets.String
-	Signer         string
+	// Signers lists the signers to sign with. The first one is the primary signer,
+	// any others co-sign the artifact. x509 signers can name the key to sign with,
+	// like x509:<key ID>, see ParseSigner.
+	Signers []string
+	// Transparency overrides the global transparency settings for this artifact, if set.
+	Transparency *TransparencyConfig
 }
 
 // StorageConfig contains the configuration to instantiate different storage providers
@@ -57,8 +73,28 @@ type StorageConfigs struct {
 
 // SigningConfig contains the configuration to instantiate different signers
 type SignerConfigs struct {
-	X509 X509Signer
-	KMS  KMSSigner
+	// NamespaceSecret is the name of a Secret in the namespace of a TaskRun to load its
+	// signing keys from. The global signing secrets are used if empty, or if it doesn't exist.
+	NamespaceSecret string
+	X509            X509Signer
+	KMS             KMSSigner
+	PKCS11          PKCS11Signer
+	Remote          RemoteSigner
+}
+
+// NamespaceOverridesConfig contains the keys that a chains-config ConfigMap in the namespace of a
+// TaskRun may override the global configuration with. Namespaces can't override anything if empty.
+type NamespaceOverridesConfig struct {
+	AllowedKeys sets.String
+}
+
+// SelectionConfig contains the selectors of the TaskRuns to sign. A nil selector selects everything.
+// TaskRuns with the chains.tekton.dev/sign: "false" annotation are never signed.
+type SelectionConfig struct {
+	// Namespaces selects the namespaces of the TaskRuns by their labels.
+	Namespaces labels.Selector
+	// TaskRuns selects the TaskRuns by their labels.
+	TaskRuns labels.Selector
 }
 
 type BuilderConfig struct {
@@ -66,14 +102,56 @@ type BuilderConfig struct {
 }
 
 type X509Signer struct {
+	// RSAScheme is the signature scheme used with RSA keys, pkcs1v15 (the default) or pss.
+	RSAScheme string
+	// ActiveKey is the ID of the named key to sign with. The unnamed key is used if empty.
+	ActiveKey string
+	// RetiredKeys are the IDs of keys that no longer sign, with the time they were retired at.
+	// They still verify TaskRuns that completed before then, plus the GracePeriod.
+	RetiredKeys map[string]time.Time
+	GracePeriod time.Duration
+	// GenerateKey generates the active key in the signing secrets if it doesn't exist,
+	// and publishes its public key.
+	GenerateKey   bool
 	FulcioEnabled bool
 	FulcioAddr    string
+	// FulcioIdentity is whose identity Fulcio certificates are requested for, the
+	// controller's (the default) or the ServiceAccount of each TaskRun.
+	FulcioIdentity string
+	FulcioClient   ClientConfig
+	// CAEnabled signs with a new key for every TaskRun, certified by the intermediate CA in
+	// the signing secrets, instead of Fulcio. The CA key is in KMS if CAKMSRef is set.
+	CAEnabled bool
+	CAKMSRef  string
+	// CAValidity is how long the certificates issued by the CA are valid for.
+	CAValidity time.Duration
 }
 
 type KMSSigner struct {
 	KMSRef string
 }
 
+type PKCS11Signer struct {
+	// ModulePath is the path to the PKCS#11 module of the HSM.
+	ModulePath string
+	// The token holding the key is selected by TokenLabel or Slot.
+	TokenLabel string
+	Slot       *int
+	// The key pair is selected by KeyLabel, KeyID (hex encoded) or both.
+	KeyLabel string
+	KeyID    string
+	// RSAScheme is the signature scheme used with RSA keys, pkcs1v15 (the default) or pss.
+	RSAScheme string
+}
+
+type RemoteSigner struct {
+	// Address is where the remote signer listens: unix://<path>, tcp://<host>:<port>
+	// or tls://<host>:<port>.
+	Address string
+	// Client holds the TLS files used with tls:// addresses.
+	Client ClientConfig
+}
+
 type GCSStorageConfig struct {
 	Bucket string
 }
@@ -94,6 +172,23 @@ type TransparencyConfig struct {
 	Enabled          bool
 	VerifyAnnotation bool
 	URL              string
+	Client           ClientConfig
+}
+
+// TimestampConfig contains the configuration of the RFC 3161 timestamp authority
+// signatures are timestamped by. Signatures aren't timestamped if URL is empty.
+type TimestampConfig struct {
+	URL    string
+	Client ClientConfig
+}
+
+// ClientConfig contains paths to the TLS and auth material used to talk to a sigstore service,
+// usually mounted into the controller from a Secret.
+type ClientConfig struct {
+	CAPath    string
+	CertPath  string
+	KeyPath   string
+	TokenPath string
 }
 
 const (
@@ -101,32 +196,94 @@ const (
 	taskrunStorageKey = "artifacts.taskrun.storage"
 	taskrunSignerKey  = "artifacts.taskrun.signer"
 
+	taskrunTransparencyKey    = "artifacts.taskrun.transparency"
+	taskrunTransparencyURLKey = "artifacts.taskrun.transparency.url"
+
 	ociFormatKey  = "artifacts.oci.format"
 	ociStorageKey = "artifacts.oci.storage"
 	ociSignerKey  = "artifacts.oci.signer"
 
+	ociTransparencyKey    = "artifacts.oci.transparency"
+	ociTransparencyURLKey = "artifacts.oci.transparency.url"
+
 	gcsBucketKey             = "storage.gcs.bucket"
 	ociRepositoryKey         = "storage.oci.repository"
 	ociRepositoryInsecureKey = "storage.oci.repository.insecure"
 	docDBUrlKey              = "storage.docdb.url"
 	// No config needed for Tekton object storage
 
-	// No config needed for x509 signer
+	signerNamespaceSecret = "signers.namespace-secret"
+
+	// x509
+	x509SignerRSAScheme       = "signers.x509.rsa.scheme"
+	x509SignerKeysActive      = "signers.x509.keys.active"
+	x509SignerKeysRetired     = "signers.x509.keys.retired"
+	x509SignerKeysGracePeriod = "signers.x509.keys.grace-period"
+	x509SignerKeysGenerate    = "signers.x509.keys.generate"
 
 	// KMS
 	kmsSignerKMSRef = "signers.kms.kmsref"
+	// PKCS#11
+	pkcs11SignerModule     = "signers.pkcs11.module"
+	pkcs11SignerTokenLabel = "signers.pkcs11.token-label"
+	pkcs11SignerSlot       = "signers.pkcs11.slot"
+	pkcs11SignerKeyLabel   = "signers.pkcs11.key-label"
+	pkcs11SignerKeyID      = "signers.pkcs11.key-id"
+	pkcs11SignerRSAScheme  = "signers.pkcs11.rsa.scheme"
+	// Remote
+	remoteSignerAddress = "signers.remote.address"
+	remoteSignerCA      = "signers.remote.tls.ca-file"
+	remoteSignerCert    = "signers.remote.tls.cert-file"
+	remoteSignerKey     = "signers.remote.tls.key-file"
 	// Fulcio
 	x509SignerFulcioEnabled = "signers.x509.fulcio.enabled"
 	x509SignerFulcioAuth    = "signers.x509.fulcio.auth"
 	x509SignerFulcioAddr    = "signers.x509.fulcio.address"
+	x509SignerFulcioID      = "signers.x509.fulcio.identity"
+	x509SignerFulcioCA      = "signers.x509.fulcio.tls.ca-file"
+	x509SignerFulcioCert    = "signers.x509.fulcio.tls.cert-file"
+	x509SignerFulcioKey     = "signers.x509.fulcio.tls.key-file"
+	x509SignerFulcioToken   = "signers.x509.fulcio.auth.token-file"
+
+	// Local CA
+	x509SignerCAEnabled  = "signers.x509.ca.enabled"
+	x509SignerCAKMSRef   = "signers.x509.ca.kmsref"
+	x509SignerCAValidity = "signers.x509.ca.validity"
 
 	// Builder config
 	builderIDKey = "builder.id"
 
 	transparencyEnabledKey = "transparency.enabled"
 	transparencyURLKey     = "transparency.url"
+	transparencyCAKey      = "transparency.tls.ca-file"
+	transparencyCertKey    = "transparency.tls.cert-file"
+	transparencyKeyKey     = "transparency.tls.key-file"
+	transparencyTokenKey   = "transparency.auth.token-file"
+
+	timestampURLKey   = "timestamp.url"
+	timestampCAKey    = "timestamp.tls.ca-file"
+	timestampCertKey  = "timestamp.tls.cert-file"
+	timestampKeyKey   = "timestamp.tls.key-file"
+	timestampTokenKey = "timestamp.auth.token-file"
+
+	namespaceOverridesAllowedKeys = "namespace-overrides.allowed-keys"
+
+	selectionNamespaceSelector = "selection.namespace-selector"
+	selectionTaskRunSelector   = "selection.taskrun-selector"
+
+	signOnKey = "sign-on"
 
 	ChainsConfig = "chains-config"
+
+	// FulcioIdentityController requests Fulcio certificates with the identity of the controller.
+	FulcioIdentityController = "controller"
+	// FulcioIdentityTaskRun requests Fulcio certificates with the identity of the ServiceAccount of each TaskRun.
+	FulcioIdentityTaskRun = "taskrun"
+
+	// SignOnAlways signs every TaskRun once it's done, whether it succeeded, failed, timed out or was cancelled.
+	SignOnAlways = "always"
+	// SignOnSuccess only signs the TaskRuns that succeeded.
+	SignOnSuccess = "success"
 )
 
 func (artifact *Artifact) Enabled() bool {
@@ -139,12 +296,12 @@ func defaultConfig() *Config {
 			TaskRuns: Artifact{
 				Format:         "tekton",
 				StorageBackend: sets.NewString("tekton"),
-				Signer:         "x509",
+				Signers:        []string{"x509"},
 			},
 			OCI: Artifact{
 				Format:         "simplesigning",
 				StorageBackend: sets.NewString("oci"),
-				Signer:         "x509",
+				Signers:        []string{"x509"},
 			},
 		},
 		Transparency: TransparencyConfig{
@@ -152,29 +309,63 @@ func defaultConfig() *Config {
 		},
 		Signers: SignerConfigs{
 			X509: X509Signer{
-				FulcioAddr: "https://v1.fulcio.sigstore.dev",
+				FulcioAddr:     "https://v1.fulcio.sigstore.dev",
+				FulcioIdentity: FulcioIdentityController,
+				CAValidity:     10 * time.Minute,
 			},
 		},
 		Builder: BuilderConfig{
 			ID: "https://tekton.dev/chains/v2",
 		},
+		SignOn: SignOnAlways,
 	}
 }
 
 // NewConfigFromMap creates a Config from the supplied map
 func NewConfigFromMap(data map[string]string) (*Config, error) {
 	cfg := defaultConfig()
+	if err := cfg.parse(data); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// WithOverrides returns a copy of cfg with the data of the chains-config ConfigMap of a namespace
+// applied on top. Only the keys in NamespaceOverrides.AllowedKeys can be overridden, and the
+// result is validated like NewConfigFromMapStrict does.
+func (cfg *Config) WithOverrides(data map[string]string) (*Config, error) {
+	denied := []string{}
+	for k := range data {
+		if k != cm.ExampleKey && !cfg.NamespaceOverrides.AllowedKeys.Has(k) {
+			denied = append(denied, k)
+		}
+	}
+	if len(denied) > 0 {
+		sort.Strings(denied)
+		return nil, fmt.Errorf("keys %v can't be overridden, they aren't in %s", denied, namespaceOverridesAllowedKeys)
+	}
+	out := cfg.DeepCopy()
+	if err := out.parse(data); err != nil {
+		return nil, err
+	}
+	if err := validate(data, out); err != nil {
+		return nil, fmt.Errorf("invalid data: %w", err)
+	}
+	return out, nil
+}
 
+// parse applies data on top of cfg.
+func (cfg *Config) parse(data map[string]string) error {
 	if err := cm.Parse(data,
 		// Artifact-specific configs
 		// TaskRuns
 		asString(taskrunFormatKey, &cfg.Artifacts.TaskRuns.Format, "tekton", "in-toto", "tekton-provenance"),
 		asStringSet(taskrunStorageKey, &cfg.Artifacts.TaskRuns.StorageBackend, sets.NewString("tekton", "oci", "gcs", "docdb")),
-		asString(taskrunSignerKey, &cfg.Artifacts.TaskRuns.Signer, "x509", "kms"),
+		asStringList(taskrunSignerKey, &cfg.Artifacts.TaskRuns.Signers, validSigner),
 		// OCI
 		asString(ociFormatKey, &cfg.Artifacts.OCI.Format, "simplesigning"),
 		asStringSet(ociStorageKey, &cfg.Artifacts.OCI.StorageBackend, sets.NewString("tekton", "oci", "gcs", "docdb")),
-		asString(ociSignerKey, &cfg.Artifacts.OCI.Signer, "x509", "kms"),
+		asStringList(ociSignerKey, &cfg.Artifacts.OCI.Signers, validSigner),
 
 		// Storage level configs
 		asString(gcsBucketKey, &cfg.Storage.GCS.Bucket),
@@ -182,22 +373,84 @@ func NewConfigFromMap(data map[string]string) (*Config, error) {
 		asBool(ociRepositoryInsecureKey, &cfg.Storage.OCI.Insecure),
 		asString(docDBUrlKey, &cfg.Storage.DocDB.URL),
 
-		oneOf(transparencyEnabledKey, &cfg.Transparency.Enabled, "true", "manual"),
-		oneOf(transparencyEnabledKey, &cfg.Transparency.VerifyAnnotation, "manual"),
+		asTransparencyEnabled(transparencyEnabledKey, &cfg.Transparency),
 		asString(transparencyURLKey, &cfg.Transparency.URL),
-
+		asString(transparencyCAKey, &cfg.Transparency.Client.CAPath),
+		asString(transparencyCertKey, &cfg.Transparency.Client.CertPath),
+		asString(transparencyKeyKey, &cfg.Transparency.Client.KeyPath),
+		asString(transparencyTokenKey, &cfg.Transparency.Client.TokenPath),
+
+		asString(timestampURLKey, &cfg.Timestamp.URL),
+		asString(timestampCAKey, &cfg.Timestamp.Client.CAPath),
+		asString(timestampCertKey, &cfg.Timestamp.Client.CertPath),
+		asString(timestampKeyKey, &cfg.Timestamp.Client.KeyPath),
+		asString(timestampTokenKey, &cfg.Timestamp.Client.TokenPath),
+
+		// Artifact-specific transparency overrides, these must come after the global transparency config
+		asTransparency(taskrunTransparencyKey, taskrunTransparencyURLKey, &cfg.Artifacts.TaskRuns.Transparency, &cfg.Transparency),
+		asTransparency(ociTransparencyKey, ociTransparencyURLKey, &cfg.Artifacts.OCI.Transparency, &cfg.Transparency),
+
+		asString(signerNamespaceSecret, &cfg.Signers.NamespaceSecret),
 		asString(kmsSignerKMSRef, &cfg.Signers.KMS.KMSRef),
 
+		asString(pkcs11SignerModule, &cfg.Signers.PKCS11.ModulePath),
+		asString(pkcs11SignerTokenLabel, &cfg.Signers.PKCS11.TokenLabel),
+		asOptionalInt(pkcs11SignerSlot, &cfg.Signers.PKCS11.Slot),
+		asString(pkcs11SignerKeyLabel, &cfg.Signers.PKCS11.KeyLabel),
+		asString(pkcs11SignerKeyID, &cfg.Signers.PKCS11.KeyID),
+		asString(pkcs11SignerRSAScheme, &cfg.Signers.PKCS11.RSAScheme, "pkcs1v15", "pss"),
+		// Remote
+		asString(remoteSignerAddress, &cfg.Signers.Remote.Address),
+		asString(remoteSignerCA, &cfg.Signers.Remote.Client.CAPath),
+		asString(remoteSignerCert, &cfg.Signers.Remote.Client.CertPath),
+		asString(remoteSignerKey, &cfg.Signers.Remote.Client.KeyPath),
+
+		asString(x509SignerRSAScheme, &cfg.Signers.X509.RSAScheme, "pkcs1v15", "pss"),
+		asKeyID(x509SignerKeysActive, &cfg.Signers.X509.ActiveKey),
+		asRetiredKeys(x509SignerKeysRetired, &cfg.Signers.X509.RetiredKeys),
+		asDuration(x509SignerKeysGracePeriod, &cfg.Signers.X509.GracePeriod),
+		asBool(x509SignerKeysGenerate, &cfg.Signers.X509.GenerateKey),
 		asBool(x509SignerFulcioEnabled, &cfg.Signers.X509.FulcioEnabled),
 		asString(x509SignerFulcioAddr, &cfg.Signers.X509.FulcioAddr),
+		asString(x509SignerFulcioID, &cfg.Signers.X509.FulcioIdentity, FulcioIdentityController, FulcioIdentityTaskRun),
+		asString(x509SignerFulcioCA, &cfg.Signers.X509.FulcioClient.CAPath),
+		asString(x509SignerFulcioCert, &cfg.Signers.X509.FulcioClient.CertPath),
+		asString(x509SignerFulcioKey, &cfg.Signers.X509.FulcioClient.KeyPath),
+		asString(x509SignerFulcioToken, &cfg.Signers.X509.FulcioClient.TokenPath),
+		asBool(x509SignerCAEnabled, &cfg.Signers.X509.CAEnabled),
+		asString(x509SignerCAKMSRef, &cfg.Signers.X509.CAKMSRef),
+		asDuration(x509SignerCAValidity, &cfg.Signers.X509.CAValidity),
 
 		// Build config
 		asString(builderIDKey, &cfg.Builder.ID),
+
+		asStringSet(namespaceOverridesAllowedKeys, &cfg.NamespaceOverrides.AllowedKeys, overridableKeys),
+
+		asSelector(selectionNamespaceSelector, &cfg.Selection.Namespaces),
+		asSelector(selectionTaskRunSelector, &cfg.Selection.TaskRuns),
+
+		asString(signOnKey, &cfg.SignOn, SignOnAlways, SignOnSuccess),
 	); err != nil {
-		return nil, fmt.Errorf("failed to parse data: %w", err)
+		return fmt.Errorf("failed to parse data: %w", err)
+	}
+	if _, ok := cfg.Signers.X509.RetiredKeys[cfg.Signers.X509.ActiveKey]; ok {
+		return fmt.Errorf("failed to parse data: key %q is both active and retired", cfg.Signers.X509.ActiveKey)
+	}
+	for _, s := range append(append([]string{}, cfg.Artifacts.TaskRuns.Signers...), cfg.Artifacts.OCI.Signers...) {
+		if _, keyID := ParseSigner(s); keyID != "" {
+			if _, ok := cfg.Signers.X509.RetiredKeys[keyID]; ok {
+				return fmt.Errorf("failed to parse data: key %q signs but is retired", keyID)
+			}
+		}
+	}
+	if cfg.Signers.X509.CAEnabled && cfg.Signers.X509.FulcioEnabled {
+		return fmt.Errorf("failed to parse data: %s and %s are mutually exclusive", x509SignerCAEnabled, x509SignerFulcioEnabled)
+	}
+	if cfg.Signers.X509.CAEnabled && cfg.Signers.X509.CAValidity == 0 {
+		return fmt.Errorf("failed to parse data: %s must be positive", x509SignerCAValidity)
 	}
 
-	return cfg, nil
+	return nil
 }
 
 // NewConfigFromConfigMap creates a Config from the supplied ConfigMap
@@ -205,21 +458,47 @@ func NewConfigFromConfigMap(configMap *corev1.ConfigMap) (*Config, error) {
 	return NewConfigFromMap(configMap.Data)
 }
 
-// oneOf sets target to true if it maches any of the values
-func oneOf(key string, target *bool, values ...string) cm.ParseFunc {
+// asTransparencyEnabled sets whether the transparency log is enabled in target, and whether it
+// is only used for TaskRuns with the transparency annotation, from the value at key if it exists.
+// Values other than "true" and "manual" disable it.
+func asTransparencyEnabled(key string, target *TransparencyConfig) cm.ParseFunc {
 	return func(data map[string]string) error {
 		raw, ok := data[key]
 		if !ok {
 			return nil
 		}
-		if values == nil {
+		target.Enabled = raw == "true" || raw == "manual"
+		target.VerifyAnnotation = raw == "manual"
+		return nil
+	}
+}
+
+// asTransparency sets target to a copy of the global transparency config with the
+// values at enabledKey and urlKey applied, if either of them exists.
+func asTransparency(enabledKey, urlKey string, target **TransparencyConfig, global *TransparencyConfig) cm.ParseFunc {
+	return func(data map[string]string) error {
+		enabled, hasEnabled := data[enabledKey]
+		url, hasURL := data[urlKey]
+		if !hasEnabled && !hasURL {
 			return nil
 		}
-		for _, v := range values {
-			if v == raw {
-				*target = true
+		t := *global
+		if hasEnabled {
+			switch enabled {
+			case "true":
+				t.Enabled, t.VerifyAnnotation = true, false
+			case "manual":
+				t.Enabled, t.VerifyAnnotation = true, true
+			case "false":
+				t.Enabled, t.VerifyAnnotation = false, false
+			default:
+				return fmt.Errorf("invalid value %q wanted one of [false manual true]", enabled)
 			}
 		}
+		if hasURL {
+			t.URL = url
+		}
+		*target = &t
 		return nil
 	}
 }
@@ -241,6 +520,104 @@ func asBool(key string, target *bool) cm.ParseFunc {
 	}
 }
 
+// asOptionalInt parses the value at key as an int into the target, if it exists.
+func asOptionalInt(key string, target **int) cm.ParseFunc {
+	return func(data map[string]string) error {
+		raw, ok := data[key]
+		if !ok {
+			return nil
+		}
+		val, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse %q: %w", key, err)
+		}
+		*target = &val
+		return nil
+	}
+}
+
+// asDuration parses the value at key as a time.Duration into the target, if it exists.
+func asDuration(key string, target *time.Duration) cm.ParseFunc {
+	return func(data map[string]string) error {
+		raw, ok := data[key]
+		if !ok {
+			return nil
+		}
+		val, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse %q: %w", key, err)
+		}
+		if val < 0 {
+			return fmt.Errorf("%s must not be negative", key)
+		}
+		*target = val
+		return nil
+	}
+}
+
+// keyIDPattern matches key IDs, which prefix the names of files in the signing secret.
+var keyIDPattern = regexp.MustCompile(`^[a-zA-Z0-9][-_a-zA-Z0-9]*$`)
+
+// asKeyID passes the value at key through into the target, if it exists and is a valid key ID.
+func asKeyID(key string, target *string) cm.ParseFunc {
+	return func(data map[string]string) error {
+		raw, ok := data[key]
+		if !ok || raw == "" {
+			return nil
+		}
+		if !keyIDPattern.MatchString(raw) {
+			return fmt.Errorf("invalid key ID %q for %s, wanted letters, digits, '-' and '_'", raw, key)
+		}
+		*target = raw
+		return nil
+	}
+}
+
+// asRetiredKeys parses the value at key as a list of <key ID>=<RFC 3339 time> (split by ',') into the target, if it exists.
+func asRetiredKeys(key string, target *map[string]time.Time) cm.ParseFunc {
+	return func(data map[string]string) error {
+		raw, ok := data[key]
+		if !ok || strings.TrimSpace(raw) == "" {
+			return nil
+		}
+		retired := map[string]time.Time{}
+		for _, v := range strings.Split(raw, ",") {
+			parts := strings.SplitN(strings.TrimSpace(v), "=", 2)
+			if len(parts) != 2 || !keyIDPattern.MatchString(parts[0]) {
+				return fmt.Errorf("invalid value %q for %s, wanted <key ID>=<RFC 3339 time>", v, key)
+			}
+			t, err := time.Parse(time.RFC3339, parts[1])
+			if err != nil {
+				return fmt.Errorf("invalid retirement time for key %q: %w", parts[0], err)
+			}
+			retired[parts[0]] = t
+		}
+		*target = retired
+		return nil
+	}
+}
+
+// asSelector parses the value at key as a label selector into the target, if it exists.
+// An empty value selects everything.
+func asSelector(key string, target *labels.Selector) cm.ParseFunc {
+	return func(data map[string]string) error {
+		raw, ok := data[key]
+		if !ok {
+			return nil
+		}
+		if strings.TrimSpace(raw) == "" {
+			*target = nil
+			return nil
+		}
+		selector, err := labels.Parse(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse %q: %w", key, err)
+		}
+		*target = selector
+		return nil
+	}
+}
+
 // asString passes the value at key through into the target, if it exists.
 // TODO(mattmoor): This might be a nice variation on cm.AsString to upstream.
 func asString(key string, target *string, values ...string) cm.ParseFunc {
@@ -282,3 +659,58 @@ func asStringSet(key string, target *sets.String, allowed sets.String) cm.ParseF
 		return nil
 	}
 }
+
+// signerTypes are the types of signers artifacts can be signed with.
+var signerTypes = sets.NewString("x509", "kms", "pkcs11", "remote")
+
+// ParseSigner splits a signer of an artifact into its type and the ID of the named key it signs
+// with, which is empty for signers that sign with the configured key. Only x509 signers name
+// their key, like x509:<key ID>, so the old and new keys can co-sign during a rotation.
+func ParseSigner(signer string) (signerType, keyID string) {
+	parts := strings.SplitN(signer, ":", 2)
+	if len(parts) == 1 {
+		return signer, ""
+	}
+	return parts[0], parts[1]
+}
+
+// validSigner checks that s is a signer type, or x509:<key ID>.
+func validSigner(s string) error {
+	signerType, keyID := ParseSigner(s)
+	if !signerTypes.Has(signerType) {
+		return fmt.Errorf("invalid value %q wanted one of %v, or x509:<key ID>", s, signerTypes.List())
+	}
+	if s != signerType && (signerType != "x509" || !keyIDPattern.MatchString(keyID)) {
+		return fmt.Errorf("invalid value %q wanted x509:<key ID> with letters, digits, '-' and '_' in the key ID", s)
+	}
+	return nil
+}
+
+// asStringList parses the value at key as an ordered list of unique values (split by ',') into the target, if it exists.
+// Each value is checked with valid.
+func asStringList(key string, target *[]string, valid func(string) error) cm.ParseFunc {
+	return func(data map[string]string) error {
+		raw, ok := data[key]
+		if !ok {
+			return nil
+		}
+		list := []string{}
+		seen := sets.NewString()
+		for _, v := range strings.Split(raw, ",") {
+			v = strings.TrimSpace(v)
+			if v == "" || seen.Has(v) {
+				continue
+			}
+			if err := valid(v); err != nil {
+				return err
+			}
+			seen.Insert(v)
+			list = append(list, v)
+		}
+		if len(list) == 0 {
+			return fmt.Errorf("%s must list at least one value", key)
+		}
+		*target = list
+		return nil
+	}
+}
@@ -20,8 +20,12 @@ import "github.com/tektoncd/chains/pkg/chains/formats"
 
 // StorageOpts contains additional information required when storing signatures
 type StorageOpts struct {
-	Key           string
-	Cert          string
-	Chain         string
+	Key   string
+	Cert  string
+	Chain string
+	// KeyID identifies the key the signature was made with.
+	KeyID string
+	// Timestamp is an RFC 3161 timestamp token over the signature.
+	Timestamp     []byte
 	PayloadFormat formats.PayloadType
 }
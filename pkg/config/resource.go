@@ -0,0 +1,174 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NewConfigFromResource creates a Config from the spec of a ChainsConfig, see DataFromResource.
+func NewConfigFromResource(ctx context.Context, c *v1alpha1.ChainsConfig) (*Config, error) {
+	data, err := DataFromResource(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	return NewConfigFromMap(data)
+}
+
+// DataFromResource defaults and validates the spec of a ChainsConfig, and converts it to the data of
+// the equivalent chains-config ConfigMap. The data is validated like NewConfigFromMapStrict does.
+func DataFromResource(ctx context.Context, c *v1alpha1.ChainsConfig) (map[string]string, error) {
+	c = c.DeepCopy()
+	c.SetDefaults(ctx)
+	if err := c.Validate(ctx); err != nil {
+		return nil, err
+	}
+	data := DataFromSpec(&c.Spec)
+	if _, err := NewConfigFromMapStrict(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// DataFromSpec converts the spec of a ChainsConfig to the data of the equivalent chains-config
+// ConfigMap. Empty fields are left out, so they keep the defaults of the ConfigMap.
+func DataFromSpec(s *v1alpha1.ChainsConfigSpec) map[string]string {
+	data := map[string]string{}
+	set := func(key, value string) {
+		if value != "" {
+			data[key] = value
+		}
+	}
+	setBool := func(key string, value bool) {
+		if value {
+			data[key] = "true"
+		}
+	}
+	setClient := func(ca, cert, key, token string, c v1alpha1.ClientSpec) {
+		set(ca, c.CAFile)
+		set(cert, c.CertFile)
+		set(key, c.KeyFile)
+		if token != "" {
+			set(token, c.TokenFile)
+		}
+	}
+	setArtifact := func(format, storage, signer, transparency, transparencyURL string, a v1alpha1.ArtifactSpec) {
+		set(format, a.Format)
+		if a.Disabled {
+			// An empty storage disables the artifact.
+			data[storage] = ""
+		} else {
+			set(storage, strings.Join(a.Storage, ","))
+		}
+		set(signer, strings.Join(a.Signers, ","))
+		if a.Transparency != nil {
+			set(transparency, transparencyValue(a.Transparency.Mode))
+			set(transparencyURL, a.Transparency.URL)
+		}
+	}
+
+	setArtifact(taskrunFormatKey, taskrunStorageKey, taskrunSignerKey, taskrunTransparencyKey, taskrunTransparencyURLKey, s.Artifacts.TaskRuns)
+	setArtifact(ociFormatKey, ociStorageKey, ociSignerKey, ociTransparencyKey, ociTransparencyURLKey, s.Artifacts.OCI)
+
+	set(gcsBucketKey, s.Storage.GCS.Bucket)
+	set(ociRepositoryKey, s.Storage.OCI.Repository)
+	setBool(ociRepositoryInsecureKey, s.Storage.OCI.Insecure)
+	set(docDBUrlKey, s.Storage.DocDB.URL)
+
+	signers := s.Signers
+	set(signerNamespaceSecret, signers.NamespaceSecret)
+	x509 := signers.X509
+	set(x509SignerRSAScheme, x509.RSAScheme)
+	set(x509SignerKeysActive, x509.Keys.Active)
+	retired := make([]string, 0, len(x509.Keys.Retired))
+	for _, k := range x509.Keys.Retired {
+		retired = append(retired, k.ID+"="+k.RetiredAt.UTC().Format(time.RFC3339))
+	}
+	set(x509SignerKeysRetired, strings.Join(retired, ","))
+	if x509.Keys.GracePeriod.Duration != 0 {
+		data[x509SignerKeysGracePeriod] = x509.Keys.GracePeriod.Duration.String()
+	}
+	setBool(x509SignerKeysGenerate, x509.Keys.Generate)
+	setBool(x509SignerFulcioEnabled, x509.Fulcio.Enabled)
+	set(x509SignerFulcioAddr, x509.Fulcio.Address)
+	set(x509SignerFulcioID, x509.Fulcio.Identity)
+	setClient(x509SignerFulcioCA, x509SignerFulcioCert, x509SignerFulcioKey, x509SignerFulcioToken, x509.Fulcio.Client)
+	setBool(x509SignerCAEnabled, x509.CA.Enabled)
+	set(x509SignerCAKMSRef, x509.CA.KMSRef)
+	if x509.CA.Validity.Duration != 0 {
+		data[x509SignerCAValidity] = x509.CA.Validity.Duration.String()
+	}
+
+	set(kmsSignerKMSRef, signers.KMS.KMSRef)
+
+	p := signers.PKCS11
+	set(pkcs11SignerModule, p.Module)
+	set(pkcs11SignerTokenLabel, p.TokenLabel)
+	if p.Slot != nil {
+		data[pkcs11SignerSlot] = strconv.Itoa(*p.Slot)
+	}
+	set(pkcs11SignerKeyLabel, p.KeyLabel)
+	set(pkcs11SignerKeyID, p.KeyID)
+	set(pkcs11SignerRSAScheme, p.RSAScheme)
+
+	set(remoteSignerAddress, signers.Remote.Address)
+	setClient(remoteSignerCA, remoteSignerCert, remoteSignerKey, "", signers.Remote.Client)
+
+	set(transparencyEnabledKey, transparencyValue(s.Transparency.Mode))
+	set(transparencyURLKey, s.Transparency.URL)
+	setClient(transparencyCAKey, transparencyCertKey, transparencyKeyKey, transparencyTokenKey, s.Transparency.Client)
+
+	set(timestampURLKey, s.Timestamp.URL)
+	setClient(timestampCAKey, timestampCertKey, timestampKeyKey, timestampTokenKey, s.Timestamp.Client)
+
+	set(builderIDKey, s.Builder.ID)
+	set(namespaceOverridesAllowedKeys, strings.Join(s.NamespaceOverrides.AllowedKeys, ","))
+	set(selectionNamespaceSelector, selectorValue(s.Selection.NamespaceSelector))
+	set(selectionTaskRunSelector, selectorValue(s.Selection.TaskRunSelector))
+	set(signOnKey, s.SignOn)
+	return data
+}
+
+// selectorValue converts a label selector to its string form. Invalid selectors are rejected by
+// validation.
+func selectorValue(s *metav1.LabelSelector) string {
+	selector, err := metav1.LabelSelectorAsSelector(s)
+	if err != nil || selector.Empty() {
+		return ""
+	}
+	return selector.String()
+}
+
+// transparencyValue converts a transparency mode to the value of transparency.enabled.
+func transparencyValue(mode string) string {
+	switch mode {
+	case "":
+		return ""
+	case v1alpha1.TransparencyEnabled:
+		return "true"
+	case v1alpha1.TransparencyDisabled:
+		return "false"
+	case v1alpha1.TransparencyManual:
+		return "manual"
+	default:
+		// Invalid modes are rejected by NewConfigFromMapStrict.
+		return mode
+	}
+}
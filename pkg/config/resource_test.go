@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestNewConfigFromResource(t *testing.T) {
+	ctx := context.Background()
+	slot := 2
+	retiredAt := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := &v1alpha1.ChainsConfig{Spec: v1alpha1.ChainsConfigSpec{
+		Artifacts: v1alpha1.ArtifactsSpec{
+			TaskRuns: v1alpha1.ArtifactSpec{
+				Format:       "in-toto",
+				Storage:      []string{"gcs", "oci"},
+				Signers:      []string{"pkcs11", "x509"},
+				Transparency: &v1alpha1.TransparencySpec{Mode: v1alpha1.TransparencyEnabled},
+			},
+			OCI: v1alpha1.ArtifactSpec{Disabled: true},
+		},
+		Storage: v1alpha1.StorageSpec{GCS: v1alpha1.GCSStorageSpec{Bucket: "bucket"}},
+		Signers: v1alpha1.SignersSpec{
+			X509: v1alpha1.X509SignerSpec{Keys: v1alpha1.KeysSpec{
+				Active:      "b",
+				Retired:     []v1alpha1.RetiredKey{{ID: "a", RetiredAt: metav1.NewTime(retiredAt)}},
+				GracePeriod: metav1.Duration{Duration: time.Hour},
+			}},
+			PKCS11: v1alpha1.PKCS11SignerSpec{Module: "/softhsm2.so", Slot: &slot, KeyLabel: "chains"},
+		},
+		Transparency:       v1alpha1.TransparencySpec{Mode: v1alpha1.TransparencyManual},
+		NamespaceOverrides: v1alpha1.NamespaceOverridesSpec{AllowedKeys: []string{"builder.id"}},
+		Selection: v1alpha1.SelectionSpec{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"chains": "enabled"}},
+			TaskRunSelector:   &metav1.LabelSelector{},
+		},
+		SignOn: v1alpha1.SignOnSuccess,
+	}}
+
+	got, err := NewConfigFromResource(ctx, c)
+	if err != nil {
+		t.Fatalf("NewConfigFromResource() = %v", err)
+	}
+	want, err := NewConfigFromMap(map[string]string{
+		"artifacts.taskrun.format":         "in-toto",
+		"artifacts.taskrun.storage":        "gcs,oci",
+		"artifacts.taskrun.signer":         "pkcs11,x509",
+		"artifacts.taskrun.transparency":   "true",
+		"artifacts.oci.storage":            "",
+		"storage.gcs.bucket":               "bucket",
+		"signers.x509.keys.active":         "b",
+		"signers.x509.keys.retired":        "a=2022-03-01T12:00:00Z",
+		"signers.x509.keys.grace-period":   "1h",
+		"signers.pkcs11.module":            "/softhsm2.so",
+		"signers.pkcs11.slot":              "2",
+		"signers.pkcs11.key-label":         "chains",
+		"transparency.enabled":             "manual",
+		"namespace-overrides.allowed-keys": "builder.id",
+		"selection.namespace-selector":     "chains=enabled",
+		"sign-on":                          "success",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got, cmp.Comparer(func(x, y labels.Selector) bool {
+		return (x == nil) == (y == nil) && (x == nil || x.String() == y.String())
+	})); diff != "" {
+		t.Errorf("NewConfigFromResource() differs from the ConfigMap (-want +got): %s", diff)
+	}
+
+	// The spec isn't modified by the defaulting.
+	if c.Spec.Artifacts.OCI.Format != "" {
+		t.Error("NewConfigFromResource() modified the ChainsConfig")
+	}
+}
+
+func TestNewConfigFromResourceInvalid(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		spec v1alpha1.ChainsConfigSpec
+		want string
+	}{{
+		name: "invalid field",
+		spec: v1alpha1.ChainsConfigSpec{Transparency: v1alpha1.TransparencySpec{Mode: "always"}},
+		want: "spec.transparency.mode",
+	}, {
+		name: "invalid combination",
+		spec: v1alpha1.ChainsConfigSpec{Artifacts: v1alpha1.ArtifactsSpec{
+			TaskRuns: v1alpha1.ArtifactSpec{Signers: []string{"kms"}},
+		}},
+		want: "artifacts.taskrun.signer kms requires signers.kms.kmsref",
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewConfigFromResource(context.Background(), &v1alpha1.ChainsConfig{Spec: tt.spec})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("NewConfigFromResource() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
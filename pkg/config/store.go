@@ -18,7 +18,14 @@ package config
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"sync"
 
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/reconciler"
 )
@@ -28,6 +35,16 @@ type cfgKey struct{}
 // +k8s:deepcopy-gen=false
 type ConfigStore struct {
 	*configmap.UntypedStore
+
+	// namespaceConfigs lists the chains-config ConfigMaps of the namespaces, that override the
+	// global configuration. Namespaces don't override anything if nil.
+	namespaceConfigs corev1listers.ConfigMapLister
+
+	mu sync.Mutex
+	// configMap is the last chains-config ConfigMap seen, and resourceData the data converted from
+	// the ChainsConfig that replaces it, if any.
+	configMap    *corev1.ConfigMap
+	resourceData map[string]string
 }
 
 var _ reconciler.ConfigStore = (*ConfigStore)(nil)
@@ -52,6 +69,65 @@ func (s *ConfigStore) Load() *Config {
 	return s.UntypedLoad(ChainsConfig).(*Config).DeepCopy()
 }
 
+// WatchConfigs watches the chains-config ConfigMap with w. The ConfigMap is only loaded while
+// no ChainsConfig replaces it, see SetResourceData.
+func (s *ConfigStore) WatchConfigs(w configmap.Watcher) {
+	w.Watch(ChainsConfig, s.onConfigMapChanged)
+}
+
+func (s *ConfigStore) onConfigMapChanged(cm *corev1.ConfigMap) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configMap = cm
+	if s.resourceData == nil {
+		s.OnConfigChanged(cm)
+	}
+}
+
+// SetResourceData replaces the chains-config ConfigMap with data, converted from a ChainsConfig by
+// NewConfigFromResource. The ConfigMap is loaded again if data is nil.
+func (s *ConfigStore) SetResourceData(data map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if reflect.DeepEqual(data, s.resourceData) {
+		return
+	}
+	s.resourceData = data
+	switch {
+	case data != nil:
+		s.OnConfigChanged(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ChainsConfig}, Data: data})
+	case s.configMap != nil:
+		s.OnConfigChanged(s.configMap)
+	}
+}
+
+// WatchNamespaces makes ToNamespaceContext override the configuration with the chains-config
+// ConfigMaps listed by lister. It must not list the global chains-config.
+func (s *ConfigStore) WatchNamespaces(lister corev1listers.ConfigMapLister) {
+	s.namespaceConfigs = lister
+}
+
+// ToNamespaceContext overrides the configuration in ctx with the chains-config ConfigMap of
+// namespace, if namespaces are allowed to override keys and it exists.
+func (s *ConfigStore) ToNamespaceContext(ctx context.Context, namespace string) (context.Context, error) {
+	cfg := FromContext(ctx)
+	if s.namespaceConfigs == nil || cfg.NamespaceOverrides.AllowedKeys.Len() == 0 {
+		return ctx, nil
+	}
+	cm, err := s.namespaceConfigs.ConfigMaps(namespace).Get(ChainsConfig)
+	if apierrors.IsNotFound(err) {
+		return ctx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	nsCfg, err := cfg.WithOverrides(cm.Data)
+	if err != nil {
+		return nil, fmt.Errorf("overriding the configuration with %s/%s: %w", namespace, ChainsConfig, err)
+	}
+	return ToContext(ctx, nsCfg), nil
+}
+
 // NewConfigStore returns a reconciler.ConfigStore for the chains configuration data.
 func NewConfigStore(logger configmap.Logger, onAfterStore ...func(name string, value interface{})) *ConfigStore {
 	return &ConfigStore{
@@ -17,6 +17,7 @@ limitations under the License.
 package config
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -25,6 +26,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	fakek8s "k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 	"knative.dev/pkg/configmap/informer"
 	logtesting "knative.dev/pkg/logging/testing"
 	rtesting "knative.dev/pkg/reconciler/testing"
@@ -67,7 +70,7 @@ func TestNewConfigStore(t *testing.T) {
 	// It should be updated by then...
 	time.Sleep(100 * time.Millisecond)
 	// Test that the values are set!
-	if diff := cmp.Diff("x509", cs.Load().Artifacts.TaskRuns.Signer); diff != "" {
+	if diff := cmp.Diff([]string{"x509"}, cs.Load().Artifacts.TaskRuns.Signers); diff != "" {
 		t.Error(diff)
 	}
 
@@ -79,14 +82,114 @@ func TestNewConfigStore(t *testing.T) {
 	}
 	time.Sleep(100 * time.Millisecond)
 	// Test that the values are set!
-	if diff := cmp.Diff("kms", cs.Load().Artifacts.TaskRuns.Signer); diff != "" {
+	if diff := cmp.Diff([]string{"kms"}, cs.Load().Artifacts.TaskRuns.Signers); diff != "" {
 		t.Error(diff)
 	}
 }
 
+func TestToNamespaceContext(t *testing.T) {
+	global := map[string]string{
+		"artifacts.taskrun.storage":        "tekton,oci",
+		"artifacts.taskrun.format":         "in-toto",
+		"namespace-overrides.allowed-keys": "artifacts.taskrun.storage, storage.oci.repository,builder.id",
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for ns, data := range map[string]map[string]string{
+		"team-a": {"storage.oci.repository": "registry.example.com/team-a", "builder.id": "https://example.com/team-a"},
+		"team-b": {"artifacts.taskrun.format": "tekton"},
+		"team-c": {"artifacts.taskrun.storage": "gcs"},
+	} {
+		if err := indexer.Add(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ChainsConfig, Namespace: ns}, Data: data}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		namespace   string
+		global      map[string]string
+		watch       bool
+		wantErr     bool
+		wantRepo    string
+		wantBuilder string
+	}{
+		{
+			name:        "overridden",
+			namespace:   "team-a",
+			global:      global,
+			watch:       true,
+			wantRepo:    "registry.example.com/team-a",
+			wantBuilder: "https://example.com/team-a",
+		},
+		{
+			name:        "no chains-config in the namespace",
+			namespace:   "team-d",
+			global:      global,
+			watch:       true,
+			wantBuilder: "https://tekton.dev/chains/v2",
+		},
+		{
+			name:      "key not allowed",
+			namespace: "team-b",
+			global:    global,
+			watch:     true,
+			wantErr:   true,
+		},
+		{
+			name:      "invalid result",
+			namespace: "team-c",
+			global:    global,
+			watch:     true,
+			wantErr:   true,
+		},
+		{
+			name:        "overrides not allowed",
+			namespace:   "team-b",
+			global:      map[string]string{},
+			watch:       true,
+			wantBuilder: "https://tekton.dev/chains/v2",
+		},
+		{
+			name:        "namespaces not watched",
+			namespace:   "team-a",
+			global:      global,
+			wantBuilder: "https://tekton.dev/chains/v2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := NewConfigFromMap(tt.global)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cs := NewConfigStore(logtesting.TestLogger(t))
+			if tt.watch {
+				cs.WatchNamespaces(corev1listers.NewConfigMapLister(indexer))
+			}
+			ctx, err := cs.ToNamespaceContext(ToContext(context.Background(), cfg), tt.namespace)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ToNamespaceContext() = %v, wantErr %t", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := FromContext(ctx)
+			if got.Storage.OCI.Repository != tt.wantRepo || got.Builder.ID != tt.wantBuilder {
+				t.Errorf("unexpected configuration %+v", got)
+			}
+			// The global configuration is left alone.
+			if cfg.Builder.ID != "https://tekton.dev/chains/v2" {
+				t.Errorf("the global configuration was modified: %+v", cfg)
+			}
+		})
+	}
+}
+
 var defaultSigners = SignerConfigs{
 	X509: X509Signer{
-		FulcioAddr: "https://v1.fulcio.sigstore.dev",
+		FulcioAddr:     "https://v1.fulcio.sigstore.dev",
+		FulcioIdentity: "controller",
+		CAValidity:     10 * time.Minute,
 	},
 }
 
@@ -107,16 +210,17 @@ func TestParse(t *testing.T) {
 				Builder: BuilderConfig{
 					"https://tekton.dev/chains/v2",
 				},
+				SignOn: SignOnAlways,
 				Artifacts: ArtifactConfigs{
 					TaskRuns: Artifact{
 						Format:         "tekton",
 						StorageBackend: sets.NewString("tekton"),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 					OCI: Artifact{
 						Format:         "simplesigning",
 						StorageBackend: sets.NewString("oci"),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 				},
 				Signers: defaultSigners,
@@ -134,16 +238,17 @@ func TestParse(t *testing.T) {
 				Builder: BuilderConfig{
 					"https://tekton.dev/chains/v2",
 				},
+				SignOn: SignOnAlways,
 				Artifacts: ArtifactConfigs{
 					TaskRuns: Artifact{
 						Format:         "tekton",
 						StorageBackend: sets.NewString("tekton", "oci"),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 					OCI: Artifact{
 						Format:         "simplesigning",
 						StorageBackend: sets.NewString("oci"),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 				},
 				Signers: defaultSigners,
@@ -161,16 +266,17 @@ func TestParse(t *testing.T) {
 				Builder: BuilderConfig{
 					"https://tekton.dev/chains/v2",
 				},
+				SignOn: SignOnAlways,
 				Artifacts: ArtifactConfigs{
 					TaskRuns: Artifact{
 						Format:         "tekton",
 						StorageBackend: sets.NewString(""),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 					OCI: Artifact{
 						Format:         "simplesigning",
 						StorageBackend: sets.NewString("oci"),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 				},
 				Signers: defaultSigners,
@@ -188,16 +294,17 @@ func TestParse(t *testing.T) {
 				Builder: BuilderConfig{
 					"https://tekton.dev/chains/v2",
 				},
+				SignOn: SignOnAlways,
 				Artifacts: ArtifactConfigs{
 					TaskRuns: Artifact{
 						Format:         "tekton",
 						StorageBackend: sets.NewString("tekton"),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 					OCI: Artifact{
 						Format:         "simplesigning",
 						StorageBackend: sets.NewString("oci", "tekton"),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 				},
 				Signers: defaultSigners,
@@ -215,16 +322,17 @@ func TestParse(t *testing.T) {
 				Builder: BuilderConfig{
 					"https://tekton.dev/chains/v2",
 				},
+				SignOn: SignOnAlways,
 				Artifacts: ArtifactConfigs{
 					TaskRuns: Artifact{
 						Format:         "tekton",
 						StorageBackend: sets.NewString("tekton"),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 					OCI: Artifact{
 						Format:         "simplesigning",
 						StorageBackend: sets.NewString(""),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 				},
 				Signers: defaultSigners,
@@ -242,16 +350,17 @@ func TestParse(t *testing.T) {
 				Builder: BuilderConfig{
 					"https://tekton.dev/chains/v2",
 				},
+				SignOn: SignOnAlways,
 				Artifacts: ArtifactConfigs{
 					TaskRuns: Artifact{
 						Format:         "tekton",
 						StorageBackend: sets.NewString("tekton", "oci"),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 					OCI: Artifact{
 						Format:         "simplesigning",
 						StorageBackend: sets.NewString(""),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 				},
 				Signers: defaultSigners,
@@ -269,16 +378,17 @@ func TestParse(t *testing.T) {
 				Builder: BuilderConfig{
 					"https://tekton.dev/chains/v2",
 				},
+				SignOn: SignOnAlways,
 				Artifacts: ArtifactConfigs{
 					TaskRuns: Artifact{
 						Format:         "tekton",
 						StorageBackend: sets.NewString(""),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 					OCI: Artifact{
 						Format:         "simplesigning",
 						StorageBackend: sets.NewString("oci", "tekton"),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 				},
 				Signers: defaultSigners,
@@ -296,16 +406,17 @@ func TestParse(t *testing.T) {
 				Builder: BuilderConfig{
 					"https://tekton.dev/chains/v2",
 				},
+				SignOn: SignOnAlways,
 				Artifacts: ArtifactConfigs{
 					TaskRuns: Artifact{
 						Format:         "tekton",
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 						StorageBackend: sets.NewString("tekton"),
 					},
 					OCI: Artifact{
 						Format:         "simplesigning",
 						StorageBackend: sets.NewString("oci"),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 				},
 				Signers: defaultSigners,
@@ -323,16 +434,17 @@ func TestParse(t *testing.T) {
 				Builder: BuilderConfig{
 					"https://tekton.dev/chains/v2",
 				},
+				SignOn: SignOnAlways,
 				Artifacts: ArtifactConfigs{
 					TaskRuns: Artifact{
 						Format:         "tekton",
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 						StorageBackend: sets.NewString("tekton"),
 					},
 					OCI: Artifact{
 						Format:         "simplesigning",
 						StorageBackend: sets.NewString("oci"),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 				},
 				Signers: defaultSigners,
@@ -352,16 +464,17 @@ func TestParse(t *testing.T) {
 				Builder: BuilderConfig{
 					"https://tekton.dev/chains/v2",
 				},
+				SignOn: SignOnAlways,
 				Artifacts: ArtifactConfigs{
 					TaskRuns: Artifact{
 						Format:         "tekton",
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 						StorageBackend: sets.NewString("tekton"),
 					},
 					OCI: Artifact{
 						Format:         "simplesigning",
 						StorageBackend: sets.NewString("oci"),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 				},
 				Signers: defaultSigners,
@@ -382,22 +495,381 @@ func TestParse(t *testing.T) {
 				Builder: BuilderConfig{
 					"https://tekton.dev/chains/v2",
 				},
+				SignOn: SignOnAlways,
+				Artifacts: ArtifactConfigs{
+					TaskRuns: Artifact{
+						Format:         "tekton",
+						Signers:        []string{"x509"},
+						StorageBackend: sets.NewString("tekton"),
+					},
+					OCI: Artifact{
+						Format:         "simplesigning",
+						StorageBackend: sets.NewString("oci"),
+						Signers:        []string{"x509"},
+					},
+				},
+				Signers: SignerConfigs{
+					X509: X509Signer{
+						FulcioEnabled:  true,
+						FulcioAddr:     "fulcio-address",
+						FulcioIdentity: "controller",
+						CAValidity:     10 * time.Minute,
+					},
+				},
+				Transparency: TransparencyConfig{
+					URL: "https://rekor.sigstore.dev",
+				},
+			},
+		}, {
+			name: "fulcio taskrun identity",
+			data: map[string]string{
+				taskrunSignerKey:               "x509",
+				"signers.x509.fulcio.enabled":  "true",
+				"signers.x509.fulcio.address":  "fulcio-address",
+				"signers.x509.fulcio.identity": "taskrun",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: BuilderConfig{
+					"https://tekton.dev/chains/v2",
+				},
+				SignOn: SignOnAlways,
+				Artifacts: ArtifactConfigs{
+					TaskRuns: Artifact{
+						Format:         "tekton",
+						Signers:        []string{"x509"},
+						StorageBackend: sets.NewString("tekton"),
+					},
+					OCI: Artifact{
+						Format:         "simplesigning",
+						StorageBackend: sets.NewString("oci"),
+						Signers:        []string{"x509"},
+					},
+				},
+				Signers: SignerConfigs{
+					X509: X509Signer{
+						FulcioEnabled:  true,
+						FulcioAddr:     "fulcio-address",
+						FulcioIdentity: "taskrun",
+						CAValidity:     10 * time.Minute,
+					},
+				},
+				Transparency: TransparencyConfig{
+					URL: "https://rekor.sigstore.dev",
+				},
+			},
+		}, {
+			name: "local ca",
+			data: map[string]string{
+				"signers.x509.ca.enabled":  "true",
+				"signers.x509.ca.kmsref":   "gcpkms://ca",
+				"signers.x509.ca.validity": "5m",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: BuilderConfig{
+					"https://tekton.dev/chains/v2",
+				},
+				SignOn: SignOnAlways,
+				Artifacts: ArtifactConfigs{
+					TaskRuns: Artifact{
+						Format:         "tekton",
+						Signers:        []string{"x509"},
+						StorageBackend: sets.NewString("tekton"),
+					},
+					OCI: Artifact{
+						Format:         "simplesigning",
+						StorageBackend: sets.NewString("oci"),
+						Signers:        []string{"x509"},
+					},
+				},
+				Signers: SignerConfigs{
+					X509: X509Signer{
+						FulcioAddr:     "https://v1.fulcio.sigstore.dev",
+						FulcioIdentity: "controller",
+						CAEnabled:      true,
+						CAKMSRef:       "gcpkms://ca",
+						CAValidity:     5 * time.Minute,
+					},
+				},
+				Transparency: TransparencyConfig{
+					URL: "https://rekor.sigstore.dev",
+				},
+			},
+		}, {
+			name: "x509 rsa pss",
+			data: map[string]string{
+				"signers.x509.rsa.scheme": "pss",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: BuilderConfig{
+					"https://tekton.dev/chains/v2",
+				},
+				SignOn: SignOnAlways,
+				Artifacts: ArtifactConfigs{
+					TaskRuns: Artifact{
+						Format:         "tekton",
+						Signers:        []string{"x509"},
+						StorageBackend: sets.NewString("tekton"),
+					},
+					OCI: Artifact{
+						Format:         "simplesigning",
+						StorageBackend: sets.NewString("oci"),
+						Signers:        []string{"x509"},
+					},
+				},
+				Signers: SignerConfigs{
+					X509: X509Signer{
+						RSAScheme:      "pss",
+						FulcioAddr:     "https://v1.fulcio.sigstore.dev",
+						FulcioIdentity: "controller",
+						CAValidity:     10 * time.Minute,
+					},
+				},
+				Transparency: TransparencyConfig{
+					URL: "https://rekor.sigstore.dev",
+				},
+			},
+		}, {
+			name: "namespace secret",
+			data: map[string]string{
+				"signers.namespace-secret": "team-signing-secrets",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: BuilderConfig{
+					"https://tekton.dev/chains/v2",
+				},
+				SignOn: SignOnAlways,
+				Artifacts: ArtifactConfigs{
+					TaskRuns: Artifact{
+						Format:         "tekton",
+						Signers:        []string{"x509"},
+						StorageBackend: sets.NewString("tekton"),
+					},
+					OCI: Artifact{
+						Format:         "simplesigning",
+						StorageBackend: sets.NewString("oci"),
+						Signers:        []string{"x509"},
+					},
+				},
+				Signers: SignerConfigs{
+					NamespaceSecret: "team-signing-secrets",
+					X509: X509Signer{
+						FulcioAddr:     "https://v1.fulcio.sigstore.dev",
+						FulcioIdentity: "controller",
+						CAValidity:     10 * time.Minute,
+					},
+				},
+				Transparency: TransparencyConfig{
+					URL: "https://rekor.sigstore.dev",
+				},
+			},
+		}, {
+			name: "x509 key rotation",
+			data: map[string]string{
+				"signers.x509.keys.active":       "2022-06",
+				"signers.x509.keys.retired":      "2022-01=2022-06-01T00:00:00Z, 2021_07=2022-01-01T12:00:00+01:00",
+				"signers.x509.keys.grace-period": "72h",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: BuilderConfig{
+					"https://tekton.dev/chains/v2",
+				},
+				SignOn: SignOnAlways,
 				Artifacts: ArtifactConfigs{
 					TaskRuns: Artifact{
 						Format:         "tekton",
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 						StorageBackend: sets.NewString("tekton"),
 					},
 					OCI: Artifact{
 						Format:         "simplesigning",
 						StorageBackend: sets.NewString("oci"),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 				},
 				Signers: SignerConfigs{
 					X509: X509Signer{
-						FulcioEnabled: true,
-						FulcioAddr:    "fulcio-address",
+						ActiveKey: "2022-06",
+						RetiredKeys: map[string]time.Time{
+							"2022-01": time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC),
+							"2021_07": time.Date(2022, 1, 1, 11, 0, 0, 0, time.UTC),
+						},
+						GracePeriod:    72 * time.Hour,
+						FulcioAddr:     "https://v1.fulcio.sigstore.dev",
+						FulcioIdentity: "controller",
+						CAValidity:     10 * time.Minute,
+					},
+				},
+				Transparency: TransparencyConfig{
+					URL: "https://rekor.sigstore.dev",
+				},
+			},
+		}, {
+			name: "x509 key generation",
+			data: map[string]string{
+				"signers.x509.keys.generate": "true",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: BuilderConfig{
+					"https://tekton.dev/chains/v2",
+				},
+				SignOn: SignOnAlways,
+				Artifacts: ArtifactConfigs{
+					TaskRuns: Artifact{
+						Format:         "tekton",
+						Signers:        []string{"x509"},
+						StorageBackend: sets.NewString("tekton"),
+					},
+					OCI: Artifact{
+						Format:         "simplesigning",
+						StorageBackend: sets.NewString("oci"),
+						Signers:        []string{"x509"},
+					},
+				},
+				Signers: SignerConfigs{
+					X509: X509Signer{
+						GenerateKey:    true,
+						FulcioAddr:     "https://v1.fulcio.sigstore.dev",
+						FulcioIdentity: "controller",
+						CAValidity:     10 * time.Minute,
+					},
+				},
+				Transparency: TransparencyConfig{
+					URL: "https://rekor.sigstore.dev",
+				},
+			},
+		}, {
+			name: "multiple signers",
+			data: map[string]string{
+				taskrunSignerKey: "x509, kms,x509",
+				ociSignerKey:     "kms,pkcs11",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: BuilderConfig{
+					"https://tekton.dev/chains/v2",
+				},
+				SignOn: SignOnAlways,
+				Artifacts: ArtifactConfigs{
+					TaskRuns: Artifact{
+						Format:         "tekton",
+						Signers:        []string{"x509", "kms"},
+						StorageBackend: sets.NewString("tekton"),
+					},
+					OCI: Artifact{
+						Format:         "simplesigning",
+						StorageBackend: sets.NewString("oci"),
+						Signers:        []string{"kms", "pkcs11"},
+					},
+				},
+				Signers: defaultSigners,
+				Transparency: TransparencyConfig{
+					URL: "https://rekor.sigstore.dev",
+				},
+			},
+		}, {
+			name: "pkcs11",
+			data: map[string]string{
+				taskrunSignerKey:             "pkcs11",
+				"signers.pkcs11.module":      "/usr/lib/softhsm/libsofthsm2.so",
+				"signers.pkcs11.slot":        "2",
+				"signers.pkcs11.key-label":   "release",
+				"signers.pkcs11.key-id":      "0a0b",
+				"signers.pkcs11.rsa.scheme":  "pss",
+				"signers.pkcs11.token-label": "chains",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: BuilderConfig{
+					"https://tekton.dev/chains/v2",
+				},
+				SignOn: SignOnAlways,
+				Artifacts: ArtifactConfigs{
+					TaskRuns: Artifact{
+						Format:         "tekton",
+						Signers:        []string{"pkcs11"},
+						StorageBackend: sets.NewString("tekton"),
+					},
+					OCI: Artifact{
+						Format:         "simplesigning",
+						StorageBackend: sets.NewString("oci"),
+						Signers:        []string{"x509"},
+					},
+				},
+				Signers: SignerConfigs{
+					X509: X509Signer{
+						FulcioAddr:     "https://v1.fulcio.sigstore.dev",
+						FulcioIdentity: "controller",
+						CAValidity:     10 * time.Minute,
+					},
+					PKCS11: PKCS11Signer{
+						ModulePath: "/usr/lib/softhsm/libsofthsm2.so",
+						TokenLabel: "chains",
+						Slot:       intPtr(2),
+						KeyLabel:   "release",
+						KeyID:      "0a0b",
+						RSAScheme:  "pss",
+					},
+				},
+				Transparency: TransparencyConfig{
+					URL: "https://rekor.sigstore.dev",
+				},
+			},
+		}, {
+			name: "remote",
+			data: map[string]string{
+				taskrunSignerKey:               "remote",
+				ociSignerKey:                   "remote",
+				"signers.remote.address":       "tls://signer.chains-signer.svc:8443",
+				"signers.remote.tls.ca-file":   "/etc/remote-signer/ca.crt",
+				"signers.remote.tls.cert-file": "/etc/remote-signer/tls.crt",
+				"signers.remote.tls.key-file":  "/etc/remote-signer/tls.key",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: BuilderConfig{
+					"https://tekton.dev/chains/v2",
+				},
+				SignOn: SignOnAlways,
+				Artifacts: ArtifactConfigs{
+					TaskRuns: Artifact{
+						Format:         "tekton",
+						Signers:        []string{"remote"},
+						StorageBackend: sets.NewString("tekton"),
+					},
+					OCI: Artifact{
+						Format:         "simplesigning",
+						StorageBackend: sets.NewString("oci"),
+						Signers:        []string{"remote"},
+					},
+				},
+				Signers: SignerConfigs{
+					X509: X509Signer{
+						FulcioAddr:     "https://v1.fulcio.sigstore.dev",
+						FulcioIdentity: "controller",
+						CAValidity:     10 * time.Minute,
+					},
+					Remote: RemoteSigner{
+						Address: "tls://signer.chains-signer.svc:8443",
+						Client: ClientConfig{
+							CAPath:   "/etc/remote-signer/ca.crt",
+							CertPath: "/etc/remote-signer/tls.crt",
+							KeyPath:  "/etc/remote-signer/tls.key",
+						},
 					},
 				},
 				Transparency: TransparencyConfig{
@@ -415,21 +887,24 @@ func TestParse(t *testing.T) {
 				Builder: BuilderConfig{
 					"https://tekton.dev/chains/v2",
 				},
+				SignOn: SignOnAlways,
 				Artifacts: ArtifactConfigs{
 					TaskRuns: Artifact{
 						Format:         "tekton",
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 						StorageBackend: sets.NewString("tekton"),
 					},
 					OCI: Artifact{
 						Format:         "simplesigning",
 						StorageBackend: sets.NewString("oci"),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 				},
 				Signers: SignerConfigs{
 					X509: X509Signer{
-						FulcioAddr: "https://v1.fulcio.sigstore.dev",
+						FulcioAddr:     "https://v1.fulcio.sigstore.dev",
+						FulcioIdentity: "controller",
+						CAValidity:     10 * time.Minute,
 					},
 				},
 				Transparency: TransparencyConfig{
@@ -448,21 +923,24 @@ func TestParse(t *testing.T) {
 				Builder: BuilderConfig{
 					"https://tekton.dev/chains/v2",
 				},
+				SignOn: SignOnAlways,
 				Artifacts: ArtifactConfigs{
 					TaskRuns: Artifact{
 						Format:         "tekton",
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 						StorageBackend: sets.NewString("tekton"),
 					},
 					OCI: Artifact{
 						Format:         "simplesigning",
 						StorageBackend: sets.NewString("oci"),
-						Signer:         "x509",
+						Signers:        []string{"x509"},
 					},
 				},
 				Signers: SignerConfigs{
 					X509: X509Signer{
-						FulcioAddr: "https://v1.fulcio.sigstore.dev",
+						FulcioAddr:     "https://v1.fulcio.sigstore.dev",
+						FulcioIdentity: "controller",
+						CAValidity:     10 * time.Minute,
 					},
 				},
 				Transparency: TransparencyConfig{
@@ -472,6 +950,170 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "artifact transparency overrides",
+			data: map[string]string{
+				"transparency.enabled":               "true",
+				"artifacts.taskrun.transparency":     "false",
+				"artifacts.oci.transparency.url":     "https://rekor.example.com",
+				"artifacts.taskrun.transparency.url": "https://rekor.internal",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: BuilderConfig{
+					"https://tekton.dev/chains/v2",
+				},
+				SignOn: SignOnAlways,
+				Artifacts: ArtifactConfigs{
+					TaskRuns: Artifact{
+						Format:         "tekton",
+						Signers:        []string{"x509"},
+						StorageBackend: sets.NewString("tekton"),
+						Transparency: &TransparencyConfig{
+							URL: "https://rekor.internal",
+						},
+					},
+					OCI: Artifact{
+						Format:         "simplesigning",
+						StorageBackend: sets.NewString("oci"),
+						Signers:        []string{"x509"},
+						Transparency: &TransparencyConfig{
+							Enabled: true,
+							URL:     "https://rekor.example.com",
+						},
+					},
+				},
+				Signers: defaultSigners,
+				Transparency: TransparencyConfig{
+					Enabled: true,
+					URL:     "https://rekor.sigstore.dev",
+				},
+			},
+		}, {
+			name: "artifact transparency manual",
+			data: map[string]string{
+				"artifacts.oci.transparency": "manual",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: BuilderConfig{
+					"https://tekton.dev/chains/v2",
+				},
+				SignOn: SignOnAlways,
+				Artifacts: ArtifactConfigs{
+					TaskRuns: Artifact{
+						Format:         "tekton",
+						Signers:        []string{"x509"},
+						StorageBackend: sets.NewString("tekton"),
+					},
+					OCI: Artifact{
+						Format:         "simplesigning",
+						StorageBackend: sets.NewString("oci"),
+						Signers:        []string{"x509"},
+						Transparency: &TransparencyConfig{
+							Enabled:          true,
+							VerifyAnnotation: true,
+							URL:              "https://rekor.sigstore.dev",
+						},
+					},
+				},
+				Signers: defaultSigners,
+				Transparency: TransparencyConfig{
+					URL: "https://rekor.sigstore.dev",
+				},
+			},
+		},
+		{
+			name: "tls and auth for rekor and fulcio",
+			data: map[string]string{
+				"transparency.tls.ca-file":            "/etc/rekor-tls/ca.pem",
+				"transparency.tls.cert-file":          "/etc/rekor-tls/tls.crt",
+				"transparency.tls.key-file":           "/etc/rekor-tls/tls.key",
+				"transparency.auth.token-file":        "/etc/rekor-auth/token",
+				"signers.x509.fulcio.tls.ca-file":     "/etc/fulcio-tls/ca.pem",
+				"signers.x509.fulcio.auth.token-file": "/etc/fulcio-auth/token",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: BuilderConfig{
+					"https://tekton.dev/chains/v2",
+				},
+				SignOn: SignOnAlways,
+				Artifacts: ArtifactConfigs{
+					TaskRuns: Artifact{
+						Format:         "tekton",
+						Signers:        []string{"x509"},
+						StorageBackend: sets.NewString("tekton"),
+					},
+					OCI: Artifact{
+						Format:         "simplesigning",
+						StorageBackend: sets.NewString("oci"),
+						Signers:        []string{"x509"},
+					},
+				},
+				Signers: SignerConfigs{
+					X509: X509Signer{
+						FulcioAddr:     "https://v1.fulcio.sigstore.dev",
+						FulcioIdentity: "controller",
+						FulcioClient: ClientConfig{
+							CAPath:    "/etc/fulcio-tls/ca.pem",
+							TokenPath: "/etc/fulcio-auth/token",
+						},
+						CAValidity: 10 * time.Minute,
+					},
+				},
+				Transparency: TransparencyConfig{
+					URL: "https://rekor.sigstore.dev",
+					Client: ClientConfig{
+						CAPath:    "/etc/rekor-tls/ca.pem",
+						CertPath:  "/etc/rekor-tls/tls.crt",
+						KeyPath:   "/etc/rekor-tls/tls.key",
+						TokenPath: "/etc/rekor-auth/token",
+					},
+				},
+			},
+		}, {
+			name: "timestamp authority",
+			data: map[string]string{
+				"timestamp.url":             "https://tsa.example.com",
+				"timestamp.tls.ca-file":     "/etc/tsa-tls/ca.pem",
+				"timestamp.auth.token-file": "/etc/tsa-auth/token",
+			},
+			taskrunEnabled: true,
+			ociEnbaled:     true,
+			want: Config{
+				Builder: BuilderConfig{
+					"https://tekton.dev/chains/v2",
+				},
+				SignOn: SignOnAlways,
+				Artifacts: ArtifactConfigs{
+					TaskRuns: Artifact{
+						Format:         "tekton",
+						Signers:        []string{"x509"},
+						StorageBackend: sets.NewString("tekton"),
+					},
+					OCI: Artifact{
+						Format:         "simplesigning",
+						StorageBackend: sets.NewString("oci"),
+						Signers:        []string{"x509"},
+					},
+				},
+				Signers: defaultSigners,
+				Transparency: TransparencyConfig{
+					URL: "https://rekor.sigstore.dev",
+				},
+				Timestamp: TimestampConfig{
+					URL: "https://tsa.example.com",
+					Client: ClientConfig{
+						CAPath:    "/etc/tsa-tls/ca.pem",
+						TokenPath: "/etc/tsa-auth/token",
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -491,3 +1133,129 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestParseInvalidNamespaceOverrides(t *testing.T) {
+	for _, keys := range []string{"builder.id,artifacts.taskrun.fromat", "namespace-overrides.allowed-keys", "selection.taskrun-selector",
+		"signers.pkcs11.module", "signers.x509.ca.kmsref", "signers.kms.kmsref", "signers.remote.address",
+		"transparency.url", "artifacts.oci.transparency.url", "timestamp.auth.token-file", "signers.x509.fulcio.tls.key-file"} {
+		if _, err := NewConfigFromMap(map[string]string{"namespace-overrides.allowed-keys": keys}); err == nil {
+			t.Errorf("NewConfigFromMap() expected error for allowed keys %q", keys)
+		}
+	}
+}
+
+func TestParseSelection(t *testing.T) {
+	cfg, err := NewConfigFromMap(map[string]string{
+		"selection.namespace-selector": "team in (a, b),!legacy",
+		"selection.taskrun-selector":   "",
+	})
+	if err != nil {
+		t.Fatalf("NewConfigFromMap() = %v", err)
+	}
+	if got, want := cfg.Selection.Namespaces.String(), "!legacy,team in (a,b)"; got != want {
+		t.Errorf("namespace selector = %q, want %q", got, want)
+	}
+	if cfg.Selection.TaskRuns != nil {
+		t.Errorf("taskrun selector = %q, want none", cfg.Selection.TaskRuns)
+	}
+	if _, err := NewConfigFromMap(map[string]string{"selection.taskrun-selector": "app in web"}); err == nil {
+		t.Error("NewConfigFromMap() expected error for an invalid selector")
+	}
+}
+
+func TestParseSignOn(t *testing.T) {
+	cfg, err := NewConfigFromMap(map[string]string{"sign-on": "success"})
+	if err != nil {
+		t.Fatalf("NewConfigFromMap() = %v", err)
+	}
+	if cfg.SignOn != SignOnSuccess {
+		t.Errorf("sign-on = %q, want %q", cfg.SignOn, SignOnSuccess)
+	}
+	if _, err := NewConfigFromMap(map[string]string{"sign-on": "failure"}); err == nil {
+		t.Error("NewConfigFromMap() expected error for an invalid sign-on policy")
+	}
+}
+
+func TestParseInvalidArtifactTransparency(t *testing.T) {
+	if _, err := NewConfigFromMap(map[string]string{"artifacts.oci.transparency": "sometimes"}); err == nil {
+		t.Error("NewConfigFromMap() expected error for invalid artifact transparency value")
+	}
+}
+
+func TestParseInvalidFulcioIdentity(t *testing.T) {
+	if _, err := NewConfigFromMap(map[string]string{"signers.x509.fulcio.identity": "pod"}); err == nil {
+		t.Error("NewConfigFromMap() expected error for invalid Fulcio identity")
+	}
+}
+
+func TestParseInvalidRSAScheme(t *testing.T) {
+	if _, err := NewConfigFromMap(map[string]string{"signers.x509.rsa.scheme": "pkcs1"}); err == nil {
+		t.Error("NewConfigFromMap() expected error for invalid RSA scheme")
+	}
+}
+
+func TestParseInvalidPKCS11Slot(t *testing.T) {
+	if _, err := NewConfigFromMap(map[string]string{"signers.pkcs11.slot": "first"}); err == nil {
+		t.Error("NewConfigFromMap() expected error for invalid PKCS#11 slot")
+	}
+}
+
+func TestParseInvalidSigners(t *testing.T) {
+	for _, signers := range []string{"", " , ", "x509,gpg", "kms:old", "x509:", "x509:a.b"} {
+		if _, err := NewConfigFromMap(map[string]string{taskrunSignerKey: signers}); err == nil {
+			t.Errorf("NewConfigFromMap() expected error for signers %q", signers)
+		}
+	}
+}
+
+func TestParseNamedSigners(t *testing.T) {
+	cfg, err := NewConfigFromMap(map[string]string{taskrunSignerKey: "x509:new, x509:old, x509:new"})
+	if err != nil {
+		t.Fatalf("NewConfigFromMap() = %v", err)
+	}
+	if diff := cmp.Diff([]string{"x509:new", "x509:old"}, cfg.Artifacts.TaskRuns.Signers); diff != "" {
+		t.Errorf("signers (-want +got): %s", diff)
+	}
+	if signerType, keyID := ParseSigner("x509:old"); signerType != "x509" || keyID != "old" {
+		t.Errorf("ParseSigner() = %q, %q, want x509, old", signerType, keyID)
+	}
+
+	// Retired keys don't sign anymore.
+	if _, err := NewConfigFromMap(map[string]string{
+		taskrunSignerKey:      "x509:new,x509:old",
+		x509SignerKeysRetired: "old=2022-03-01T00:00:00Z",
+	}); err == nil {
+		t.Error("NewConfigFromMap() expected error for a retired key that signs")
+	}
+}
+
+func TestParseInvalidCA(t *testing.T) {
+	for _, data := range []map[string]string{
+		{"signers.x509.ca.enabled": "true", "signers.x509.fulcio.enabled": "true"},
+		{"signers.x509.ca.enabled": "true", "signers.x509.ca.validity": "0s"},
+		{"signers.x509.ca.validity": "10"},
+	} {
+		if _, err := NewConfigFromMap(data); err == nil {
+			t.Errorf("NewConfigFromMap() expected error for %v", data)
+		}
+	}
+}
+
+func TestParseInvalidKeyRotation(t *testing.T) {
+	for _, data := range []map[string]string{
+		{"signers.x509.keys.active": "../x509"},
+		{"signers.x509.keys.retired": "2022-01"},
+		{"signers.x509.keys.retired": "2022-01=yesterday"},
+		{"signers.x509.keys.grace-period": "a week"},
+		{"signers.x509.keys.grace-period": "-1h"},
+		{"signers.x509.keys.active": "2022-01", "signers.x509.keys.retired": "2022-01=2022-06-01T00:00:00Z"},
+	} {
+		if _, err := NewConfigFromMap(data); err == nil {
+			t.Errorf("NewConfigFromMap() expected error for %v", data)
+		}
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/go-multierror"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	cm "knative.dev/pkg/configmap"
+)
+
+// knownKeys are all the keys of the chains configuration.
+var knownKeys = sets.NewString(
+	taskrunFormatKey, taskrunStorageKey, taskrunSignerKey, taskrunTransparencyKey, taskrunTransparencyURLKey,
+	ociFormatKey, ociStorageKey, ociSignerKey, ociTransparencyKey, ociTransparencyURLKey,
+	gcsBucketKey, ociRepositoryKey, ociRepositoryInsecureKey, docDBUrlKey,
+	signerNamespaceSecret,
+	x509SignerRSAScheme, x509SignerKeysActive, x509SignerKeysRetired, x509SignerKeysGracePeriod, x509SignerKeysGenerate,
+	kmsSignerKMSRef,
+	pkcs11SignerModule, pkcs11SignerTokenLabel, pkcs11SignerSlot, pkcs11SignerKeyLabel, pkcs11SignerKeyID, pkcs11SignerRSAScheme,
+	remoteSignerAddress, remoteSignerCA, remoteSignerCert, remoteSignerKey,
+	x509SignerFulcioEnabled, x509SignerFulcioAddr, x509SignerFulcioID,
+	x509SignerFulcioCA, x509SignerFulcioCert, x509SignerFulcioKey, x509SignerFulcioToken,
+	x509SignerCAEnabled, x509SignerCAKMSRef, x509SignerCAValidity,
+	builderIDKey,
+	transparencyEnabledKey, transparencyURLKey, transparencyCAKey, transparencyCertKey, transparencyKeyKey, transparencyTokenKey,
+	timestampURLKey, timestampCAKey, timestampCertKey, timestampKeyKey, timestampTokenKey,
+	namespaceOverridesAllowedKeys,
+	selectionNamespaceSelector, selectionTaskRunSelector,
+	signOnKey,
+	// The example data of the ConfigMap is ignored.
+	cm.ExampleKey,
+)
+
+// credentialKeys are the keys that select the keys, certificates and tokens of the controller,
+// and the services they are sent to. Namespaces can't override them to sign with the keys of
+// another tenant, read files of the controller or send its credentials elsewhere.
+var credentialKeys = sets.NewString(
+	x509SignerKeysActive, x509SignerKeysRetired, x509SignerKeysGenerate,
+	kmsSignerKMSRef,
+	pkcs11SignerModule, pkcs11SignerTokenLabel, pkcs11SignerSlot, pkcs11SignerKeyLabel, pkcs11SignerKeyID,
+	remoteSignerAddress, remoteSignerCA, remoteSignerCert, remoteSignerKey,
+	x509SignerFulcioAddr, x509SignerFulcioID,
+	x509SignerFulcioCA, x509SignerFulcioCert, x509SignerFulcioKey, x509SignerFulcioToken,
+	x509SignerCAKMSRef,
+	transparencyURLKey, transparencyCAKey, transparencyCertKey, transparencyKeyKey, transparencyTokenKey,
+	taskrunTransparencyURLKey, ociTransparencyURLKey,
+	timestampURLKey, timestampCAKey, timestampCertKey, timestampKeyKey, timestampTokenKey,
+)
+
+// overridableKeys are the keys that namespaces may be allowed to override. The selection of the
+// TaskRuns to sign, and the sign-on policy, apply before the configuration of their namespace is
+// loaded.
+var overridableKeys = knownKeys.Difference(credentialKeys).Difference(sets.NewString(
+	namespaceOverridesAllowedKeys, selectionNamespaceSelector, selectionTaskRunSelector, signOnKey, cm.ExampleKey))
+
+// boolKeys are the keys that NewConfigFromMap parses as booleans, ignoring invalid values.
+var boolKeys = []string{ociRepositoryInsecureKey, x509SignerKeysGenerate, x509SignerFulcioEnabled, x509SignerCAEnabled}
+
+// NewConfigFromMapStrict creates a Config from the supplied map like NewConfigFromMap, but also
+// rejects unknown keys, invalid values that NewConfigFromMap ignores, and combinations of values
+// that can't sign or store anything.
+func NewConfigFromMapStrict(data map[string]string) (*Config, error) {
+	cfg, err := NewConfigFromMap(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := validate(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid data: %w", err)
+	}
+	return cfg, nil
+}
+
+// NewConfigFromConfigMapStrict creates a Config from the supplied ConfigMap, see NewConfigFromMapStrict.
+func NewConfigFromConfigMapStrict(configMap *corev1.ConfigMap) (*Config, error) {
+	return NewConfigFromMapStrict(configMap.Data)
+}
+
+// validate returns all the problems with data, which cfg was parsed from.
+func validate(data map[string]string, cfg *Config) error {
+	var merr *multierror.Error
+	for _, k := range sets.StringKeySet(data).List() {
+		if !knownKeys.Has(k) {
+			merr = multierror.Append(merr, fmt.Errorf("unknown key %q", k))
+		}
+	}
+	for _, k := range boolKeys {
+		if raw, ok := data[k]; ok {
+			if _, err := strconv.ParseBool(raw); err != nil {
+				merr = multierror.Append(merr, fmt.Errorf("invalid value %q for %s, wanted true or false", raw, k))
+			}
+		}
+	}
+	if raw, ok := data[transparencyEnabledKey]; ok && !sets.NewString("true", "false", "manual").Has(raw) {
+		merr = multierror.Append(merr, fmt.Errorf("invalid value %q for %s, wanted one of [false manual true]", raw, transparencyEnabledKey))
+	}
+
+	for _, a := range []struct {
+		name     string
+		artifact Artifact
+	}{
+		{name: "artifacts.taskrun", artifact: cfg.Artifacts.TaskRuns},
+		{name: "artifacts.oci", artifact: cfg.Artifacts.OCI},
+	} {
+		if a.artifact.Enabled() {
+			merr = multierror.Append(merr, validateArtifact(a.name, a.artifact, cfg)...)
+		}
+	}
+	return merr.ErrorOrNil()
+}
+
+// validateArtifact returns the settings the artifact needs that are missing or inconsistent.
+func validateArtifact(name string, a Artifact, cfg *Config) []error {
+	var errs []error
+	storage := a.StorageBackend
+	// The OCI storage only stores simple signing payloads and attestations next to images.
+	if storage.Has("oci") && a.Format == "tekton" {
+		errs = append(errs, fmt.Errorf("%s.storage oci can't store the %s format", name, a.Format))
+	}
+	if storage.Has("gcs") && cfg.Storage.GCS.Bucket == "" {
+		errs = append(errs, fmt.Errorf("%s.storage gcs requires %s", name, gcsBucketKey))
+	}
+	if storage.Has("docdb") && cfg.Storage.DocDB.URL == "" {
+		errs = append(errs, fmt.Errorf("%s.storage docdb requires %s", name, docDBUrlKey))
+	}
+
+	for _, s := range a.Signers {
+		switch {
+		case s == "kms" && cfg.Signers.KMS.KMSRef == "":
+			errs = append(errs, fmt.Errorf("%s.signer kms requires %s", name, kmsSignerKMSRef))
+		case s == "pkcs11" && cfg.Signers.PKCS11.ModulePath == "":
+			errs = append(errs, fmt.Errorf("%s.signer pkcs11 requires %s", name, pkcs11SignerModule))
+		case s == "remote" && cfg.Signers.Remote.Address == "":
+			errs = append(errs, fmt.Errorf("%s.signer remote requires %s", name, remoteSignerAddress))
+		}
+	}
+
+	t := cfg.Transparency
+	if a.Transparency != nil {
+		t = *a.Transparency
+	}
+	if t.Enabled && t.URL == "" {
+		errs = append(errs, fmt.Errorf("%s has the transparency log enabled without a URL", name))
+	}
+	return errs
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseStrict(t *testing.T) {
+	for _, data := range []map[string]string{
+		{},
+		{
+			"artifacts.taskrun.format":  "in-toto",
+			"artifacts.taskrun.storage": "oci,gcs",
+			"artifacts.taskrun.signer":  "kms",
+			"storage.gcs.bucket":        "bucket",
+			"signers.kms.kmsref":        "gcpkms://key",
+			"transparency.enabled":      "manual",
+			"signers.x509.ca.enabled":   "false",
+			"_example":                  "anything",
+		},
+		// Disabled artifacts aren't checked.
+		{"artifacts.oci.storage": "", "artifacts.oci.signer": "pkcs11"},
+	} {
+		if _, err := NewConfigFromMapStrict(data); err != nil {
+			t.Errorf("NewConfigFromMapStrict(%v) = %v", data, err)
+		}
+	}
+}
+
+func TestParseStrictInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string]string
+		want string
+	}{
+		{
+			name: "unknown key",
+			data: map[string]string{"artifacts.taskrun.fromat": "in-toto"},
+			want: `unknown key "artifacts.taskrun.fromat"`,
+		},
+		{
+			name: "invalid boolean",
+			data: map[string]string{"signers.x509.fulcio.enabled": "yes"},
+			want: `invalid value "yes" for signers.x509.fulcio.enabled`,
+		},
+		{
+			name: "invalid transparency",
+			data: map[string]string{"transparency.enabled": "always"},
+			want: `invalid value "always" for transparency.enabled`,
+		},
+		{
+			name: "oci storage with the tekton format",
+			data: map[string]string{"artifacts.taskrun.storage": "oci"},
+			want: "artifacts.taskrun.storage oci can't store the tekton format",
+		},
+		{
+			name: "gcs storage without a bucket",
+			data: map[string]string{"artifacts.oci.storage": "gcs"},
+			want: "artifacts.oci.storage gcs requires storage.gcs.bucket",
+		},
+		{
+			name: "kms signer without a key",
+			data: map[string]string{"artifacts.oci.signer": "x509,kms"},
+			want: "artifacts.oci.signer kms requires signers.kms.kmsref",
+		},
+		{
+			name: "transparency without a URL",
+			data: map[string]string{"artifacts.taskrun.transparency": "true", "transparency.url": ""},
+			want: "artifacts.taskrun has the transparency log enabled without a URL",
+		},
+		{
+			name: "invalid for NewConfigFromMap",
+			data: map[string]string{"artifacts.taskrun.format": "slsa"},
+			want: "failed to parse data",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewConfigFromMap(tt.data); err != nil && tt.want != "failed to parse data" {
+				t.Fatalf("NewConfigFromMap() = %v, expected it to ignore the problem", err)
+			}
+			_, err := NewConfigFromMapStrict(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("NewConfigFromMapStrict() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStrictReportsAllErrors(t *testing.T) {
+	_, err := NewConfigFromMapStrict(map[string]string{
+		"unknown":                  "",
+		"signers.x509.ca.enabled":  "on",
+		"artifacts.taskrun.signer": "remote",
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{`unknown key "unknown"`, "signers.x509.ca.enabled", "signers.remote.address"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't contain %q", err, want)
+		}
+	}
+}
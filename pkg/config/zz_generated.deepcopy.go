@@ -22,6 +22,8 @@ limitations under the License.
 package config
 
 import (
+	time "time"
+
 	sets "k8s.io/apimachinery/pkg/util/sets"
 )
 
@@ -35,6 +37,16 @@ func (in *Artifact) DeepCopyInto(out *Artifact) {
 			(*out)[key] = val
 		}
 	}
+	if in.Signers != nil {
+		in, out := &in.Signers, &out.Signers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Transparency != nil {
+		in, out := &in.Transparency, &out.Transparency
+		*out = new(TransparencyConfig)
+		**out = **in
+	}
 	return
 }
 
@@ -82,14 +94,33 @@ func (in *BuilderConfig) DeepCopy() *BuilderConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientConfig) DeepCopyInto(out *ClientConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientConfig.
+func (in *ClientConfig) DeepCopy() *ClientConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Config) DeepCopyInto(out *Config) {
 	*out = *in
 	in.Artifacts.DeepCopyInto(&out.Artifacts)
 	out.Storage = in.Storage
-	out.Signers = in.Signers
+	in.Signers.DeepCopyInto(&out.Signers)
 	out.Builder = in.Builder
 	out.Transparency = in.Transparency
+	out.Timestamp = in.Timestamp
+	in.NamespaceOverrides.DeepCopyInto(&out.NamespaceOverrides)
+	in.Selection.DeepCopyInto(&out.Selection)
 	return
 }
 
@@ -151,6 +182,29 @@ func (in *KMSSigner) DeepCopy() *KMSSigner {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceOverridesConfig) DeepCopyInto(out *NamespaceOverridesConfig) {
+	*out = *in
+	if in.AllowedKeys != nil {
+		in, out := &in.AllowedKeys, &out.AllowedKeys
+		*out = make(sets.String, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceOverridesConfig.
+func (in *NamespaceOverridesConfig) DeepCopy() *NamespaceOverridesConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceOverridesConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OCIStorageConfig) DeepCopyInto(out *OCIStorageConfig) {
 	*out = *in
@@ -167,11 +221,73 @@ func (in *OCIStorageConfig) DeepCopy() *OCIStorageConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PKCS11Signer) DeepCopyInto(out *PKCS11Signer) {
+	*out = *in
+	if in.Slot != nil {
+		in, out := &in.Slot, &out.Slot
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PKCS11Signer.
+func (in *PKCS11Signer) DeepCopy() *PKCS11Signer {
+	if in == nil {
+		return nil
+	}
+	out := new(PKCS11Signer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemoteSigner) DeepCopyInto(out *RemoteSigner) {
+	*out = *in
+	out.Client = in.Client
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteSigner.
+func (in *RemoteSigner) DeepCopy() *RemoteSigner {
+	if in == nil {
+		return nil
+	}
+	out := new(RemoteSigner)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SelectionConfig) DeepCopyInto(out *SelectionConfig) {
+	*out = *in
+	if in.Namespaces != nil {
+		out.Namespaces = in.Namespaces.DeepCopySelector()
+	}
+	if in.TaskRuns != nil {
+		out.TaskRuns = in.TaskRuns.DeepCopySelector()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SelectionConfig.
+func (in *SelectionConfig) DeepCopy() *SelectionConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SelectionConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SignerConfigs) DeepCopyInto(out *SignerConfigs) {
 	*out = *in
-	out.X509 = in.X509
+	in.X509.DeepCopyInto(&out.X509)
 	out.KMS = in.KMS
+	in.PKCS11.DeepCopyInto(&out.PKCS11)
+	out.Remote = in.Remote
 	return
 }
 
@@ -208,6 +324,11 @@ func (in *StorageConfigs) DeepCopy() *StorageConfigs {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageOpts) DeepCopyInto(out *StorageOpts) {
 	*out = *in
+	if in.Timestamp != nil {
+		in, out := &in.Timestamp, &out.Timestamp
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -237,9 +358,27 @@ func (in *TektonStorageConfig) DeepCopy() *TektonStorageConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimestampConfig) DeepCopyInto(out *TimestampConfig) {
+	*out = *in
+	out.Client = in.Client
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimestampConfig.
+func (in *TimestampConfig) DeepCopy() *TimestampConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TimestampConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TransparencyConfig) DeepCopyInto(out *TransparencyConfig) {
 	*out = *in
+	out.Client = in.Client
 	return
 }
 
@@ -256,6 +395,14 @@ func (in *TransparencyConfig) DeepCopy() *TransparencyConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *X509Signer) DeepCopyInto(out *X509Signer) {
 	*out = *in
+	if in.RetiredKeys != nil {
+		in, out := &in.RetiredKeys, &out.RetiredKeys
+		*out = make(map[string]time.Time, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.FulcioClient = in.FulcioClient
 	return
 }
 
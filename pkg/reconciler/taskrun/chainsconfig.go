@@ -0,0 +1,170 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskrun
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	"github.com/tektoncd/chains/pkg/config"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection/clients/dynamicclient"
+	"knative.dev/pkg/system"
+)
+
+var chainsConfigResource = v1alpha1.SchemeGroupVersion.WithResource("chainsconfigs")
+
+// chainsConfigSyncTimeout bounds how long the controller waits for the ChainsConfigs to be listed
+// before it starts with the chains-config ConfigMap.
+const chainsConfigSyncTimeout = 30 * time.Second
+
+// chainsConfigReconciler applies the ChainsConfig named chains-config to the ConfigStore, and
+// reports on all the ChainsConfigs whether they are applied.
+type chainsConfigReconciler struct {
+	ctx      context.Context
+	client   dynamic.ResourceInterface
+	cfgStore *config.ConfigStore
+	logger   *zap.SugaredLogger
+}
+
+// watchChainsConfigs starts watching the ChainsConfigs of the system namespace, and returns once
+// the one that replaces the chains-config ConfigMap, if any, is applied. Only the ConfigMap is
+// used when the ChainsConfig CRD isn't installed.
+func watchChainsConfigs(ctx context.Context, cfgStore *config.ConfigStore, logger *zap.SugaredLogger) {
+	if err := chainsConfigsServed(kubeclient.Get(ctx).Discovery()); err != nil {
+		logger.Infof("Not watching ChainsConfigs, using the %s ConfigMap: %v", config.ChainsConfig, err)
+		return
+	}
+	client := dynamicclient.Get(ctx)
+	r := &chainsConfigReconciler{
+		ctx:      ctx,
+		client:   client.Resource(chainsConfigResource).Namespace(system.Namespace()),
+		cfgStore: cfgStore,
+		logger:   logger,
+	}
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(client, controller.GetResyncPeriod(ctx), system.Namespace(), nil)
+	informer := factory.ForResource(chainsConfigResource)
+	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    r.reconcile,
+		UpdateFunc: func(_, obj interface{}) { r.reconcile(obj) },
+		DeleteFunc: r.delete,
+	})
+	factory.Start(ctx.Done())
+	syncCtx, cancel := context.WithTimeout(ctx, chainsConfigSyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), informer.Informer().HasSynced) {
+		// The handlers apply the ChainsConfig once it's listed.
+		logger.Warnf("Timed out listing ChainsConfigs, using the %s ConfigMap until then", config.ChainsConfig)
+		return
+	}
+
+	// The handlers may not have run yet, apply the configuration before any TaskRun is signed.
+	obj, err := informer.Lister().ByNamespace(system.Namespace()).Get(v1alpha1.ChainsConfigName)
+	if err != nil {
+		return
+	}
+	if cc, err := fromUnstructured(obj); err == nil {
+		if data, err := config.DataFromResource(ctx, cc); err == nil {
+			cfgStore.SetResourceData(data)
+		}
+	}
+}
+
+// chainsConfigsServed returns an error if the API server doesn't serve the ChainsConfigs.
+func chainsConfigsServed(client discovery.DiscoveryInterface) error {
+	resources, err := client.ServerResourcesForGroupVersion(chainsConfigResource.GroupVersion().String())
+	if err != nil {
+		return err
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == chainsConfigResource.Resource {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s isn't served by the API server", chainsConfigResource.GroupResource())
+}
+
+func (r *chainsConfigReconciler) reconcile(obj interface{}) {
+	cc, err := fromUnstructured(obj)
+	if err != nil {
+		r.logger.Errorf("Error reading ChainsConfig: %v", err)
+		return
+	}
+
+	status := cc.Status.DeepCopy()
+	status.InitializeConditions()
+	status.ObservedGeneration = cc.Generation
+	if cc.Name != v1alpha1.ChainsConfigName {
+		status.MarkNotApplied(v1alpha1.ReasonIgnored, "Only the ChainsConfig named %s is used", v1alpha1.ChainsConfigName)
+	} else if data, err := config.DataFromResource(r.ctx, cc); err != nil {
+		r.logger.Errorf("Error applying ChainsConfig %s: %v", cc.Name, err)
+		status.MarkNotApplied(v1alpha1.ReasonInvalid, "The previous configuration is still used: %v", err)
+	} else {
+		r.cfgStore.SetResourceData(data)
+		status.MarkApplied()
+	}
+	if equality.Semantic.DeepEqual(status, &cc.Status) {
+		return
+	}
+
+	cc.Status = *status
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cc)
+	if err != nil {
+		r.logger.Errorf("Error converting ChainsConfig %s: %v", cc.Name, err)
+		return
+	}
+	if _, err := r.client.UpdateStatus(r.ctx, &unstructured.Unstructured{Object: u}, metav1.UpdateOptions{}); err != nil {
+		// The update of a newer version triggers another reconcile.
+		r.logger.Errorf("Error updating the status of ChainsConfig %s: %v", cc.Name, err)
+	}
+}
+
+func (r *chainsConfigReconciler) delete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	if m.GetName() == v1alpha1.ChainsConfigName {
+		// Fall back to the chains-config ConfigMap.
+		r.cfgStore.SetResourceData(nil)
+	}
+}
+
+func fromUnstructured(obj interface{}) (*v1alpha1.ChainsConfig, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %T", obj)
+	}
+	cc := &v1alpha1.ChainsConfig{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, cc); err != nil {
+		return nil, fmt.Errorf("converting %s: %w", u.GetName(), err)
+	}
+	return cc, nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskrun
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/chains/pkg/apis/chains/v1alpha1"
+	"github.com/tektoncd/chains/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic/fake"
+	k8sscheme "k8s.io/client-go/kubernetes/scheme"
+	"knative.dev/pkg/apis"
+	fakekubeclient "knative.dev/pkg/client/injection/kube/client/fake"
+	"knative.dev/pkg/configmap"
+	fakedynamicclient "knative.dev/pkg/injection/clients/dynamicclient/fake"
+	logtesting "knative.dev/pkg/logging/testing"
+	rtesting "knative.dev/pkg/reconciler/testing"
+	"knative.dev/pkg/system"
+)
+
+// withChainsConfigs adds a fake dynamic client that knows the ChainsConfigs to ctx, and makes the
+// fake discovery serve them.
+func withChainsConfigs(ctx context.Context, t *testing.T, objs ...runtime.Object) (context.Context, *fake.FakeDynamicClient) {
+	t.Helper()
+	fakekubeclient.Get(ctx).Resources = []*metav1.APIResourceList{{
+		GroupVersion: v1alpha1.SchemeGroupVersion.String(),
+		APIResources: []metav1.APIResource{{Name: chainsConfigResource.Resource, Namespaced: true, Kind: "ChainsConfig"}},
+	}}
+	scheme := runtime.NewScheme()
+	if err := k8sscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return fakedynamicclient.With(ctx, scheme, objs...)
+}
+
+func chainsConfig(name string, spec v1alpha1.ChainsConfigSpec) *v1alpha1.ChainsConfig {
+	return &v1alpha1.ChainsConfig{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: "ChainsConfig"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: system.Namespace()},
+		Spec:       spec,
+	}
+}
+
+// waitForReady waits until the Ready condition of the ChainsConfig has reason.
+func waitForReady(ctx context.Context, t *testing.T, client *fake.FakeDynamicClient, name, reason string) {
+	t.Helper()
+	var last *apis.Condition
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		u, err := client.Resource(chainsConfigResource).Namespace(system.Namespace()).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		cc, err := fromUnstructured(u)
+		if err != nil {
+			return false, err
+		}
+		last = cc.Status.GetCondition(apis.ConditionReady)
+		return last != nil && last.Reason == reason, nil
+	}); err != nil {
+		t.Fatalf("ChainsConfig %s: Ready = %+v, want the reason %s: %v", name, last, reason, err)
+	}
+}
+
+func TestWatchChainsConfigs(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ctx, client := withChainsConfigs(ctx, t,
+		chainsConfig(v1alpha1.ChainsConfigName, v1alpha1.ChainsConfigSpec{
+			Artifacts: v1alpha1.ArtifactsSpec{TaskRuns: v1alpha1.ArtifactSpec{Format: "in-toto"}},
+		}),
+		chainsConfig("other", v1alpha1.ChainsConfigSpec{}),
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cfgStore := config.NewConfigStore(logtesting.TestLogger(t))
+	cfgStore.WatchConfigs(configmap.NewStaticWatcher(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: system.Namespace(), Name: config.ChainsConfig},
+		Data:       map[string]string{"artifacts.taskrun.format": "tekton-provenance"},
+	}))
+	watchChainsConfigs(ctx, cfgStore, logtesting.TestLogger(t))
+
+	// The ChainsConfig replaces the ConfigMap as soon as the watch returns.
+	if got := cfgStore.Load().Artifacts.TaskRuns.Format; got != "in-toto" {
+		t.Errorf("format = %s, want the one of the ChainsConfig", got)
+	}
+	waitForReady(ctx, t, client, v1alpha1.ChainsConfigName, v1alpha1.ReasonApplied)
+	waitForReady(ctx, t, client, "other", v1alpha1.ReasonIgnored)
+
+	// An invalid ChainsConfig is reported, and the previous configuration kept.
+	resources := client.Resource(chainsConfigResource).Namespace(system.Namespace())
+	invalid := chainsConfig(v1alpha1.ChainsConfigName, v1alpha1.ChainsConfigSpec{
+		Artifacts: v1alpha1.ArtifactsSpec{TaskRuns: v1alpha1.ArtifactSpec{Storage: []string{"gcs"}}},
+	})
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(invalid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resources.Update(ctx, &unstructured.Unstructured{Object: u}, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForReady(ctx, t, client, v1alpha1.ChainsConfigName, v1alpha1.ReasonInvalid)
+	if got := cfgStore.Load().Artifacts.TaskRuns.Format; got != "in-toto" {
+		t.Errorf("format = %s, want the previous one", got)
+	}
+
+	// Deleting the ChainsConfig falls back to the ConfigMap.
+	if err := resources.Delete(ctx, v1alpha1.ChainsConfigName, metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return cfgStore.Load().Artifacts.TaskRuns.Format == "tekton-provenance", nil
+	}); err != nil {
+		t.Errorf("format = %s, want the one of the ConfigMap", cfgStore.Load().Artifacts.TaskRuns.Format)
+	}
+}
+
+func TestWatchChainsConfigs_NotInstalled(t *testing.T) {
+	// Without the ChainsConfig CRD, the dynamic informer would never sync.
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cfgStore := config.NewConfigStore(logtesting.TestLogger(t))
+	cfgStore.WatchConfigs(configmap.NewStaticWatcher(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: system.Namespace(), Name: config.ChainsConfig},
+		Data:       map[string]string{"artifacts.taskrun.format": "tekton-provenance"},
+	}))
+	done := make(chan struct{})
+	go func() {
+		watchChainsConfigs(ctx, cfgStore, logtesting.TestLogger(t))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchChainsConfigs() didn't return without the ChainsConfig CRD")
+	}
+	if got := cfgStore.Load().Artifacts.TaskRuns.Format; got != "tekton-provenance" {
+		t.Errorf("format = %s, want the one of the ConfigMap", got)
+	}
+}
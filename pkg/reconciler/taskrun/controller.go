@@ -15,21 +15,62 @@ package taskrun
 
 import (
 	"context"
+	"net/http"
+	"time"
 
 	"github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/chains/keygen"
 	"github.com/tektoncd/chains/pkg/config"
 	pipelineclient "github.com/tektoncd/pipeline/pkg/client/injection/client"
 	taskruninformer "github.com/tektoncd/pipeline/pkg/client/injection/informers/pipeline/v1beta1/taskrun"
 	taskrunreconciler "github.com/tektoncd/pipeline/pkg/client/injection/reconciler/pipeline/v1beta1/taskrun"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection"
 	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
 )
 
+// Options configure the controllers that NewControllerWithOptions builds.
+type Options struct {
+	// PublicKeyAddress is the address the generated public key is served on over HTTP.
+	// It isn't served if empty.
+	PublicKeyAddress string
+	// ProbeAddress is the address the readiness of the configured signers, storage backends
+	// and transparency logs is served on over HTTP, at /readiness. It isn't served if empty.
+	ProbeAddress string
+	// Namespaces are the namespaces whose TaskRuns the controller watches, each with its own informers,
+	// so that it only needs access to these namespaces. The namespace scope of the context is watched
+	// if Namespaces and NamespaceSelector are empty.
+	Namespaces []string
+	// NamespaceSelector selects the namespaces whose TaskRuns the controller watches by their labels,
+	// in addition to Namespaces.
+	NamespaceSelector labels.Selector
+}
+
+// NewController builds a controller with the default Options.
 func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+	return NewControllerWithOptions(Options{})(ctx, cmw)
+}
+
+// NewControllerWithOptions returns a constructor of controllers configured with opts.
+func NewControllerWithOptions(opts Options) injection.ControllerConstructor {
+	return func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+		return newController(ctx, cmw, opts)
+	}
+}
+
+func newController(ctx context.Context, cmw configmap.Watcher, opts Options) *controller.Impl {
 	logger := logging.FromContext(ctx)
-	taskRunInformer := taskruninformer.Get(ctx)
 
 	c := &Reconciler{
 		TaskRunSigner: &chains.TaskRunSigner{
@@ -37,20 +78,138 @@ func NewController(ctx context.Context, cmw configmap.Watcher) *controller.Impl
 			Pipelineclientset: pipelineclient.Get(ctx),
 			SecretPath:        SecretPath,
 		},
+		Pipelineclientset: pipelineclient.Get(ctx),
+	}
+	publisher := &keygen.Publisher{
+		KubeClient: kubeclient.Get(ctx),
+		Namespace:  system.Namespace(),
+	}
+	if opts.PublicKeyAddress != "" {
+		go func() {
+			logger.Infof("Serving the public key on %s", opts.PublicKeyAddress)
+			if err := http.ListenAndServe(opts.PublicKeyAddress, publisher); err != nil {
+				logger.Errorf("Error serving the public key: %v", err)
+			}
+		}()
+	}
+
+	health := &chains.HealthChecker{
+		KubeClient:        kubeclient.Get(ctx),
+		Pipelineclientset: pipelineclient.Get(ctx),
+		SecretPath:        SecretPath,
+		Namespace:         system.Namespace(),
+	}
+	if opts.ProbeAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/readiness", health)
+		go func() {
+			logger.Infof("Serving the readiness probe on %s", opts.ProbeAddress)
+			if err := http.ListenAndServe(opts.ProbeAddress, mux); err != nil {
+				logger.Errorf("Error serving the readiness probe: %v", err)
+			}
+		}()
+	}
+
+	var taskRuns interface {
+		AddEventHandler(cache.ResourceEventHandler)
+	}
+	var namespaceConfigs corev1listers.ConfigMapLister
+	if len(opts.Namespaces) > 0 || opts.NamespaceSelector != nil {
+		namespaced := watchNamespaced(ctx, opts)
+		logger.Infof("Watching the namespaces %v", namespaced.watched())
+		// The reconciler gets the TaskRuns from the informer in the context.
+		ctx = context.WithValue(ctx, taskruninformer.Key{}, namespaced)
+		taskRuns = namespaced
+		namespaceConfigs = namespaced.ConfigMapLister()
+	} else {
+		taskRuns = taskruninformer.Get(ctx).Informer()
+		namespaceConfigs = watchNamespaceConfigs(ctx)
+	}
+	c.Selector = &Selector{
+		StartNamespaces: func() corev1listers.NamespaceLister {
+			return watchNamespaces(ctx)
+		},
 	}
+
 	impl := taskrunreconciler.NewImpl(ctx, c, func(impl *controller.Impl) controller.Options {
-		cfgStore := config.NewConfigStore(logger)
+		cfgStore := config.NewConfigStore(logger, func(name string, value interface{}) {
+			if cfg, ok := value.(*config.Config); ok && name == config.ChainsConfig {
+				go reconcileKeys(ctx, publisher, *cfg, logger)
+				health.Watch(ctx, *cfg, logger)
+			}
+		})
 		cfgStore.WatchConfigs(cmw)
+		cfgStore.WatchNamespaces(namespaceConfigs)
+		c.ConfigStore = cfgStore
 
 		return controller.Options{
 			// The chains reconciler shouldn't mutate the taskrun's status.
 			SkipStatusUpdates: true,
 			ConfigStore:       cfgStore,
-			FinalizerName:     "chains.tekton.dev",
+			FinalizerName:     finalizerName,
 		}
 	})
 
-	taskRunInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+	watchChainsConfigs(ctx, c.ConfigStore, logger)
+
+	taskRuns.AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: filterTaskRuns(c.ConfigStore, c.Selector),
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
 
 	return impl
 }
+
+// reconcileKeys generates and publishes the signing key, retrying until it succeeds.
+func reconcileKeys(ctx context.Context, publisher *keygen.Publisher, cfg config.Config, logger *zap.SugaredLogger) {
+	backoff := wait.Backoff{Duration: time.Second, Factor: 2, Steps: 10, Cap: 5 * time.Minute}
+	if err := wait.ExponentialBackoffWithContext(ctx, backoff, func() (bool, error) {
+		if err := publisher.Reconcile(ctx, cfg, logger); err != nil {
+			logger.Errorf("Error generating or publishing the signing key: %v", err)
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		logger.Errorf("Giving up generating or publishing the signing key: %v", err)
+	}
+}
+
+// watchNamespaceConfigs starts watching the chains-config ConfigMaps of the namespaces, other
+// than the global one, and returns their lister once they are synced.
+func watchNamespaceConfigs(ctx context.Context) corev1listers.ConfigMapLister {
+	selector := fields.AndSelectors(
+		fields.OneTermEqualSelector("metadata.name", config.ChainsConfig),
+		fields.OneTermNotEqualSelector("metadata.namespace", system.Namespace()),
+	)
+	factory := informers.NewSharedInformerFactoryWithOptions(kubeclient.Get(ctx), controller.GetResyncPeriod(ctx),
+		informers.WithNamespace(injection.GetNamespaceScope(ctx)),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = selector.String()
+		}))
+	lister := factory.Core().V1().ConfigMaps().Lister()
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+	return lister
+}
+
+// watchNamespaced starts watching the TaskRuns and chains-config ConfigMaps of opts.Namespaces, and
+// of the namespaces that opts.NamespaceSelector selects.
+func watchNamespaced(ctx context.Context, opts Options) *namespacedInformers {
+	namespaced := newNamespacedInformers(ctx, pipelineclient.Get(ctx), kubeclient.Get(ctx))
+	for _, ns := range opts.Namespaces {
+		namespaced.add(ns)
+	}
+	if opts.NamespaceSelector != nil {
+		namespaced.watchSelector(opts.NamespaceSelector)
+	}
+	return namespaced
+}
+
+// watchNamespaces starts watching the namespaces, and returns their lister once they are synced.
+func watchNamespaces(ctx context.Context) corev1listers.NamespaceLister {
+	factory := informers.NewSharedInformerFactory(kubeclient.Get(ctx), controller.GetResyncPeriod(ctx))
+	lister := factory.Core().V1().Namespaces().Lister()
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+	return lister
+}
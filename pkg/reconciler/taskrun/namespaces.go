@@ -0,0 +1,295 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskrun
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	versioned "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	pipelineinformers "github.com/tektoncd/pipeline/pkg/client/informers/externalversions"
+	v1beta1informers "github.com/tektoncd/pipeline/pkg/client/informers/externalversions/pipeline/v1beta1"
+	v1beta1listers "github.com/tektoncd/pipeline/pkg/client/listers/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
+)
+
+// namespacedInformers watches the TaskRuns and the chains-config ConfigMaps of a set of
+// namespaces, with informers scoped to each namespace, so that the controller doesn't need
+// cluster-wide access to them. The set changes as namespaces are added and removed.
+type namespacedInformers struct {
+	ctx            context.Context
+	pipelineClient versioned.Interface
+	kubeClient     kubernetes.Interface
+
+	mu         sync.RWMutex
+	namespaces map[string]*namespaceInformers
+	handlers   []cache.ResourceEventHandler
+}
+
+// namespaceInformers are the informers of one namespace.
+type namespaceInformers struct {
+	taskRuns   cache.SharedIndexInformer
+	configMaps cache.SharedIndexInformer
+	cancel     context.CancelFunc
+}
+
+var _ v1beta1informers.TaskRunInformer = (*namespacedInformers)(nil)
+
+func newNamespacedInformers(ctx context.Context, pipelineClient versioned.Interface, kubeClient kubernetes.Interface) *namespacedInformers {
+	return &namespacedInformers{
+		ctx:            ctx,
+		pipelineClient: pipelineClient,
+		kubeClient:     kubeClient,
+		namespaces:     map[string]*namespaceInformers{},
+	}
+}
+
+// add starts watching namespace.
+func (n *namespacedInformers) add(namespace string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.namespaces[namespace]; ok {
+		return
+	}
+	ctx, cancel := context.WithCancel(n.ctx)
+	resync := controller.GetResyncPeriod(ctx)
+
+	pipelineFactory := pipelineinformers.NewSharedInformerFactoryWithOptions(n.pipelineClient, resync,
+		pipelineinformers.WithNamespace(namespace))
+	kubeFactory := informers.NewSharedInformerFactoryWithOptions(n.kubeClient, resync,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", config.ChainsConfig).String()
+		}))
+	ns := &namespaceInformers{
+		taskRuns:   pipelineFactory.Tekton().V1beta1().TaskRuns().Informer(),
+		configMaps: kubeFactory.Core().V1().ConfigMaps().Informer(),
+		cancel:     cancel,
+	}
+	for _, h := range n.handlers {
+		ns.taskRuns.AddEventHandler(h)
+	}
+	pipelineFactory.Start(ctx.Done())
+	kubeFactory.Start(ctx.Done())
+	n.namespaces[namespace] = ns
+}
+
+// remove stops watching namespace, and removes the finalizer from its TaskRuns: their deletion
+// isn't watched anymore, so the reconciler couldn't remove it.
+func (n *namespacedInformers) remove(namespace string) {
+	n.mu.Lock()
+	ns, ok := n.namespaces[namespace]
+	if ok {
+		ns.cancel()
+		delete(n.namespaces, namespace)
+	}
+	n.mu.Unlock()
+	if ok {
+		n.releaseTaskRuns(ns.taskRuns.GetIndexer())
+	}
+}
+
+// releaseTaskRuns removes the finalizer from the TaskRuns of indexer that have it.
+func (n *namespacedInformers) releaseTaskRuns(indexer cache.Indexer) {
+	logger := logging.FromContext(n.ctx)
+	for _, obj := range indexer.List() {
+		cached, ok := obj.(*v1beta1.TaskRun)
+		if !ok || !hasFinalizer(cached) {
+			continue
+		}
+		// The cache may be stale, patch the latest version.
+		client := n.pipelineClient.TektonV1beta1().TaskRuns(cached.Namespace)
+		tr, err := client.Get(n.ctx, cached.Name, metav1.GetOptions{})
+		if err != nil {
+			logger.Errorf("Error getting TaskRun %s/%s to remove its finalizer: %v", cached.Namespace, cached.Name, err)
+			continue
+		}
+		finalizers := []string{}
+		for _, f := range tr.Finalizers {
+			if f != finalizerName {
+				finalizers = append(finalizers, f)
+			}
+		}
+		if len(finalizers) == len(tr.Finalizers) {
+			continue
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"finalizers":      finalizers,
+				"resourceVersion": tr.ResourceVersion,
+			},
+		})
+		if err != nil {
+			logger.Errorf("Error creating the finalizer patch of TaskRun %s/%s: %v", tr.Namespace, tr.Name, err)
+			continue
+		}
+		if _, err := client.Patch(n.ctx, tr.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			logger.Errorf("Error removing the finalizer of TaskRun %s/%s: %v", tr.Namespace, tr.Name, err)
+			continue
+		}
+		logger.Infof("Removed the finalizer of TaskRun %s/%s, its namespace isn't watched anymore", tr.Namespace, tr.Name)
+	}
+}
+
+// watchSelector watches the namespaces matching selector, and returns once the ones that match
+// already are watched.
+func (n *namespacedInformers) watchSelector(selector labels.Selector) {
+	factory := informers.NewSharedInformerFactoryWithOptions(n.kubeClient, controller.GetResyncPeriod(n.ctx),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = selector.String()
+		}))
+	factory.Core().V1().Namespaces().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if ns, ok := obj.(*corev1.Namespace); ok {
+				n.add(ns.Name)
+			}
+		},
+		// Namespaces whose labels stop matching are deleted from the watch.
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if ns, ok := obj.(*corev1.Namespace); ok {
+				n.remove(ns.Name)
+			}
+		},
+	})
+	factory.Start(n.ctx.Done())
+	factory.WaitForCacheSync(n.ctx.Done())
+}
+
+// watched returns the namespaces being watched.
+func (n *namespacedInformers) watched() []string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	namespaces := make([]string, 0, len(n.namespaces))
+	for ns := range n.namespaces {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// AddEventHandler adds a handler of the TaskRuns of all the namespaces, current and future.
+func (n *namespacedInformers) AddEventHandler(h cache.ResourceEventHandler) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.handlers = append(n.handlers, h)
+	for _, ns := range n.namespaces {
+		ns.taskRuns.AddEventHandler(h)
+	}
+}
+
+// Informer implements v1beta1informers.TaskRunInformer. There is no single informer of the
+// TaskRuns of all the namespaces, use AddEventHandler and Lister instead.
+func (n *namespacedInformers) Informer() cache.SharedIndexInformer {
+	return nil
+}
+
+// Lister implements v1beta1informers.TaskRunInformer.
+func (n *namespacedInformers) Lister() v1beta1listers.TaskRunLister {
+	return taskRunLister{n}
+}
+
+// ConfigMapLister lists the chains-config ConfigMaps of the namespaces.
+func (n *namespacedInformers) ConfigMapLister() corev1listers.ConfigMapLister {
+	return configMapLister{n}
+}
+
+// indexers returns the indexer of each namespace, or only of namespace if it isn't empty.
+func (n *namespacedInformers) indexers(namespace string, informer func(*namespaceInformers) cache.SharedIndexInformer) []cache.Indexer {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if namespace != metav1.NamespaceAll {
+		if ns, ok := n.namespaces[namespace]; ok {
+			return []cache.Indexer{informer(ns).GetIndexer()}
+		}
+		return nil
+	}
+	indexers := make([]cache.Indexer, 0, len(n.namespaces))
+	for _, ns := range n.namespaces {
+		indexers = append(indexers, informer(ns).GetIndexer())
+	}
+	return indexers
+}
+
+func taskRunsOf(ns *namespaceInformers) cache.SharedIndexInformer   { return ns.taskRuns }
+func configMapsOf(ns *namespaceInformers) cache.SharedIndexInformer { return ns.configMaps }
+
+// emptyIndexer backs the listers of the namespaces that aren't watched.
+var emptyIndexer = cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+
+type taskRunLister struct {
+	n *namespacedInformers
+}
+
+func (l taskRunLister) List(selector labels.Selector) ([]*v1beta1.TaskRun, error) {
+	var all []*v1beta1.TaskRun
+	for _, indexer := range l.n.indexers(metav1.NamespaceAll, taskRunsOf) {
+		trs, err := v1beta1listers.NewTaskRunLister(indexer).List(selector)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, trs...)
+	}
+	return all, nil
+}
+
+func (l taskRunLister) TaskRuns(namespace string) v1beta1listers.TaskRunNamespaceLister {
+	indexer := emptyIndexer
+	if indexers := l.n.indexers(namespace, taskRunsOf); len(indexers) == 1 {
+		indexer = indexers[0]
+	}
+	return v1beta1listers.NewTaskRunLister(indexer).TaskRuns(namespace)
+}
+
+type configMapLister struct {
+	n *namespacedInformers
+}
+
+func (l configMapLister) List(selector labels.Selector) ([]*corev1.ConfigMap, error) {
+	var all []*corev1.ConfigMap
+	for _, indexer := range l.n.indexers(metav1.NamespaceAll, configMapsOf) {
+		cms, err := corev1listers.NewConfigMapLister(indexer).List(selector)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, cms...)
+	}
+	return all, nil
+}
+
+func (l configMapLister) ConfigMaps(namespace string) corev1listers.ConfigMapNamespaceLister {
+	indexer := emptyIndexer
+	// The chains-config of the system namespace is the global one, not an override.
+	if indexers := l.n.indexers(namespace, configMapsOf); len(indexers) == 1 && namespace != system.Namespace() {
+		indexer = indexers[0]
+	}
+	return corev1listers.NewConfigMapLister(indexer).ConfigMaps(namespace)
+}
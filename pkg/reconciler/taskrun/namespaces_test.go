@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskrun
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	fakepipeline "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+func taskRun(namespace, name string) *v1beta1.TaskRun {
+	return &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+}
+
+func TestNamespacedInformers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pipelineClient := fakepipeline.NewSimpleClientset(taskRun("a", "build"), taskRun("b", "build"), taskRun("c", "build"))
+	kubeClient := fakek8s.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "b", Name: config.ChainsConfig},
+	})
+	n := newNamespacedInformers(ctx, pipelineClient, kubeClient)
+
+	var mu sync.Mutex
+	seen := sets.NewString()
+	n.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			mu.Lock()
+			defer mu.Unlock()
+			seen.Insert(obj.(*v1beta1.TaskRun).Namespace)
+		},
+	})
+	n.add("a")
+	n.add("b")
+
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return seen.Len() == 2, nil
+	}); err != nil {
+		t.Fatalf("handled the TaskRuns of %v, want the ones of [a b]", seen.List())
+	}
+	if diff := cmp.Diff([]string{"a", "b"}, seen.List()); diff != "" {
+		t.Errorf("handled namespaces (-want +got): %s", diff)
+	}
+
+	trs, err := n.Lister().List(labels.Everything())
+	if err != nil || len(trs) != 2 {
+		t.Errorf("List() = %d TaskRuns, %v, want the 2 of the watched namespaces", len(trs), err)
+	}
+	if _, err := n.Lister().TaskRuns("a").Get("build"); err != nil {
+		t.Errorf("Get(a/build) = %v", err)
+	}
+	if _, err := n.Lister().TaskRuns("c").Get("build"); !apierrors.IsNotFound(err) {
+		t.Errorf("Get(c/build) = %v, want not found in a namespace that isn't watched", err)
+	}
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		_, err := n.ConfigMapLister().ConfigMaps("b").Get(config.ChainsConfig)
+		return err == nil, nil
+	}); err != nil {
+		t.Error("the chains-config of b isn't listed")
+	}
+
+	n.remove("a")
+	if diff := cmp.Diff([]string{"b"}, n.watched()); diff != "" {
+		t.Errorf("watched namespaces (-want +got): %s", diff)
+	}
+	if _, err := n.Lister().TaskRuns("a").Get("build"); !apierrors.IsNotFound(err) {
+		t.Errorf("Get(a/build) = %v, want not found once a isn't watched", err)
+	}
+}
+
+func TestNamespacedInformersSelector(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	kubeClient := fakek8s.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "a", Labels: map[string]string{"tenant": "blue"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "b", Labels: map[string]string{"tenant": "green"}}},
+	)
+	n := newNamespacedInformers(ctx, fakepipeline.NewSimpleClientset(), kubeClient)
+	n.watchSelector(labels.SelectorFromSet(labels.Set{"tenant": "blue"}))
+	if diff := cmp.Diff([]string{"a"}, n.watched()); diff != "" {
+		t.Errorf("watched namespaces (-want +got): %s", diff)
+	}
+
+	if _, err := kubeClient.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "c", Labels: map[string]string{"tenant": "blue"}},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := kubeClient.CoreV1().Namespaces().Delete(ctx, "a", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return cmp.Equal([]string{"c"}, n.watched()), nil
+	}); err != nil {
+		t.Errorf("watched namespaces = %v, want [c]", n.watched())
+	}
+}
+
+func TestNamespacedInformersRemoveReleasesTaskRuns(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signing := taskRun("a", "signing")
+	signing.Finalizers = []string{"example.com/other", finalizerName}
+	pipelineClient := fakepipeline.NewSimpleClientset(signing, taskRun("a", "unselected"))
+	n := newNamespacedInformers(ctx, pipelineClient, fakek8s.NewSimpleClientset())
+	n.add("a")
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		trs, err := n.Lister().TaskRuns("a").List(labels.Everything())
+		return len(trs) == 2, err
+	}); err != nil {
+		t.Fatalf("the TaskRuns of a aren't listed: %v", err)
+	}
+
+	// Once a isn't watched, the deletion of its TaskRuns would wait for the finalizer forever.
+	n.remove("a")
+	tr, err := pipelineClient.TektonV1beta1().TaskRuns("a").Get(ctx, "signing", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"example.com/other"}, tr.Finalizers); diff != "" {
+		t.Errorf("finalizers (-want +got): %s", diff)
+	}
+	for _, a := range pipelineClient.Actions() {
+		if p, ok := a.(ktesting.PatchAction); ok && p.GetName() != "signing" {
+			t.Errorf("patched %s, want only the TaskRun with the finalizer", p.GetName())
+		}
+	}
+}
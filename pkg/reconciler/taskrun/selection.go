@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskrun
+
+import (
+	"fmt"
+	"sync"
+
+	signing "github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+)
+
+// Selector decides which TaskRuns are signed, according to config.SelectionConfig.
+type Selector struct {
+	// StartNamespaces starts watching the namespaces and returns their lister once synced. It is
+	// only called once a namespace selector needs the labels of the namespaces.
+	StartNamespaces func() corev1listers.NamespaceLister
+
+	once       sync.Once
+	namespaces corev1listers.NamespaceLister
+}
+
+// Selects returns whether cfg selects tr for signing, or else why not.
+func (s *Selector) Selects(cfg *config.Config, tr *v1beta1.TaskRun) (bool, string, error) {
+	if tr.Annotations[signing.SignAnnotation] == "false" {
+		return false, fmt.Sprintf("it has the %s: \"false\" annotation", signing.SignAnnotation), nil
+	}
+	if selector := cfg.Selection.TaskRuns; selector != nil && !selector.Matches(labels.Set(tr.Labels)) {
+		return false, fmt.Sprintf("its labels don't match %q", selector), nil
+	}
+	if selector := cfg.Selection.Namespaces; selector != nil {
+		s.once.Do(func() {
+			s.namespaces = s.StartNamespaces()
+		})
+		ns, err := s.namespaces.Get(tr.Namespace)
+		if err != nil {
+			return false, "", fmt.Errorf("getting namespace %s: %w", tr.Namespace, err)
+		}
+		if !selector.Matches(labels.Set(ns.Labels)) {
+			return false, fmt.Sprintf("the labels of its namespace don't match %q", selector), nil
+		}
+	}
+	return true, "", nil
+}
+
+// filterTaskRuns returns a filter of the TaskRuns that need reconciling: the ones that are selected
+// for signing, and the ones that still have the finalizer from when they were. TaskRuns that can't
+// be checked are reconciled, which retries the check.
+func filterTaskRuns(cfgStore *config.ConfigStore, selector *Selector) func(obj interface{}) bool {
+	return func(obj interface{}) bool {
+		tr, ok := obj.(*v1beta1.TaskRun)
+		if !ok {
+			return false
+		}
+		if hasFinalizer(tr) {
+			return true
+		}
+		selected, _, err := selector.Selects(cfgStore.Load(), tr)
+		return selected || err != nil
+	}
+}
+
+// hasFinalizer returns whether tr has the finalizer of chains.
+func hasFinalizer(tr *v1beta1.TaskRun) bool {
+	for _, f := range tr.Finalizers {
+		if f == finalizerName {
+			return true
+		}
+	}
+	return false
+}
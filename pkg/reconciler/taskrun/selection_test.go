@@ -0,0 +1,129 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package taskrun
+
+import (
+	"testing"
+
+	"github.com/tektoncd/chains/pkg/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func namespaceLister(t *testing.T, namespaces ...*corev1.Namespace) corev1listers.NamespaceLister {
+	t.Helper()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ns := range namespaces {
+		if err := indexer.Add(ns); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return corev1listers.NewNamespaceLister(indexer)
+}
+
+func TestSelectorSelects(t *testing.T) {
+	namespaces := namespaceLister(t,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "signed", Labels: map[string]string{"chains": "enabled"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "unsigned"}},
+	)
+	tests := []struct {
+		name        string
+		data        map[string]string
+		namespace   string
+		labels      map[string]string
+		annotations map[string]string
+		want        bool
+	}{{
+		name: "no selectors",
+		want: true,
+	}, {
+		name:        "opted out",
+		annotations: map[string]string{"chains.tekton.dev/sign": "false"},
+		want:        false,
+	}, {
+		name:        "not opted out",
+		annotations: map[string]string{"chains.tekton.dev/sign": "true"},
+		want:        true,
+	}, {
+		name:   "taskrun selected",
+		data:   map[string]string{"selection.taskrun-selector": "app in (web, api)"},
+		labels: map[string]string{"app": "web"},
+		want:   true,
+	}, {
+		name:   "taskrun not selected",
+		data:   map[string]string{"selection.taskrun-selector": "app in (web, api)"},
+		labels: map[string]string{"app": "db"},
+		want:   false,
+	}, {
+		name:      "namespace selected",
+		data:      map[string]string{"selection.namespace-selector": "chains=enabled"},
+		namespace: "signed",
+		want:      true,
+	}, {
+		name:      "namespace not selected",
+		data:      map[string]string{"selection.namespace-selector": "chains=enabled"},
+		namespace: "unsigned",
+		want:      false,
+	}, {
+		name:        "opted out in a selected namespace",
+		data:        map[string]string{"selection.namespace-selector": "chains=enabled"},
+		namespace:   "signed",
+		annotations: map[string]string{"chains.tekton.dev/sign": "false"},
+		want:        false,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := config.NewConfigFromMap(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			started := false
+			s := &Selector{StartNamespaces: func() corev1listers.NamespaceLister {
+				started = true
+				return namespaces
+			}}
+			tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{
+				Name:        "taskrun",
+				Namespace:   tt.namespace,
+				Labels:      tt.labels,
+				Annotations: tt.annotations,
+			}}
+			got, reason, err := s.Selects(cfg, tr)
+			if err != nil {
+				t.Fatalf("Selects() = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Selects() = %v (%s), want %v", got, reason, tt.want)
+			}
+			if started != (cfg.Selection.Namespaces != nil && tt.annotations == nil) {
+				t.Errorf("namespaces watched = %v, want them only watched for a namespace selector", started)
+			}
+		})
+	}
+}
+
+func TestSelectorUnknownNamespace(t *testing.T) {
+	cfg, err := config.NewConfigFromMap(map[string]string{"selection.namespace-selector": "chains=enabled"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Selector{StartNamespaces: func() corev1listers.NamespaceLister { return namespaceLister(t) }}
+	tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "taskrun", Namespace: "missing"}}
+	if _, _, err := s.Selects(cfg, tr); err == nil {
+		t.Error("Selects() = nil, want an error for a namespace that isn't listed yet")
+	}
+}
@@ -17,7 +17,9 @@ import (
 	"context"
 
 	signing "github.com/tektoncd/chains/pkg/chains"
+	"github.com/tektoncd/chains/pkg/config"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	versioned "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 	taskrunreconciler "github.com/tektoncd/pipeline/pkg/client/injection/reconciler/pipeline/v1beta1/taskrun"
 	"knative.dev/pkg/logging"
 	pkgreconciler "knative.dev/pkg/reconciler"
@@ -26,10 +28,20 @@ import (
 const (
 	// SecretPath contains the path to the secrets volume that is mounted in.
 	SecretPath = "/etc/signing-secrets"
+
+	// finalizerName is the finalizer that keeps TaskRuns around until they are signed.
+	finalizerName = "chains.tekton.dev"
 )
 
 type Reconciler struct {
 	TaskRunSigner signing.Signer
+	// ConfigStore overrides the configuration with the chains-config of the namespace of each
+	// TaskRun, if set.
+	ConfigStore *config.ConfigStore
+	// Selector skips the TaskRuns that the configuration doesn't select for signing, if set.
+	Selector *Selector
+	// Pipelineclientset marks the TaskRuns that the sign-on policy skips.
+	Pipelineclientset versioned.Interface
 }
 
 // Check that our Reconciler implements taskrunreconciler.Interface and taskrunreconciler.Finalizer
@@ -47,6 +59,19 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, tr *v1beta1.TaskRun) pkg
 // that we see flowing through the system.  If we don't add a finalizer, it could
 // get cleaned up before we see the final state and sign it.
 func (r *Reconciler) FinalizeKind(ctx context.Context, tr *v1beta1.TaskRun) pkgreconciler.Event {
+	// Check the TaskRun is selected for signing. The ones that aren't are normally filtered out
+	// before they get the finalizer, unless the configuration changed since.
+	if r.Selector != nil {
+		selected, reason, err := r.Selector.Selects(config.FromContext(ctx), tr)
+		if err != nil {
+			logging.FromContext(ctx).Errorf("taskrun %s/%s can't be selected: %v", tr.Namespace, tr.Name, err)
+			return err
+		}
+		if !selected {
+			logging.FromContext(ctx).Infof("taskrun %s/%s isn't selected for signing: %s", tr.Namespace, tr.Name, reason)
+			return nil
+		}
+	}
 	// Check to make sure the TaskRun is finished.
 	if !tr.IsDone() {
 		logging.FromContext(ctx).Infof("taskrun %s/%s is still running", tr.Namespace, tr.Name)
@@ -58,6 +83,22 @@ func (r *Reconciler) FinalizeKind(ctx context.Context, tr *v1beta1.TaskRun) pkgr
 		return nil
 	}
 
+	// The sign-on policy is the global one, namespaces can't override it. The TaskRuns it skips are
+	// marked, so they aren't reconciled again.
+	if cfg := config.FromContext(ctx); cfg.SignOn == config.SignOnSuccess && !tr.IsSuccessful() {
+		logging.FromContext(ctx).Infof("taskrun %s/%s didn't succeed and sign-on is %q, not signing it", tr.Namespace, tr.Name, cfg.SignOn)
+		return signing.MarkSkipped(tr, r.Pipelineclientset, nil)
+	}
+
+	if r.ConfigStore != nil {
+		nsCtx, err := r.ConfigStore.ToNamespaceContext(ctx, tr.Namespace)
+		if err != nil {
+			logging.FromContext(ctx).Errorf("taskrun %s/%s can't be signed: %v", tr.Namespace, tr.Name, err)
+			return err
+		}
+		ctx = nsCtx
+	}
+
 	if err := r.TaskRunSigner.SignTaskRun(ctx, tr); err != nil {
 		return err
 	}
@@ -17,6 +17,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	signing "github.com/tektoncd/chains/pkg/chains"
 	"github.com/tektoncd/chains/pkg/config"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
@@ -26,10 +27,13 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 	"knative.dev/pkg/apis"
 	duckv1beta1 "knative.dev/pkg/apis/duck/v1beta1"
 	_ "knative.dev/pkg/client/injection/kube/client/fake"
 	"knative.dev/pkg/configmap"
+	logtesting "knative.dev/pkg/logging/testing"
 	pkgreconciler "knative.dev/pkg/reconciler"
 	rtesting "knative.dev/pkg/reconciler/testing"
 	"knative.dev/pkg/system"
@@ -63,6 +67,7 @@ func TestReconciler_Reconcile(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 
 			ctx, _ := rtesting.SetupFakeContext(t)
+			ctx, _ = withChainsConfigs(ctx, t)
 			setupData(ctx, t, tt.taskRuns)
 
 			configMapWatcher := configmap.NewStaticWatcher(&corev1.ConfigMap{
@@ -86,6 +91,43 @@ func TestReconciler_Reconcile(t *testing.T) {
 	}
 }
 
+func TestNewControllerWithOptions(t *testing.T) {
+	ctx, _ := rtesting.SetupFakeContext(t)
+	ctx, _ = withChainsConfigs(ctx, t)
+	setupData(ctx, t, []*v1beta1.TaskRun{
+		{ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "foo"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "other"}},
+	})
+	configMapWatcher := configmap.NewStaticWatcher(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: system.Namespace(),
+			Name:      config.ChainsConfig,
+		},
+	})
+
+	// The controller only sees the TaskRuns of the namespaces in its options.
+	ctl := NewControllerWithOptions(Options{Namespaces: []string{"foo"}})(ctx, configMapWatcher)
+	if la, ok := ctl.Reconciler.(pkgreconciler.LeaderAware); ok {
+		if err := la.Promote(pkgreconciler.UniversalBucket(), func(pkgreconciler.Bucket, types.NamespacedName) {}); err != nil {
+			t.Fatalf("Promote() = %v", err)
+		}
+	}
+	for _, key := range []string{"foo/bar", "other/bar"} {
+		if err := ctl.Reconciler.Reconcile(ctx, key); err != nil {
+			t.Errorf("Reconcile(%s) error = %v", key, err)
+		}
+	}
+	var patched []string
+	for _, a := range fakepipelineclient.Get(ctx).Actions() {
+		if a.GetVerb() == "patch" {
+			patched = append(patched, a.GetNamespace())
+		}
+	}
+	if diff := cmp.Diff([]string{"foo"}, patched); diff != "" {
+		t.Errorf("patched namespaces (-want +got): %s", diff)
+	}
+}
+
 func setupData(ctx context.Context, t *testing.T, trs []*v1beta1.TaskRun) informers.TaskRunInformer {
 	tri := faketaskruninformer.Get(ctx)
 	c := fakepipelineclient.Get(ctx)
@@ -151,6 +193,7 @@ func TestReconciler_handleTaskRun(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			signer := &mockSigner{}
 			ctx, _ := rtesting.SetupFakeContext(t)
+			ctx = config.ToContext(ctx, &config.Config{})
 
 			r := &Reconciler{
 				TaskRunSigner: signer,
@@ -165,11 +208,156 @@ func TestReconciler_handleTaskRun(t *testing.T) {
 	}
 }
 
+func TestReconciler_NamespaceConfig(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: config.ChainsConfig},
+		Data:       map[string]string{"builder.id": "https://example.com/team-a"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	global, err := config.NewConfigFromMap(map[string]string{"namespace-overrides.allowed-keys": "builder.id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfgStore := config.NewConfigStore(logtesting.TestLogger(t))
+	cfgStore.WatchNamespaces(corev1listers.NewConfigMapLister(indexer))
+
+	for ns, want := range map[string]string{
+		"team-a": "https://example.com/team-a",
+		"team-b": "https://tekton.dev/chains/v2",
+	} {
+		signer := &mockSigner{withConfig: true}
+		r := &Reconciler{TaskRunSigner: signer, ConfigStore: cfgStore}
+		tr := &v1beta1.TaskRun{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: "build"},
+			Status: v1beta1.TaskRunStatus{
+				Status: duckv1beta1.Status{
+					Conditions: []apis.Condition{{Type: apis.ConditionSucceeded}},
+				}},
+		}
+		if err := r.ReconcileKind(config.ToContext(context.Background(), global), tr); err != nil {
+			t.Fatalf("Reconciler.ReconcileKind() error = %v", err)
+		}
+		if signer.builderID != want {
+			t.Errorf("TaskRun in %s signed with builder ID %q, want %q", ns, signer.builderID, want)
+		}
+	}
+}
+
+func TestReconciler_Selection(t *testing.T) {
+	cfg, err := config.NewConfigFromMap(map[string]string{"selection.taskrun-selector": "chains.tekton.dev/sign-me"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name       string
+		labels     map[string]string
+		shouldSign bool
+	}{
+		{name: "selected", labels: map[string]string{"chains.tekton.dev/sign-me": ""}, shouldSign: true},
+		{name: "not selected", shouldSign: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := &mockSigner{}
+			r := &Reconciler{TaskRunSigner: signer, Selector: &Selector{}}
+			tr := &v1beta1.TaskRun{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "build", Labels: tt.labels},
+				Status: v1beta1.TaskRunStatus{
+					Status: duckv1beta1.Status{
+						Conditions: []apis.Condition{{Type: apis.ConditionSucceeded}},
+					}},
+			}
+			if err := r.ReconcileKind(config.ToContext(context.Background(), cfg), tr); err != nil {
+				t.Errorf("Reconciler.ReconcileKind() error = %v", err)
+			}
+			if signer.signed != tt.shouldSign {
+				t.Errorf("Reconciler.ReconcileKind() signed = %v, wanted %v", signer.signed, tt.shouldSign)
+			}
+		})
+	}
+}
+
+func TestReconciler_SignOn(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		signOn      string
+		status      corev1.ConditionStatus
+		namespace   map[string]string
+		shouldSign  bool
+		wantSkipped bool
+	}{
+		{name: "always, failed", signOn: config.SignOnAlways, status: corev1.ConditionFalse, shouldSign: true},
+		{name: "success, failed", signOn: config.SignOnSuccess, status: corev1.ConditionFalse, wantSkipped: true},
+		{name: "success, succeeded", signOn: config.SignOnSuccess, status: corev1.ConditionTrue, shouldSign: true},
+		{
+			// The chains-config of the namespace can't relax the global policy.
+			name:        "success, failed, overridden",
+			signOn:      config.SignOnSuccess,
+			status:      corev1.ConditionFalse,
+			namespace:   map[string]string{"sign-on": config.SignOnAlways},
+			wantSkipped: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			global, err := config.NewConfigFromMap(map[string]string{
+				"sign-on":                          tt.signOn,
+				"namespace-overrides.allowed-keys": "builder.id",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: config.ChainsConfig},
+				Data:       tt.namespace,
+			}); err != nil {
+				t.Fatal(err)
+			}
+			cfgStore := config.NewConfigStore(logtesting.TestLogger(t))
+			cfgStore.WatchNamespaces(corev1listers.NewConfigMapLister(indexer))
+
+			ctx, _ := rtesting.SetupFakeContext(t)
+			ps := fakepipelineclient.Get(ctx)
+			tr := &v1beta1.TaskRun{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "build"}}
+			tr.Status.SetCondition(&apis.Condition{Type: apis.ConditionSucceeded, Status: tt.status, Reason: "Failed"})
+			if _, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Create(ctx, tr, metav1.CreateOptions{}); err != nil {
+				t.Fatal(err)
+			}
+
+			signer := &mockSigner{}
+			r := &Reconciler{TaskRunSigner: signer, ConfigStore: cfgStore, Pipelineclientset: ps}
+			if err := r.ReconcileKind(config.ToContext(ctx, global), tr); err != nil {
+				t.Fatalf("Reconciler.ReconcileKind() error = %v", err)
+			}
+			if signer.signed != tt.shouldSign {
+				t.Errorf("Reconciler.ReconcileKind() signed = %v, wanted %v", signer.signed, tt.shouldSign)
+			}
+			got, err := ps.TektonV1beta1().TaskRuns(tr.Namespace).Get(ctx, tr.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if skipped := got.Annotations[signing.ChainsAnnotation] == "skipped"; skipped != tt.wantSkipped {
+				t.Errorf("skipped = %v, wanted %v", skipped, tt.wantSkipped)
+			}
+			if tt.wantSkipped && !signing.Reconciled(got) {
+				t.Error("a skipped TaskRun isn't reconciled")
+			}
+		})
+	}
+}
+
 type mockSigner struct {
 	signed bool
+	// withConfig records the builder ID of the configuration the TaskRun is signed with.
+	withConfig bool
+	builderID  string
 }
 
 func (m *mockSigner) SignTaskRun(ctx context.Context, tr *v1beta1.TaskRun) error {
 	m.signed = true
+	if m.withConfig {
+		m.builderID = config.FromContext(ctx).Builder.ID
+	}
 	return nil
 }
@@ -378,7 +378,7 @@ func TestMultiBackendStorage(t *testing.T) {
 		"artifacts.oci.signer":            "x509",
 		"artifacts.taskrun.format":        "tekton",
 		"artifacts.taskrun.signer":        "x509",
-		"artifacts.taskrun.storage":       "tekton,oci",
+		"artifacts.taskrun.storage":       "tekton",
 		"storage.oci.repository.insecure": "true",
 	})
 	defer resetConfig()